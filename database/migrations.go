@@ -2,9 +2,11 @@ package database
 
 import (
 	"log"
+	"strings"
 
 	"gorm.io/gorm"
 	"olimpo-vicedecanatura/models"
+	"olimpo-vicedecanatura/parser"
 )
 
 // RunMigrations ejecuta las migraciones de la base de datos
@@ -15,6 +17,9 @@ func RunMigrations(db *gorm.DB) {
 		&models.StudyPlan{},
 		&models.Subject{},
 		&models.Equivalence{},
+		&models.SubjectAlias{},
+		&models.TypologySynonym{},
+		&models.ElectiveGroup{},
 	)
 	if err != nil {
 		log.Fatalf("Error ejecutando migraciones: %v", err)
@@ -54,6 +59,12 @@ func RunMigrations(db *gorm.DB) {
 		log.Println("Migración de equivalences completada")
 	}
 
+	// Los tipos de equivalencia se validan en mayúsculas (TOTAL, PARCIAL); las equivalencias
+	// anteriores guardaban el tipo como texto libre
+	if err := db.Exec("UPDATE equivalences SET type = UPPER(TRIM(type)) WHERE type <> UPPER(TRIM(type));").Error; err != nil {
+		log.Printf("Error normalizando tipos de equivalencia: %v", err)
+	}
+
 	// Crear índices adicionales si son necesarios
 	// Por ejemplo, para búsquedas frecuentes por código de materia
 	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_subjects_code ON subjects(code);").Error; err != nil {
@@ -76,6 +87,16 @@ func RunMigrations(db *gorm.DB) {
 	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_equivalences_target_subject_id ON equivalences(target_subject_id);").Error; err != nil {
 		log.Printf("Error creando índice: %v", err)
 	}
+
+	// Índice para buscar los alias de una materia
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_subject_aliases_subject_id ON subject_aliases(subject_id);").Error; err != nil {
+		log.Printf("Error creando índice: %v", err)
+	}
+
+	// Índice para buscar las agrupaciones de optativas de un plan
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_elective_groups_study_plan_id ON elective_groups(study_plan_id);").Error; err != nil {
+		log.Printf("Error creando índice: %v", err)
+	}
 }
 
 // SeedInitialData inserta datos iniciales en la base de datos
@@ -112,4 +133,24 @@ func SeedInitialData(db *gorm.DB) {
 
 	// Nota: Los planes de estudio y materias se pueden cargar desde archivos JSON
 	// o mediante una interfaz administrativa
-} 
\ No newline at end of file
+}
+
+// SeedTypologySynonyms registra las etiquetas de tipología del SIA si la tabla de sinónimos está vacía
+func SeedTypologySynonyms(db *gorm.DB) {
+	var count int64
+	db.Model(&models.TypologySynonym{}).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	for label, tipologia := range parser.DefaultTipologiaSynonyms() {
+		synonym := models.TypologySynonym{
+			Label:     strings.ToUpper(label),
+			Tipologia: tipologia,
+			Notes:     "Etiqueta del SIA",
+		}
+		if err := db.Create(&synonym).Error; err != nil {
+			log.Printf("Error creando sinónimo de tipología %s: %v", label, err)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package functions
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"olimpo-vicedecanatura/models"
+)
+
+// codeResolver traduce los códigos de la historia académica a los códigos canónicos del catálogo
+// usando primero la tabla de alias y luego las reglas de normalización
+type codeResolver struct {
+	exact   map[string]bool     // Códigos canónicos tal como están en el catálogo
+	known   map[string]string   // Código normalizado -> código canónico
+	base    map[string][]string // Código normalizado sin sufijo de sede -> códigos canónicos
+	aliases map[string]string   // Alias normalizado -> código canónico
+}
+
+// newCodeResolver construye el resolutor para los códigos canónicos indicados
+// (materias del plan y materias de las equivalencias) junto con todos los alias registrados
+func newCodeResolver(db *gorm.DB, canonicalCodes []string) *codeResolver {
+	r := &codeResolver{
+		exact:   make(map[string]bool),
+		known:   make(map[string]string),
+		base:    make(map[string][]string),
+		aliases: make(map[string]string),
+	}
+	for _, code := range canonicalCodes {
+		if r.exact[code] {
+			continue
+		}
+		r.exact[code] = true
+		if _, exists := r.known[models.NormalizarCodigo(code)]; !exists {
+			r.known[models.NormalizarCodigo(code)] = code
+		}
+		base := models.CodigoBase(code)
+		r.base[base] = append(r.base[base], code)
+	}
+
+	var aliases []models.SubjectAlias
+	db.Preload("Subject").Find(&aliases)
+	for _, alias := range aliases {
+		r.aliases[models.NormalizarCodigo(alias.Alias)] = alias.Subject.Code
+	}
+	return r
+}
+
+// resolve retorna el código canónico de un código de la historia académica. Si el código se
+// resolvió por alias o normalización también retorna cómo se hizo; si no se reconoce, se
+// retorna sin cambios para que la comparación lo trate como una materia fuera del catálogo.
+func (r *codeResolver) resolve(code string) (string, *models.CodeResolution) {
+	trimmed := strings.TrimSpace(code)
+	if r.exact[trimmed] {
+		return trimmed, nil
+	}
+
+	resolution := func(canonical, rule string) (string, *models.CodeResolution) {
+		return canonical, &models.CodeResolution{HistoryCode: trimmed, CanonicalCode: canonical, Rule: rule}
+	}
+
+	normalized := models.NormalizarCodigo(trimmed)
+	if canonical, exists := r.aliases[normalized]; exists {
+		return resolution(canonical, models.ResolucionAlias)
+	}
+	if canonical, exists := r.known[normalized]; exists {
+		return resolution(canonical, models.ResolucionNormalizacion)
+	}
+	// Un código sin sufijo de sede se resuelve por su base solo si no hay ambigüedad; uno con otro
+	// sufijo (por ejemplo "1000003-B" frente a "1000003-M") es otra materia y solo se resuelve por alias
+	if base := models.CodigoBase(trimmed); base == normalized {
+		if candidates := r.base[base]; len(candidates) == 1 {
+			return resolution(candidates[0], models.ResolucionNormalizacion)
+		}
+	}
+	return trimmed, nil
+}
+
+// resolveSubjects retorna una copia de las materias con los códigos canónicos y la resolución
+// aplicada, por código canónico (la del primer intento que la necesitó) y por código de la historia
+// (cada variante con la que aparece la materia, para explicar la decisión de cada una)
+func (r *codeResolver) resolveSubjects(subjects []models.SubjectInput) ([]models.SubjectInput, map[string]*models.CodeResolution, map[string]*models.CodeResolution) {
+	resolved := make([]models.SubjectInput, len(subjects))
+	byCanonical := make(map[string]*models.CodeResolution)
+	byHistory := make(map[string]*models.CodeResolution)
+	for i, subject := range subjects {
+		code, resolution := r.resolve(subject.Code)
+		subject.Code = code
+		resolved[i] = subject
+		if resolution == nil {
+			continue
+		}
+		if _, exists := byCanonical[code]; !exists {
+			byCanonical[code] = resolution
+		}
+		byHistory[resolution.HistoryCode] = resolution
+	}
+	return resolved, byCanonical, byHistory
+}
+
+// ===== CRUD FUNCTIONS FOR SUBJECT ALIASES =====
+
+// CreateSubjectAlias registra un código alterno para la materia con el código canónico indicado
+func CreateSubjectAlias(db *gorm.DB, alias, subjectCode, notes string) (*models.SubjectAlias, error) {
+	normalized := models.NormalizarCodigo(alias)
+	if normalized == "" || strings.TrimSpace(subjectCode) == "" {
+		return nil, errors.New("alias and subject_code are required")
+	}
+
+	var subject models.Subject
+	if err := db.Where("code = ?", strings.TrimSpace(subjectCode)).First(&subject).Error; err != nil {
+		return nil, errors.New("subject not found")
+	}
+	if err := validateAlias(db, normalized, 0); err != nil {
+		return nil, err
+	}
+
+	subjectAlias := models.SubjectAlias{
+		Alias:     normalized,
+		SubjectID: subject.ID,
+		Notes:     notes,
+	}
+	if err := db.Create(&subjectAlias).Error; err != nil {
+		return nil, errors.New("failed to create subject alias: " + err.Error())
+	}
+
+	db.Preload("Subject").First(&subjectAlias, subjectAlias.ID)
+	return &subjectAlias, nil
+}
+
+// validateAlias verifica que el alias no esté registrado y que no sea el código de otra materia
+func validateAlias(db *gorm.DB, normalized string, excludeID uint) error {
+	var existingAlias models.SubjectAlias
+	if err := db.Where("alias = ? AND id <> ?", normalized, excludeID).First(&existingAlias).Error; err == nil {
+		return errors.New("alias already exists")
+	}
+	var existingSubject models.Subject
+	if err := db.Where("code = ?", normalized).First(&existingSubject).Error; err == nil {
+		return errors.New("alias matches the code of an existing subject")
+	}
+	return nil
+}
+
+// GetAllSubjectAliases obtiene todos los alias de códigos
+func GetAllSubjectAliases(db *gorm.DB) ([]models.SubjectAlias, error) {
+	var aliases []models.SubjectAlias
+	if err := db.Preload("Subject").Order("alias").Find(&aliases).Error; err != nil {
+		return nil, errors.New("failed to fetch subject aliases: " + err.Error())
+	}
+	return aliases, nil
+}
+
+// GetSubjectAliasByID obtiene un alias por su ID
+func GetSubjectAliasByID(db *gorm.DB, aliasID uint) (*models.SubjectAlias, error) {
+	var alias models.SubjectAlias
+	if err := db.Preload("Subject").First(&alias, aliasID).Error; err != nil {
+		return nil, errors.New("subject alias not found")
+	}
+	return &alias, nil
+}
+
+// UpdateSubjectAlias actualiza el código alterno, la materia canónica o las notas de un alias
+func UpdateSubjectAlias(db *gorm.DB, aliasID uint, updates struct {
+	Alias       string `json:"alias"`
+	SubjectCode string `json:"subject_code"`
+	Notes       string `json:"notes"`
+}) (*models.SubjectAlias, error) {
+	var alias models.SubjectAlias
+	if err := db.First(&alias, aliasID).Error; err != nil {
+		return nil, errors.New("subject alias not found")
+	}
+
+	if updates.Alias != "" {
+		normalized := models.NormalizarCodigo(updates.Alias)
+		if err := validateAlias(db, normalized, alias.ID); err != nil {
+			return nil, err
+		}
+		alias.Alias = normalized
+	}
+	if updates.SubjectCode != "" {
+		var subject models.Subject
+		if err := db.Where("code = ?", strings.TrimSpace(updates.SubjectCode)).First(&subject).Error; err != nil {
+			return nil, errors.New("subject not found")
+		}
+		alias.SubjectID = subject.ID
+	}
+	if updates.Notes != "" {
+		alias.Notes = updates.Notes
+	}
+
+	if err := db.Save(&alias).Error; err != nil {
+		return nil, errors.New("failed to update subject alias: " + err.Error())
+	}
+
+	db.Preload("Subject").First(&alias, alias.ID)
+	return &alias, nil
+}
+
+// DeleteSubjectAlias elimina un alias (la materia canónica no se modifica)
+func DeleteSubjectAlias(db *gorm.DB, aliasID uint) error {
+	var alias models.SubjectAlias
+	if err := db.First(&alias, aliasID).Error; err != nil {
+		return errors.New("subject alias not found")
+	}
+	if err := db.Delete(&alias).Error; err != nil {
+		return errors.New("failed to delete subject alias: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package functions
+
+import (
+	"sort"
+
+	"gorm.io/gorm"
+	"olimpo-vicedecanatura/models"
+)
+
+// ExploreCareers compara una historia académica con el plan activo de cada carrera y retorna las
+// carreras ordenadas por porcentaje de avance y créditos homologables, para responder en qué carrera
+// perdería menos créditos el estudiante. Las carreras sin plan activo se retornan aparte.
+func ExploreCareers(db *gorm.DB, subjects []models.SubjectInput) ([]models.CareerOption, []models.SkippedCareer, error) {
+	var careers []models.Career
+	if err := db.Order("code").Find(&careers).Error; err != nil {
+		return nil, nil, err
+	}
+
+	options := []models.CareerOption{}
+	skipped := []models.SkippedCareer{}
+	for _, career := range careers {
+		studyPlan, err := GetStudyPlanByCareerCode(db, career.Code)
+		if err != nil {
+			skipped = append(skipped, models.SkippedCareer{CareerCode: career.Code, CareerName: career.Name, Reason: err.Error()})
+			continue
+		}
+		history := models.AcademicHistoryInput{CareerCode: career.Code, Subjects: subjects}
+		result, err := CompareAcademicHistoryWithStudyPlan(db, history, studyPlan.ID)
+		if err != nil {
+			skipped = append(skipped, models.SkippedCareer{CareerCode: career.Code, CareerName: career.Name, Reason: err.Error()})
+			continue
+		}
+
+		unused := 0
+		for _, entry := range result.UnusedCredits {
+			unused += entry.Credits
+		}
+		options = append(options, models.CareerOption{
+			CareerCode:           career.Code,
+			CareerName:           career.Name,
+			StudyPlanID:          studyPlan.ID,
+			StudyPlanVersion:     studyPlan.Version,
+			CompletionPercentage: result.CreditsSummary.CompletionPercentage(),
+			HomologableCredits:   result.CreditsSummary.Total.Completed,
+			MissingCredits:       result.CreditsSummary.Total.Missing,
+			UnusedCredits:        unused,
+			ApprovedSubjects:     len(result.EquivalentSubjects),
+			MissingSubjects:      len(result.MissingSubjects),
+			CreditsSummary:       result.CreditsSummary,
+		})
+	}
+
+	// Mayor avance primero; a igual avance, más créditos homologables y luego por código de carrera
+	sort.SliceStable(options, func(i, j int) bool {
+		if options[i].CompletionPercentage != options[j].CompletionPercentage {
+			return options[i].CompletionPercentage > options[j].CompletionPercentage
+		}
+		return options[i].HomologableCredits > options[j].HomologableCredits
+	})
+	for i := range options {
+		options[i].Rank = i + 1
+	}
+	return options, skipped, nil
+}
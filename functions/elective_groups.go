@@ -0,0 +1,301 @@
+package functions
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"olimpo-vicedecanatura/models"
+)
+
+// electivePools reparte las optativas del plan en agrupaciones para la comparación. Cada optativa
+// suma a la primera agrupación que la contiene; las que no están en ninguna forman una agrupación
+// implícita por tipología que exige los créditos del plan no cubiertos por las agrupaciones.
+type electivePools struct {
+	groups []*models.ElectiveGroupResult
+	byCode map[string]*models.ElectiveGroupResult
+}
+
+// loadElectivePools carga las agrupaciones de optativas del plan de estudio
+func loadElectivePools(db *gorm.DB, studyPlan models.StudyPlan) *electivePools {
+	var groups []models.ElectiveGroup
+	db.Preload("Subjects").Where("study_plan_id = ?", studyPlan.ID).Order("id").Find(&groups)
+	return newElectivePools(groups, studyPlan)
+}
+
+func newElectivePools(groups []models.ElectiveGroup, studyPlan models.StudyPlan) *electivePools {
+	pools := &electivePools{byCode: make(map[string]*models.ElectiveGroupResult)}
+
+	groupedCredits := make(map[models.TipologiaAsignatura]int)
+	for _, group := range groups {
+		result := pools.add(group.ID, group.Name, group.Tipologia, group.MinCredits)
+		groupedCredits[group.Tipologia] += group.MinCredits
+		for _, subject := range group.Subjects {
+			if _, assigned := pools.byCode[subject.Code]; !assigned {
+				pools.byCode[subject.Code] = result
+			}
+		}
+	}
+
+	planCredits := map[models.TipologiaAsignatura]int{
+		models.TipologiaFundamentalOptativa: studyPlan.FundOptativaCredits,
+		models.TipologiaDisciplinarOptativa: studyPlan.DisOptativaCredits,
+	}
+	implicit := make(map[models.TipologiaAsignatura]*models.ElectiveGroupResult)
+	for _, subject := range studyPlan.Subjects {
+		if _, assigned := pools.byCode[subject.Code]; assigned || !subject.Type.EsOptativa() {
+			continue
+		}
+		group, exists := implicit[subject.Type]
+		if !exists {
+			minCredits := planCredits[subject.Type] - groupedCredits[subject.Type]
+			if minCredits < 0 {
+				minCredits = 0
+			}
+			group = pools.add(0, "Optativas sin agrupación ("+string(subject.Type)+")", subject.Type, minCredits)
+			implicit[subject.Type] = group
+		}
+		pools.byCode[subject.Code] = group
+	}
+	return pools
+}
+
+func (p *electivePools) add(id uint, name string, tipologia models.TipologiaAsignatura, minCredits int) *models.ElectiveGroupResult {
+	group := &models.ElectiveGroupResult{
+		ID:         id,
+		Name:       name,
+		Tipologia:  tipologia,
+		MinCredits: minCredits,
+		Completed:  []models.SubjectResult{},
+		InProgress: []models.SubjectResult{},
+		Options:    []models.SubjectResult{},
+	}
+	p.groups = append(p.groups, group)
+	return group
+}
+
+// group retorna la agrupación a la que pertenece una materia del plan, o nil si no es optativa
+func (p *electivePools) group(planCode string) *models.ElectiveGroupResult {
+	return p.byCode[planCode]
+}
+
+// takeExcess retira del libro de créditos lo que excede la cuota de una tipología optativa sin dejar
+// una agrupación por debajo de su mínimo mientras otra tenga créditos de sobra: primero se retira lo
+// que cada agrupación tiene por encima de su mínimo, luego los abonos que no son de ninguna agrupación
+// y, solo si aún sobran créditos (mínimos que suman más que la cuota), de cualquier abono. Los créditos
+// retirados se descuentan de su agrupación: pasan a libre elección o no se abonan.
+func (p *electivePools) takeExcess(ledger *models.CreditLedger, tipologia models.TipologiaAsignatura, quota int) []models.CreditLedgerEntry {
+	excess := ledger.Excess(tipologia, quota)
+	var taken []models.CreditLedgerEntry
+	take := func(credits int, selected func(models.CreditLedgerEntry) bool) {
+		for _, entry := range ledger.Take(tipologia, credits, selected) {
+			if group := p.group(entry.PlanCode); group != nil {
+				group.CompletedCredits -= entry.Credits
+			}
+			excess -= entry.Credits
+			taken = append(taken, entry)
+		}
+	}
+
+	for _, group := range p.groups {
+		surplus := group.CompletedCredits - group.MinCredits
+		if excess == 0 || surplus <= 0 {
+			continue
+		}
+		take(min(surplus, excess), func(entry models.CreditLedgerEntry) bool {
+			return p.group(entry.PlanCode) == group
+		})
+	}
+	if excess > 0 {
+		take(excess, func(entry models.CreditLedgerEntry) bool {
+			return p.group(entry.PlanCode) == nil
+		})
+	}
+	if excess > 0 {
+		take(excess, nil)
+	}
+	return taken
+}
+
+// results calcula los créditos faltantes de cada agrupación y retorna el estado de todas
+func (p *electivePools) results() []models.ElectiveGroupResult {
+	results := make([]models.ElectiveGroupResult, 0, len(p.groups))
+	for _, group := range p.groups {
+		group.MissingCredits = group.MinCredits - group.CompletedCredits
+		if group.MissingCredits < 0 {
+			group.MissingCredits = 0
+		}
+		group.Satisfied = group.MissingCredits == 0
+		results = append(results, *group)
+	}
+	return results
+}
+
+// ===== CRUD FUNCTIONS FOR ELECTIVE GROUPS =====
+
+// CreateElectiveGroup crea una agrupación de optativas con las materias indicadas del plan de estudio
+func CreateElectiveGroup(db *gorm.DB, studyPlanID uint, name, tipologia string, minCredits int, subjectCodes []string, notes string) (*models.ElectiveGroup, error) {
+	if strings.TrimSpace(name) == "" || tipologia == "" {
+		return nil, errors.New("name and tipologia are required")
+	}
+	if !esTipologiaOptativa(tipologia) {
+		return nil, errInvalidElectiveTipologia
+	}
+	if minCredits <= 0 {
+		return nil, errors.New("min_credits must be greater than zero")
+	}
+
+	var studyPlan models.StudyPlan
+	if err := db.Preload("Subjects").First(&studyPlan, studyPlanID).Error; err != nil {
+		return nil, errors.New("study plan not found")
+	}
+	subjects, err := electiveGroupSubjects(studyPlan, models.TipologiaAsignatura(tipologia), subjectCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	group := models.ElectiveGroup{
+		StudyPlanID: studyPlan.ID,
+		Name:        strings.TrimSpace(name),
+		Tipologia:   models.TipologiaAsignatura(tipologia),
+		MinCredits:  minCredits,
+		Notes:       notes,
+		Subjects:    subjects,
+	}
+	if err := db.Create(&group).Error; err != nil {
+		return nil, errors.New("failed to create elective group: " + err.Error())
+	}
+
+	db.Preload("Subjects").First(&group, group.ID)
+	return &group, nil
+}
+
+// errInvalidElectiveTipologia se retorna cuando la tipología de una agrupación no es optativa
+var errInvalidElectiveTipologia = errors.New("invalid tipologia. Must be one of: " + string(models.TipologiaFundamentalOptativa) + ", " + string(models.TipologiaDisciplinarOptativa))
+
+// esTipologiaOptativa indica si la tipología sirve para una agrupación: solo las optativas se exigen por créditos
+func esTipologiaOptativa(tipologia string) bool {
+	return models.ValidarTipologia(tipologia) && models.TipologiaAsignatura(tipologia).EsOptativa()
+}
+
+// electiveGroupSubjects obtiene las materias de la agrupación, que deben pertenecer al plan de estudio
+// y tener la tipología de la agrupación
+func electiveGroupSubjects(studyPlan models.StudyPlan, tipologia models.TipologiaAsignatura, subjectCodes []string) ([]models.Subject, error) {
+	if len(subjectCodes) == 0 {
+		return nil, errors.New("subject_codes is required")
+	}
+	planSubjects := make(map[string]models.Subject, len(studyPlan.Subjects))
+	for _, subject := range studyPlan.Subjects {
+		planSubjects[subject.Code] = subject
+	}
+
+	subjects := make([]models.Subject, 0, len(subjectCodes))
+	for _, code := range subjectCodes {
+		subject, exists := planSubjects[strings.TrimSpace(code)]
+		if !exists {
+			return nil, errors.New("subject " + code + " is not part of the study plan")
+		}
+		if subject.Type != tipologia {
+			return nil, errors.New("subject " + code + " is " + string(subject.Type) + ", not " + string(tipologia))
+		}
+		subjects = append(subjects, subject)
+	}
+	return subjects, nil
+}
+
+// GetElectiveGroupsByStudyPlan obtiene las agrupaciones de optativas de un plan de estudio
+func GetElectiveGroupsByStudyPlan(db *gorm.DB, studyPlanID uint) ([]models.ElectiveGroup, error) {
+	var groups []models.ElectiveGroup
+	if err := db.Preload("Subjects").Where("study_plan_id = ?", studyPlanID).Order("id").Find(&groups).Error; err != nil {
+		return nil, errors.New("failed to fetch elective groups: " + err.Error())
+	}
+	return groups, nil
+}
+
+// GetElectiveGroupByID obtiene una agrupación de optativas por su ID
+func GetElectiveGroupByID(db *gorm.DB, groupID uint) (*models.ElectiveGroup, error) {
+	var group models.ElectiveGroup
+	if err := db.Preload("Subjects").First(&group, groupID).Error; err != nil {
+		return nil, errors.New("elective group not found")
+	}
+	return &group, nil
+}
+
+// UpdateElectiveGroup actualiza los datos de una agrupación; si se envían materias reemplazan las actuales
+func UpdateElectiveGroup(db *gorm.DB, groupID uint, updates struct {
+	Name         string   `json:"name"`
+	Tipologia    string   `json:"tipologia"`
+	MinCredits   int      `json:"min_credits"`
+	SubjectCodes []string `json:"subject_codes"`
+	Notes        string   `json:"notes"`
+}) (*models.ElectiveGroup, error) {
+	var group models.ElectiveGroup
+	if err := db.Preload("Subjects").First(&group, groupID).Error; err != nil {
+		return nil, errors.New("elective group not found")
+	}
+
+	if name := strings.TrimSpace(updates.Name); name != "" {
+		group.Name = name
+	}
+	if updates.Tipologia != "" {
+		if !esTipologiaOptativa(updates.Tipologia) {
+			return nil, errInvalidElectiveTipologia
+		}
+		group.Tipologia = models.TipologiaAsignatura(updates.Tipologia)
+	}
+	if updates.MinCredits < 0 {
+		return nil, errors.New("min_credits must be greater than zero")
+	}
+	if updates.MinCredits > 0 {
+		group.MinCredits = updates.MinCredits
+	}
+	if updates.Notes != "" {
+		group.Notes = updates.Notes
+	}
+
+	// Las materias se validan contra la tipología final: un cambio de tipología sin materias nuevas
+	// también debe ser coherente con las materias actuales de la agrupación
+	if updates.SubjectCodes != nil || updates.Tipologia != "" {
+		subjectCodes := updates.SubjectCodes
+		if subjectCodes == nil {
+			for _, subject := range group.Subjects {
+				subjectCodes = append(subjectCodes, subject.Code)
+			}
+		}
+		var studyPlan models.StudyPlan
+		if err := db.Preload("Subjects").First(&studyPlan, group.StudyPlanID).Error; err != nil {
+			return nil, errors.New("study plan not found")
+		}
+		subjects, err := electiveGroupSubjects(studyPlan, group.Tipologia, subjectCodes)
+		if err != nil {
+			return nil, err
+		}
+		if updates.SubjectCodes != nil {
+			if err := db.Model(&group).Association("Subjects").Replace(subjects); err != nil {
+				return nil, errors.New("failed to update elective group subjects: " + err.Error())
+			}
+		}
+	}
+
+	if err := db.Omit("Subjects").Save(&group).Error; err != nil {
+		return nil, errors.New("failed to update elective group: " + err.Error())
+	}
+
+	db.Preload("Subjects").First(&group, group.ID)
+	return &group, nil
+}
+
+// DeleteElectiveGroup elimina una agrupación; sus materias quedan en la agrupación implícita de su tipología
+func DeleteElectiveGroup(db *gorm.DB, groupID uint) error {
+	var group models.ElectiveGroup
+	if err := db.First(&group, groupID).Error; err != nil {
+		return errors.New("elective group not found")
+	}
+	if err := db.Model(&group).Association("Subjects").Clear(); err != nil {
+		return errors.New("failed to delete elective group subjects: " + err.Error())
+	}
+	if err := db.Delete(&group).Error; err != nil {
+		return errors.New("failed to delete elective group: " + err.Error())
+	}
+	return nil
+}
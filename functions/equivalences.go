@@ -0,0 +1,237 @@
+package functions
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"olimpo-vicedecanatura/models"
+)
+
+// equivalenceRule es una equivalencia aplicada en un sentido: aprobar todas las materias sources
+// cubre cada una de las materias targets
+type equivalenceRule struct {
+	equivalence models.Equivalence
+	sources     []string
+	targets     []string
+	reverse     bool // La equivalencia se aplica del destino al origen
+}
+
+// direction retorna el sentido en que la regla aplica la equivalencia
+func (r equivalenceRule) direction() string {
+	if r.reverse {
+		return models.DireccionDestinoOrigen
+	}
+	return models.DireccionOrigenDestino
+}
+
+// maxEquivalenceDepth es el número máximo de equivalencias encadenadas entre la materia de la
+// historia y la materia del plan (por ejemplo plan antiguo → plan intermedio → plan vigente)
+const maxEquivalenceDepth = 4
+
+// equivalenceMatch es una regla cuyas materias origen están en la historia, directamente o
+// cubiertas a su vez por otras equivalencias
+type equivalenceMatch struct {
+	rule     equivalenceRule       // Equivalencia que cubre la materia del plan
+	subjects []models.SubjectInput // Materias de la historia que justifican la equivalencia
+	chain    []equivalenceStep     // Equivalencias aplicadas, desde la historia hasta la materia del plan
+}
+
+// equivalenceStep es una equivalencia de la cadena junto con la materia que cubrió
+type equivalenceStep struct {
+	rule   equivalenceRule
+	target string
+}
+
+// equivalenceIndex agrupa las reglas de equivalencia por materia destino
+type equivalenceIndex struct {
+	byTarget map[string][]equivalenceRule
+	codes    []string
+}
+
+// newEquivalenceIndex construye las reglas de las equivalencias. Con reverse las equivalencias
+// TOTAL también se aplican del destino al origen; las parciales solo abonan créditos en su sentido.
+func newEquivalenceIndex(equivalences []models.Equivalence, reverse bool) *equivalenceIndex {
+	index := &equivalenceIndex{byTarget: make(map[string][]equivalenceRule)}
+	add := func(rule equivalenceRule) {
+		for _, target := range rule.targets {
+			index.byTarget[target] = append(index.byTarget[target], rule)
+		}
+	}
+	for _, equivalence := range equivalences {
+		sources, targets := equivalence.SourceCodes(), equivalence.TargetCodes()
+		index.codes = append(index.codes, sources...)
+		index.codes = append(index.codes, targets...)
+		add(equivalenceRule{equivalence: equivalence, sources: sources, targets: targets})
+		if reverse && !equivalence.IsPartial() {
+			add(equivalenceRule{equivalence: equivalence, sources: targets, targets: sources, reverse: true})
+		}
+	}
+	// Una equivalencia total se prefiere sobre una parcial para la misma materia destino
+	for _, rules := range index.byTarget {
+		sort.SliceStable(rules, func(i, j int) bool {
+			return !rules[i].equivalence.IsPartial() && rules[j].equivalence.IsPartial()
+		})
+	}
+	return index
+}
+
+// match retorna la cadena de equivalencias más corta que cubre la materia del plan con materias
+// de la historia. Las materias intermedias solo se cubren con equivalencias totales.
+func (x *equivalenceIndex) match(history map[string]models.SubjectInput, planCode string) *equivalenceMatch {
+	for depth := 1; depth <= maxEquivalenceDepth; depth++ {
+		if match := x.resolve(history, planCode, map[string]bool{planCode: true}, depth, true); match != nil {
+			return match
+		}
+	}
+	return nil
+}
+
+// resolve busca una regla que cubra code con a lo sumo depth equivalencias encadenadas. path
+// tiene las materias del camino actual: una materia que ya está en el camino no se vuelve a
+// buscar, así los ciclos del grafo (A → B → A) no se recorren.
+func (x *equivalenceIndex) resolve(history map[string]models.SubjectInput, code string, path map[string]bool, depth int, allowPartial bool) *equivalenceMatch {
+	if depth == 0 {
+		return nil
+	}
+	for _, rule := range x.byTarget[code] {
+		if rule.equivalence.IsPartial() && !allowPartial {
+			continue
+		}
+		match := &equivalenceMatch{rule: rule}
+		covered := true
+		for _, source := range rule.sources {
+			if subject, exists := history[source]; exists {
+				match.subjects = append(match.subjects, subject)
+				continue
+			}
+			if path[source] {
+				covered = false
+				break
+			}
+			path[source] = true
+			inner := x.resolve(history, source, path, depth-1, false)
+			delete(path, source)
+			if inner == nil {
+				covered = false
+				break
+			}
+			match.subjects = append(match.subjects, inner.subjects...)
+			match.chain = append(match.chain, inner.chain...)
+		}
+		if covered {
+			match.chain = append(match.chain, equivalenceStep{rule: rule, target: code})
+			return match
+		}
+	}
+	return nil
+}
+
+// historyCodes retorna los códigos de las materias de la historia que justifican la equivalencia
+func (m *equivalenceMatch) historyCodes() []string {
+	codes := make([]string, len(m.subjects))
+	for i, subject := range m.subjects {
+		codes[i] = subject.Code
+	}
+	return codes
+}
+
+// sourceList retorna los códigos de las materias de la historia como "A + B"
+func (m *equivalenceMatch) sourceList() string {
+	return strings.Join(m.historyCodes(), " + ")
+}
+
+// chainDescription describe la cadena de equivalencias como "A → B, B → C"
+func (m *equivalenceMatch) chainDescription() string {
+	steps := make([]string, len(m.chain))
+	for i, step := range m.chain {
+		steps[i] = strings.Join(step.rule.sources, " + ") + " → " + step.target
+	}
+	return strings.Join(steps, ", ")
+}
+
+// notes explica la equivalencia: las materias de la historia y, si hubo varias equivalencias
+// encadenadas, la cadena completa
+func (m *equivalenceMatch) notes(prefix string) string {
+	if len(m.chain) < 2 {
+		return prefix + m.sourceList()
+	}
+	return prefix + m.sourceList() + " (" + m.chainDescription() + ")"
+}
+
+// credits retorna los créditos que la equivalencia abona a la materia destino
+func (m *equivalenceMatch) credits(target models.Subject) int {
+	if m.rule.equivalence.IsPartial() && m.rule.equivalence.Credits < target.Credits {
+		return m.rule.equivalence.Credits
+	}
+	return target.Credits
+}
+
+// result arma la información de la equivalencia para el resultado de la comparación
+func (m *equivalenceMatch) result(target models.Subject, notes string) *models.EquivalenceResult {
+	tipo := models.EquivalenciaTotal
+	if m.rule.equivalence.IsPartial() {
+		tipo = models.EquivalenciaParcial
+	}
+	chain := make([]models.EquivalenceStep, len(m.chain))
+	for i, step := range m.chain {
+		chain[i] = models.EquivalenceStep{
+			ID:          step.rule.equivalence.ID,
+			Type:        models.NormalizarTipoEquivalencia(step.rule.equivalence.Type),
+			SourceCodes: step.rule.sources,
+			TargetCode:  step.target,
+			Direction:   step.rule.direction(),
+		}
+	}
+	return &models.EquivalenceResult{
+		ID:          m.rule.equivalence.ID,
+		Type:        tipo,
+		Notes:       notes,
+		SourceCodes: m.historyCodes(),
+		Credits:     m.credits(target),
+		Chain:       chain,
+	}
+}
+
+// loadCareerEquivalences carga las equivalencias de una carrera. Se cargan todas, no solo las de
+// las materias del plan, para poder encadenar equivalencias entre versiones del plan.
+func loadCareerEquivalences(db *gorm.DB, careerID uint) []models.Equivalence {
+	var equivalences []models.Equivalence
+	db.Preload("SourceSubject").Preload("TargetSubject").Preload("ExtraSourceSubjects").Preload("ExtraTargetSubjects").
+		Where("career_id = ?", careerID).Order("id").Find(&equivalences)
+	return equivalences
+}
+
+// equivalenceSubjects obtiene las materias adicionales de una equivalencia por sus códigos
+func equivalenceSubjects(db *gorm.DB, codes []string) ([]models.Subject, error) {
+	subjects := make([]models.Subject, 0, len(codes))
+	for _, code := range codes {
+		var subject models.Subject
+		if err := db.Where("code = ?", strings.TrimSpace(code)).First(&subject).Error; err != nil {
+			return nil, errors.New("subject " + code + " not found")
+		}
+		subjects = append(subjects, subject)
+	}
+	return subjects, nil
+}
+
+// validateEquivalenceCredits verifica los créditos de la equivalencia según su tipo: una equivalencia
+// PARCIAL abona entre 1 y los créditos de cada materia destino, una TOTAL no indica créditos
+func validateEquivalenceCredits(equivalenceType string, credits int, targets []models.Subject) error {
+	if equivalenceType != models.EquivalenciaParcial {
+		if credits != 0 {
+			return errors.New("credits only apply to PARCIAL equivalences")
+		}
+		return nil
+	}
+	if credits <= 0 {
+		return errors.New("credits must be greater than 0 for PARCIAL equivalences")
+	}
+	for _, target := range targets {
+		if credits >= target.Credits {
+			return errors.New("credits of a PARCIAL equivalence must be less than the credits of target subject " + target.Code)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package functions
+
+import (
+	"fmt"
+	"strings"
+
+	"olimpo-vicedecanatura/models"
+)
+
+// decisionLog registra en orden cómo se decidió cada materia de la comparación. Solo registra
+// cuando se pidió la explicación (?explain=true); de lo contrario sus métodos no hacen nada.
+type decisionLog struct {
+	enabled bool
+	entries []models.Decision
+}
+
+func newDecisionLog(enabled bool) *decisionLog {
+	return &decisionLog{enabled: enabled}
+}
+
+// add agrega una decisión al final del registro
+func (l *decisionLog) add(decision models.Decision) {
+	if !l.enabled {
+		return
+	}
+	decision.Seq = len(l.entries) + 1
+	l.entries = append(l.entries, decision)
+}
+
+// codes registra las materias de la historia cuyo código se comparó con otra forma: con espacios
+// sobrantes, por alias o por normalización del formato. Las resoluciones van por código de la historia
+func (l *decisionLog) codes(original []models.SubjectInput, resolutions map[string]*models.CodeResolution) {
+	if !l.enabled {
+		return
+	}
+	for _, subject := range original {
+		trimmed := strings.TrimSpace(subject.Code)
+		if resolution := resolutions[trimmed]; resolution != nil {
+			l.add(models.Decision{
+				Kind:        models.DecisionCodigoNormalizado,
+				HistoryCode: resolution.CanonicalCode,
+				Message:     fmt.Sprintf("El código %q de la historia se comparó como %s (%s)", subject.Code, resolution.CanonicalCode, resolution.Rule),
+			})
+		} else if trimmed != subject.Code {
+			l.add(models.Decision{
+				Kind:        models.DecisionCodigoNormalizado,
+				HistoryCode: trimmed,
+				Message:     fmt.Sprintf("El código %q de la historia tenía espacios sobrantes y se comparó como %s", subject.Code, trimmed),
+			})
+		}
+	}
+}
+
+// rejected registra por qué no cubrieron la materia del plan la materia de la historia con el mismo
+// código y cada una de las equivalencias que tienen la materia como destino, salvo la aplicada
+func (l *decisionLog) rejected(planCode string, history map[string]models.SubjectInput, equivalences *equivalenceIndex, applied *models.EquivalenceResult) {
+	if !l.enabled {
+		return
+	}
+	if subject, exists := history[planCode]; exists && !subject.IsApproved() {
+		l.add(models.Decision{
+			Kind:        models.DecisionDescartada,
+			PlanCode:    planCode,
+			HistoryCode: subject.Code,
+			Message:     "La materia está en la historia con estado " + historyStatus(subject) + " y no otorga créditos",
+		})
+	}
+	for _, rule := range equivalences.byTarget[planCode] {
+		if applied != nil && applied.Chain[len(applied.Chain)-1].ID == rule.equivalence.ID {
+			continue
+		}
+		var reasons []string
+		for _, source := range rule.sources {
+			subject, exists := history[source]
+			switch {
+			case !exists:
+				reasons = append(reasons, source+" no está en la historia")
+			case !subject.IsApproved():
+				reasons = append(reasons, source+" está en la historia con estado "+historyStatus(subject))
+			}
+		}
+		if len(reasons) == 0 {
+			// Las materias origen están aprobadas pero la equivalencia no se usó (hubo otra preferida)
+			continue
+		}
+		l.add(models.Decision{
+			Kind:          models.DecisionDescartada,
+			PlanCode:      planCode,
+			EquivalenceID: rule.equivalence.ID,
+			Direction:     rule.direction(),
+			Message:       "Equivalencia " + strings.Join(rule.sources, " + ") + " → " + planCode + " descartada: " + strings.Join(reasons, ", "),
+		})
+	}
+}
+
+// historyStatus retorna el estado de una materia de la historia para los mensajes del registro
+func historyStatus(subject models.SubjectInput) string {
+	if status := models.NormalizarEstado(subject.Status); status != "" {
+		return status
+	}
+	return fmt.Sprintf("sin estado y calificación %.1f", subject.Grade)
+}
+
+// forSubject retorna las decisiones de una materia en orden: las de la materia del plan y las de la
+// materia de la historia con el mismo código (por ejemplo, un código normalizado)
+func (l *decisionLog) forSubject(code string) []models.Decision {
+	var decisions []models.Decision
+	for _, decision := range l.entries {
+		if decision.PlanCode == code || (decision.PlanCode == "" && decision.HistoryCode == code) {
+			decisions = append(decisions, decision)
+		}
+	}
+	return decisions
+}
+
+// annotate agrega a cada materia del resultado las decisiones que la explican
+func (l *decisionLog) annotate(results []models.SubjectResult) {
+	if !l.enabled {
+		return
+	}
+	for i := range results {
+		results[i].Decisions = l.forSubject(results[i].Code)
+	}
+}
@@ -4,10 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"gorm.io/gorm"
+	"math"
 	"olimpo-vicedecanatura/models"
+	"olimpo-vicedecanatura/parser"
 	"strings"
-	"regexp"
-	"strconv"
 )
 
 // CompareAcademicHistoryWithStudyPlan compara la historia académica de un estudiante con un plan de estudio
@@ -18,86 +18,81 @@ func CompareAcademicHistoryWithStudyPlan(db *gorm.DB, academicHistory models.Aca
 		return nil, errors.New("plan de estudio no encontrado")
 	}
 
-	// 2. Obtener todas las equivalencias relevantes para las materias del plan
-	var studyPlanSubjectIDs []uint
-	for _, subject := range studyPlan.Subjects {
-		studyPlanSubjectIDs = append(studyPlanSubjectIDs, subject.ID)
-	}
-
-	var equivalences []models.Equivalence
-	db.Preload("SourceSubject").Preload("TargetSubject").Where(
-		"source_subject_id IN ? OR target_subject_id IN ?", 
-		studyPlanSubjectIDs, studyPlanSubjectIDs,
-	).Find(&equivalences)
+	// 2. Obtener las equivalencias de la carrera del plan
+	equivalences := loadCareerEquivalences(db, studyPlan.CareerID)
 
 	// 3. Crear mapas para facilitar las búsquedas
-	studyPlanSubjectsMap := make(map[string]*models.Subject)
-	for i := range studyPlan.Subjects {
-		studyPlanSubjectsMap[studyPlan.Subjects[i].Code] = &studyPlan.Subjects[i]
-	}
+	// Las equivalencias totales se aplican en ambos sentidos; las compuestas exigen todas sus materias
+	// origen y las equivalencias se encadenan entre versiones del plan
+	equivalenceIndex := newEquivalenceIndex(equivalences, true)
 
-	// Crear mapa de equivalencias
-	equivalenceMap := make(map[string][]string) // código -> códigos equivalentes
-	for _, equiv := range equivalences {
-		// Si la materia origen está en el plan, agregar la destino como equivalente
-		if _, exists := studyPlanSubjectsMap[equiv.SourceSubject.Code]; exists {
-			equivalenceMap[equiv.SourceSubject.Code] = append(equivalenceMap[equiv.SourceSubject.Code], equiv.TargetSubject.Code)
-		}
-		// Si la materia destino está en el plan, agregar la origen como equivalente
-		if _, exists := studyPlanSubjectsMap[equiv.TargetSubject.Code]; exists {
-			equivalenceMap[equiv.TargetSubject.Code] = append(equivalenceMap[equiv.TargetSubject.Code], equiv.SourceSubject.Code)
+	// Resolver los códigos de la historia (alias y variantes de formato) a los códigos del catálogo
+	canonicalCodes := make([]string, 0, len(studyPlan.Subjects)+len(equivalenceIndex.codes))
+	for _, subject := range studyPlan.Subjects {
+		canonicalCodes = append(canonicalCodes, subject.Code)
+	}
+	canonicalCodes = append(canonicalCodes, equivalenceIndex.codes...)
+	historyInput, codeResolutions, historyResolutions := newCodeResolver(db, canonicalCodes).resolveSubjects(academicHistory.Subjects)
+
+	// 4. Procesar la historia académica: solo las materias aprobadas otorgan créditos,
+	// las inscritas en el periodo actual se reportan como proyectadas
+	// Los intentos repetidos se consolidan usando el último intento aprobado
+	historySubjects := models.MergeAttempts(historyInput)
+	historyByCode := make(map[string]models.SubjectInput)      // todas las materias de la historia por código
+	approvedSubjects := make(map[string]models.SubjectInput)   // materias aprobadas por código
+	inProgressSubjects := make(map[string]models.SubjectInput) // materias en curso por código
+	for _, historySubject := range historySubjects {
+		code := strings.TrimSpace(historySubject.Code)
+		historyByCode[code] = historySubject
+		switch {
+		case historySubject.IsApproved():
+			approvedSubjects[code] = historySubject
+		case historySubject.IsInProgress():
+			inProgressSubjects[code] = historySubject
 		}
 	}
 
-	// 4. Procesar la historia académica
-	approvedSubjects := make(map[string]bool) // códigos de materias aprobadas
-	for _, historySubject := range academicHistory.Subjects {
-		// Asumir que todas las materias en la historia académica están aprobadas
-		// ya que están en la historia académica del estudiante
-		approvedSubjects[strings.TrimSpace(historySubject.Code)] = true
-	}
-	fmt.Printf("[DEBUG] Materias aprobadas en historia académica: %+v\n", approvedSubjects)
-	fmt.Printf("[DEBUG] Materias del plan: ")
-	for _, planSubject := range studyPlan.Subjects {
-		fmt.Printf("%s, ", planSubject.Code)
+	// Con explain se registra en orden cómo se decidió cada materia
+	decisions := newDecisionLog(academicHistory.Explain)
+	decisions.codes(academicHistory.Subjects, historyResolutions)
+
+	// matchHistory verifica si una materia del plan está en un conjunto de la historia (directa o por equivalencia)
+	matchHistory := func(history map[string]models.SubjectInput, planSubject models.Subject) (*models.SubjectInput, *models.EquivalenceResult) {
+		if historySubject, exists := history[planSubject.Code]; exists {
+			return &historySubject, nil
+		}
+		if match := equivalenceIndex.match(history, planSubject.Code); match != nil {
+			return &match.subjects[0], match.result(planSubject, match.notes("Aprobada por equivalencia con "))
+		}
+		return nil, nil
 	}
-	fmt.Println()
-	fmt.Printf("[DEBUG] Equivalencias cargadas: %+v\n", equivalenceMap)
 
 	// 5. Determinar qué materias del plan están aprobadas (directa o por equivalencia)
 	var equivalentSubjects []models.SubjectResult
 	var missingSubjects []models.SubjectResult
-	
-	creditsByType := map[string]int{
-		"fund.obligatoria": 0,
-		"fund.optativa":    0,
-		"dis.obligatoria":  0,
-		"dis.optativa":     0,
-		"libre":            0,
+	projectedSubjects := []models.SubjectResult{}
+	projectedCredits := 0
+
+	// Créditos aprobados por tipología, con la materia y la vía que aportó cada abono
+	ledger := models.NewCreditLedger()
+
+	// Las optativas se exigen por créditos dentro de su agrupación, no una por una
+	pools := loadElectivePools(db, studyPlan)
+
+	// Materias de la historia que ya cubren una materia del plan
+	usedHistory := make(map[string]bool)
+
+	// Una equivalencia compuesta se proyecta cuando sus materias origen están aprobadas o en curso
+	projectableSubjects := make(map[string]models.SubjectInput, len(approvedSubjects)+len(inProgressSubjects))
+	for code, historySubject := range approvedSubjects {
+		projectableSubjects[code] = historySubject
+	}
+	for code, historySubject := range inProgressSubjects {
+		projectableSubjects[code] = historySubject
 	}
 
 	for _, planSubject := range studyPlan.Subjects {
-		isApproved := false
-		var equivalenceInfo *models.EquivalenceResult
-
-		// Verificar si está aprobada directamente
-		if approvedSubjects[planSubject.Code] {
-			isApproved = true
-		} else {
-			// Verificar si está aprobada por equivalencia
-			if equivalentCodes, hasEquivalences := equivalenceMap[planSubject.Code]; hasEquivalences {
-				for _, equivCode := range equivalentCodes {
-					if approvedSubjects[equivCode] {
-						isApproved = true
-						equivalenceInfo = &models.EquivalenceResult{
-							Type:  "total", // Asumimos equivalencia total por simplicidad
-							Notes: "Aprobada por equivalencia con " + equivCode,
-						}
-						break
-					}
-				}
-			}
-		}
+		approvedSubject, equivalenceInfo := matchHistory(approvedSubjects, planSubject)
 
 		subjectResult := models.SubjectResult{
 			Code:        planSubject.Code,
@@ -106,89 +101,255 @@ func CompareAcademicHistoryWithStudyPlan(db *gorm.DB, academicHistory models.Aca
 			Type:        planSubject.Type,
 			Equivalence: equivalenceInfo,
 		}
+		group := pools.group(planSubject.Code)
+
+		if approvedSubject != nil {
+			if equivalenceInfo == nil {
+				decisions.add(models.Decision{
+					Kind:        models.DecisionCoincidenciaDirecta,
+					PlanCode:    planSubject.Code,
+					HistoryCode: approvedSubject.Code,
+					Message:     "La materia está aprobada en la historia con el mismo código",
+				})
+			} else {
+				decisions.rejected(planSubject.Code, historyByCode, equivalenceIndex, equivalenceInfo)
+				decisions.add(equivalenceDecision(planSubject, equivalenceInfo))
+			}
 
-		if isApproved {
+			// Una equivalencia parcial abona solo parte de los créditos: las obligatorias siguen pendientes
+			credits := planSubject.Credits
 			subjectResult.Status = "APROBADA"
-			equivalentSubjects = append(equivalentSubjects, subjectResult)
-			creditsByType[string(planSubject.Type)] += planSubject.Credits
+			if equivalenceInfo != nil {
+				credits = equivalenceInfo.Credits
+				for _, sourceCode := range equivalenceInfo.SourceCodes {
+					usedHistory[sourceCode] = true
+				}
+				if credits < planSubject.Credits {
+					subjectResult.Status = models.EquivalenciaParcial
+				}
+			}
+			subjectResult.Modality = approvedSubject.Modality
+			subjectResult.Validated = approvedSubject.Modality == models.ModalidadValidacion
+			subjectResult.ResolvedAlias = codeResolutions[approvedSubject.Code]
+			usedHistory[approvedSubject.Code] = true
+			switch {
+			case group != nil:
+				group.Completed = append(group.Completed, subjectResult)
+				group.CompletedCredits += credits
+				equivalentSubjects = append(equivalentSubjects, subjectResult)
+			case credits < planSubject.Credits:
+				missingSubjects = append(missingSubjects, subjectResult)
+			default:
+				equivalentSubjects = append(equivalentSubjects, subjectResult)
+			}
+			if planSubject.Type.CuentaParaGrado() {
+				entry := models.CreditLedgerEntry{
+					Tipologia:     planSubject.Type,
+					Credits:       credits,
+					PlanCode:      planSubject.Code,
+					HistoryCode:   approvedSubject.Code,
+					Via:           models.ViaDirecta,
+					ResolvedAlias: subjectResult.ResolvedAlias,
+				}
+				if equivalenceInfo != nil {
+					entry.Via = models.ViaEquivalencia
+					entry.HistoryCode = strings.Join(equivalenceInfo.SourceCodes, " + ")
+					entry.Notes = equivalenceInfo.Notes
+				}
+				if group != nil {
+					entry.ElectiveGroup = group.Name
+				}
+				ledger.Add(entry)
+			}
+		} else if inProgress, projectedEquivalence := matchHistory(projectableSubjects, planSubject); inProgress != nil {
+			decisions.add(models.Decision{
+				Kind:        models.DecisionEnCurso,
+				PlanCode:    planSubject.Code,
+				HistoryCode: inProgress.Code,
+				Message:     "La materia se cubriría con " + inProgress.Code + ", que está en curso",
+			})
+			// Con una equivalencia parcial en curso solo se proyectan los créditos que abonaría
+			credits := planSubject.Credits
+			if projectedEquivalence != nil {
+				credits = projectedEquivalence.Credits
+			}
+			subjectResult.Status = "EN CURSO"
+			subjectResult.Equivalence = projectedEquivalence
+			subjectResult.ResolvedAlias = codeResolutions[inProgress.Code]
+			projectedSubjects = append(projectedSubjects, subjectResult)
+			if group != nil {
+				group.InProgress = append(group.InProgress, subjectResult)
+				group.ProjectedCredits += credits
+			}
+			if planSubject.Type.CuentaParaGrado() {
+				projectedCredits += credits
+			}
 		} else {
-			subjectResult.Status = "PENDIENTE"
-			missingSubjects = append(missingSubjects, subjectResult)
+			decisions.rejected(planSubject.Code, historyByCode, equivalenceIndex, nil)
+			decisions.add(models.Decision{
+				Kind:     models.DecisionSinCoincidencia,
+				PlanCode: planSubject.Code,
+				Message:  "Ninguna materia aprobada o en curso de la historia cubre la materia, ni directamente ni por equivalencia",
+			})
+			if group != nil {
+				// Una optativa no cursada es una opción de su agrupación, no una materia pendiente
+				subjectResult.Status = "DISPONIBLE"
+				group.Options = append(group.Options, subjectResult)
+			} else {
+				subjectResult.Status = "PENDIENTE"
+				missingSubjects = append(missingSubjects, subjectResult)
+			}
 		}
 	}
 
-	// 6. Calcular resumen de créditos
-	creditsSummary := models.CreditsSummary{
-		FundObligatoria: models.CreditTypeInfo{
-			Required:  studyPlan.FundObligatoriaCredits,
-			Completed: creditsByType["fund.obligatoria"],
-			Missing:   studyPlan.FundObligatoriaCredits - creditsByType["fund.obligatoria"],
-		},
-		FundOptativa: models.CreditTypeInfo{
-			Required:  studyPlan.FundOptativaCredits,
-			Completed: creditsByType["fund.optativa"],
-			Missing:   studyPlan.FundOptativaCredits - creditsByType["fund.optativa"],
-		},
-		DisObligatoria: models.CreditTypeInfo{
-			Required:  studyPlan.DisObligatoriaCredits,
-			Completed: creditsByType["dis.obligatoria"],
-			Missing:   studyPlan.DisObligatoriaCredits - creditsByType["dis.obligatoria"],
-		},
-		DisOptativa: models.CreditTypeInfo{
-			Required:  studyPlan.DisOptativaCredits,
-			Completed: creditsByType["dis.optativa"],
-			Missing:   studyPlan.DisOptativaCredits - creditsByType["dis.optativa"],
-		},
-		Libre: models.CreditTypeInfo{
-			Required:  studyPlan.LibreCredits,
-			Completed: creditsByType["libre"],
-			Missing:   studyPlan.LibreCredits - creditsByType["libre"],
-		},
-	}
-
-	// Calcular totales
-	totalCompleted := creditsByType["fund.obligatoria"] + creditsByType["fund.optativa"] + 
-					  creditsByType["dis.obligatoria"] + creditsByType["dis.optativa"] + creditsByType["libre"]
-	
-	creditsSummary.Total = models.CreditTypeInfo{
-		Required:  studyPlan.TotalCredits,
-		Completed: totalCompleted,
-		Missing:   studyPlan.TotalCredits - totalCompleted,
+	// Los cursos de nivelación de la historia se reportan aparte y no suman a los créditos del grado
+	levelingSubjects := []models.SubjectResult{}
+	var levelingCredits models.CreditTypeInfo
+	for _, historySubject := range historySubjects {
+		if historySubject.Type.CuentaParaGrado() {
+			continue
+		}
+		levelingResult := models.SubjectResult{
+			Code:          historySubject.Code,
+			Name:          historySubject.Name,
+			Credits:       historySubject.Credits,
+			Type:          models.TipologiaNivelacion,
+			Status:        "PENDIENTE",
+			Modality:      historySubject.Modality,
+			Validated:     historySubject.Modality == models.ModalidadValidacion,
+			ResolvedAlias: codeResolutions[historySubject.Code],
+		}
+		levelingCredits.Required += historySubject.Credits
+		switch {
+		case historySubject.IsApproved():
+			levelingResult.Status = "APROBADA"
+			levelingCredits.Completed += historySubject.Credits
+		case historySubject.IsInProgress():
+			levelingResult.Status = "EN CURSO"
+		}
+		levelingSubjects = append(levelingSubjects, levelingResult)
 	}
+	levelingCredits.Missing = levelingCredits.Required - levelingCredits.Completed
 
-	// Asegurar que los valores faltantes no sean negativos
-	if creditsSummary.FundObligatoria.Missing < 0 {
-		creditsSummary.FundObligatoria.Missing = 0
-	}
-	if creditsSummary.FundOptativa.Missing < 0 {
-		creditsSummary.FundOptativa.Missing = 0
-	}
-	if creditsSummary.DisObligatoria.Missing < 0 {
-		creditsSummary.DisObligatoria.Missing = 0
-	}
-	if creditsSummary.DisOptativa.Missing < 0 {
-		creditsSummary.DisOptativa.Missing = 0
-	}
-	if creditsSummary.Libre.Missing < 0 {
-		creditsSummary.Libre.Missing = 0
+	// 6. Las materias aprobadas que no cubren ninguna materia del plan y los créditos de optativas
+	// por encima de la cuota de su tipología se abonan a libre elección hasta completar su cupo
+	libre := newLibreEleccion(ledger, studyPlan.LibreCredits)
+	outOfPlanSubjects := []models.SubjectResult{}
+	for _, historySubject := range historySubjects {
+		if !historySubject.IsApproved() || !historySubject.Type.CuentaParaGrado() || usedHistory[historySubject.Code] {
+			continue
+		}
+		outOfPlan := models.SubjectResult{
+			Code:          historySubject.Code,
+			Name:          historySubject.Name,
+			Credits:       historySubject.Credits,
+			Type:          historySubject.Type,
+			Status:        string(models.TipologiaLibreEleccion),
+			Modality:      historySubject.Modality,
+			Validated:     historySubject.Modality == models.ModalidadValidacion,
+			ResolvedAlias: codeResolutions[historySubject.Code],
+		}
+		credits := libre.add(models.CreditLedgerEntry{
+			Tipologia:     historySubject.Type,
+			Credits:       historySubject.Credits,
+			HistoryCode:   historySubject.Code,
+			Via:           models.ViaFueraDelPlan,
+			ResolvedAlias: outOfPlan.ResolvedAlias,
+		})
+		if credits == 0 {
+			outOfPlan.Status = "SIN USAR"
+		}
+		decisions.add(models.Decision{
+			Kind:        models.DecisionFueraDelPlan,
+			HistoryCode: historySubject.Code,
+			Message:     fmt.Sprintf("La materia no cubre ninguna materia del plan; se abonaron %d de sus %d créditos a libre elección", credits, historySubject.Credits),
+		})
+		outOfPlanSubjects = append(outOfPlanSubjects, outOfPlan)
+	}
+
+	optativaQuotas := []struct {
+		tipologia models.TipologiaAsignatura
+		quota     int
+	}{
+		{models.TipologiaFundamentalOptativa, studyPlan.FundOptativaCredits},
+		{models.TipologiaDisciplinarOptativa, studyPlan.DisOptativaCredits},
+	}
+	for _, optativa := range optativaQuotas {
+		for _, excess := range pools.takeExcess(ledger, optativa.tipologia, optativa.quota) {
+			excess.Via = models.ViaExcedente
+			if studyPlan.ElectiveOverflow == models.ExcedenteSinAbonar {
+				libre.discard(excess, "Excede la cuota de "+string(optativa.tipologia)+" y el plan no abona excedentes")
+				continue
+			}
+			excess.Notes = "Excedente de " + string(optativa.tipologia)
+			libre.add(excess)
+		}
 	}
-	if creditsSummary.Total.Missing < 0 {
-		creditsSummary.Total.Missing = 0
+
+	// 7. Calcular resumen de créditos a partir del libro de créditos
+	creditsSummary := models.CreditsSummary{
+		FundObligatoria: ledger.CreditInfo(models.TipologiaFundamentalObligatoria, studyPlan.FundObligatoriaCredits),
+		FundOptativa:    ledger.CreditInfo(models.TipologiaFundamentalOptativa, studyPlan.FundOptativaCredits),
+		DisObligatoria:  ledger.CreditInfo(models.TipologiaDisciplinarObligatoria, studyPlan.DisObligatoriaCredits),
+		DisOptativa:     ledger.CreditInfo(models.TipologiaDisciplinarOptativa, studyPlan.DisOptativaCredits),
+		Libre:           ledger.CreditInfo(models.TipologiaLibreEleccion, studyPlan.LibreCredits),
+		TrabajoGrado:    ledger.CreditInfo(models.TipologiaTrabajoGrado, studyPlan.TrabajoGradoCredits),
+		Total:           ledger.TotalInfo(studyPlan.TotalCredits),
+		Nivelacion:      levelingCredits,
+	}
+
+	electiveGroups := pools.results()
+	decisions.annotate(equivalentSubjects)
+	decisions.annotate(missingSubjects)
+	decisions.annotate(projectedSubjects)
+	decisions.annotate(outOfPlanSubjects)
+	for i := range electiveGroups {
+		decisions.annotate(electiveGroups[i].Completed)
+		decisions.annotate(electiveGroups[i].InProgress)
+		decisions.annotate(electiveGroups[i].Options)
 	}
 
 	return &models.ComparisonResult{
 		EquivalentSubjects: equivalentSubjects,
 		MissingSubjects:    missingSubjects,
+		ProjectedSubjects:  projectedSubjects,
+		ProjectedCredits:   projectedCredits,
+		RepeatedSubjects:   models.RepeatedSubjects(historySubjects),
+		LevelingSubjects:   levelingSubjects,
 		CreditsSummary:     creditsSummary,
+		CreditLedger:       ledger.Entries(),
+		ElectiveGroups:     electiveGroups,
+		OutOfPlanSubjects:  outOfPlanSubjects,
+		UnusedCredits:      libre.unused,
+		DecisionLog:        decisions.entries,
 	}, nil
 }
 
-// GetStudyPlanByCareerCode obtiene el plan de estudio activo de una carrera por su código
+// equivalenceDecision arma la decisión de una materia del plan cubierta por una equivalencia
+func equivalenceDecision(planSubject models.Subject, equivalence *models.EquivalenceResult) models.Decision {
+	applied := equivalence.Chain[len(equivalence.Chain)-1]
+	message := equivalence.Notes
+	if equivalence.Credits < planSubject.Credits {
+		message += fmt.Sprintf("; equivalencia parcial: abona %d de %d créditos", equivalence.Credits, planSubject.Credits)
+	}
+	return models.Decision{
+		Kind:          models.DecisionEquivalencia,
+		PlanCode:      planSubject.Code,
+		HistoryCode:   strings.Join(equivalence.SourceCodes, " + "),
+		EquivalenceID: applied.ID,
+		Direction:     applied.Direction,
+		Message:       message,
+	}
+}
+
+// GetStudyPlanByCareerCode obtiene el plan de estudio activo de una carrera por su código. Si la
+// carrera tiene varios planes activos se retorna el más reciente (el último creado).
 func GetStudyPlanByCareerCode(db *gorm.DB, careerCode string) (*models.StudyPlan, error) {
 	var studyPlan models.StudyPlan
 	err := db.Preload("Subjects").Preload("Career").
 		Joins("JOIN careers ON careers.id = study_plans.career_id").
 		Where("careers.code = ? AND study_plans.is_active = ?", careerCode, true).
+		Order("study_plans.id DESC").
 		First(&studyPlan).Error
 	
 	if err != nil {
@@ -200,8 +361,8 @@ func GetStudyPlanByCareerCode(db *gorm.DB, careerCode string) (*models.StudyPlan
 
 // CompareAcademicHistoryByCareerCode compara la historia académica usando el código de carrera
 func CompareAcademicHistoryByCareerCode(db *gorm.DB, academicHistory models.AcademicHistoryInput) (*models.ComparisonResult, error) {
-	// Obtener el plan de estudio activo de la carrera
-	studyPlan, err := GetStudyPlanByCareerCode(db, academicHistory.CareerCode)
+	// Obtener el plan de estudio que aplica al estudiante (versión, periodo de admisión o plan activo)
+	studyPlan, err := ResolveStudyPlan(db, academicHistory.CareerCode, academicHistory.PlanVersion, academicHistory.AdmissionPeriod)
 	if err != nil {
 		return nil, err
 	}
@@ -238,7 +399,7 @@ func CreateCareer(db *gorm.DB, name, code, description string) (*models.Career,
 }
 
 // CreateStudyPlan crea un plan de estudio vacio (Sin subjects) y lo asocia a una carrera
-func CreateStudyPlan(db *gorm.DB, careerID uint, version string, fundObligatoriaCredits, fundOptativaCredits, disObligatoriaCredits, disOptativaCredits, libreCredits int) (*models.StudyPlan, error) {
+func CreateStudyPlan(db *gorm.DB, careerID uint, version string, fundObligatoriaCredits, fundOptativaCredits, disObligatoriaCredits, disOptativaCredits, libreCredits, trabajoGradoCredits int) (*models.StudyPlan, error) {
 	// Validate required fields
 	if version == "" {
 		return nil, errors.New("version is required")
@@ -257,7 +418,7 @@ func CreateStudyPlan(db *gorm.DB, careerID uint, version string, fundObligatoria
 	}
 
 	// Calculate total credits
-	totalCredits := fundObligatoriaCredits + fundOptativaCredits + disObligatoriaCredits + disOptativaCredits + libreCredits
+	totalCredits := fundObligatoriaCredits + fundOptativaCredits + disObligatoriaCredits + disOptativaCredits + libreCredits + trabajoGradoCredits
 
 	// Create new study plan
 	studyPlan := models.StudyPlan{
@@ -269,6 +430,7 @@ func CreateStudyPlan(db *gorm.DB, careerID uint, version string, fundObligatoria
 		DisObligatoriaCredits:   disObligatoriaCredits,
 		DisOptativaCredits:      disOptativaCredits,
 		LibreCredits:            libreCredits,
+		TrabajoGradoCredits:     trabajoGradoCredits,
 		TotalCredits:            totalCredits,
 	}
 
@@ -282,6 +444,23 @@ func CreateStudyPlan(db *gorm.DB, careerID uint, version string, fundObligatoria
 	return &studyPlan, nil
 }
 
+// UpdateStudyPlanElectiveOverflow cambia la regla del plan para los créditos de optativas que exceden la cuota
+func UpdateStudyPlanElectiveOverflow(db *gorm.DB, studyPlanID uint, rule string) (*models.StudyPlan, error) {
+	rule = strings.ToUpper(strings.TrimSpace(rule))
+	if !models.ValidarReglaExcedente(rule) {
+		return nil, errors.New("invalid elective_overflow. Must be one of: " + models.ExcedenteALibre + ", " + models.ExcedenteSinAbonar)
+	}
+
+	var studyPlan models.StudyPlan
+	if err := db.First(&studyPlan, studyPlanID).Error; err != nil {
+		return nil, errors.New("study plan not found")
+	}
+	if err := db.Model(&studyPlan).Update("elective_overflow", rule).Error; err != nil {
+		return nil, errors.New("failed to update study plan: " + err.Error())
+	}
+	return &studyPlan, nil
+}
+
 // CreateSubject crea un nuevo subject y lo asocia a un plan de estudios
 func CreateSubject(db *gorm.DB, studyPlanID uint, code, name, subjectType, description string, credits int) (*models.Subject, error) {
 	// Validate required fields
@@ -291,7 +470,7 @@ func CreateSubject(db *gorm.DB, studyPlanID uint, code, name, subjectType, descr
 
 	// Validate subject type using the model's validation function
 	if !models.ValidarTipologia(subjectType) {
-		return nil, errors.New("invalid subject type. Must be one of: FUND. OBLIGATORIA, FUND. OPTATIVA, DISCIPLINAR OBLIGATORIA, DISCIPLINAR OPTATIVA, LIBRE ELECCIÓN, TRABAJO DE GRADO")
+		return nil, errors.New("invalid subject type. Must be one of: FUND. OBLIGATORIA, FUND. OPTATIVA, DISCIPLINAR OBLIGATORIA, DISCIPLINAR OPTATIVA, LIBRE ELECCIÓN, TRABAJO DE GRADO, NIVELACIÓN")
 	}
 
 	// Validate credits
@@ -333,7 +512,7 @@ func CreateSubject(db *gorm.DB, studyPlanID uint, code, name, subjectType, descr
 }
 
 // Helper function to create a complete study plan with subjects in one go
-func CreateCompleteStudyPlan(db *gorm.DB, careerID uint, version string, fundObligatoriaCredits, fundOptativaCredits, disObligatoriaCredits, disOptativaCredits, libreCredits int, subjects []struct {
+func CreateCompleteStudyPlan(db *gorm.DB, careerID uint, version string, fundObligatoriaCredits, fundOptativaCredits, disObligatoriaCredits, disOptativaCredits, libreCredits, trabajoGradoCredits int, subjects []struct {
 	Code        string
 	Name        string
 	Type        string
@@ -349,7 +528,7 @@ func CreateCompleteStudyPlan(db *gorm.DB, careerID uint, version string, fundObl
 	}()
 
 	// Create study plan
-	studyPlan, err := CreateStudyPlan(tx, careerID, version, fundObligatoriaCredits, fundOptativaCredits, disObligatoriaCredits, disOptativaCredits, libreCredits)
+	studyPlan, err := CreateStudyPlan(tx, careerID, version, fundObligatoriaCredits, fundOptativaCredits, disObligatoriaCredits, disOptativaCredits, libreCredits, trabajoGradoCredits)
 	if err != nil {
 		tx.Rollback()
 		return nil, err
@@ -384,7 +563,7 @@ func CreateEquivalence(db *gorm.DB, sourceSubjectData struct {
 	Type        string `json:"type" binding:"required"`
 	Credits     int    `json:"credits" binding:"required"`
 	Description string `json:"description"`
-}, targetSubjectID uint, careerID uint, equivalenceType, notes string) (*models.Equivalence, error) {
+}, targetSubjectID uint, careerID uint, equivalenceType string, credits int, notes string, extraSourceCodes, extraTargetCodes []string) (*models.Equivalence, error) {
 	// Validar campos requeridos
 	if sourceSubjectData.Code == "" || sourceSubjectData.Name == "" || sourceSubjectData.Type == "" {
 		return nil, errors.New("code, name, and type are required for source subject")
@@ -398,6 +577,10 @@ func CreateEquivalence(db *gorm.DB, sourceSubjectData struct {
 	if equivalenceType == "" {
 		return nil, errors.New("equivalence type is required")
 	}
+	equivalenceType = models.NormalizarTipoEquivalencia(equivalenceType)
+	if !models.ValidarTipoEquivalencia(equivalenceType) {
+		return nil, errors.New("invalid equivalence type. Must be one of: " + models.EquivalenciaTotal + ", " + models.EquivalenciaParcial)
+	}
 
 	// Validar que la carrera existe
 	var career models.Career
@@ -411,9 +594,22 @@ func CreateEquivalence(db *gorm.DB, sourceSubjectData struct {
 		return nil, errors.New("target subject not found")
 	}
 
+	// Validar las materias adicionales de una equivalencia compuesta y los créditos de una parcial
+	extraSources, err := equivalenceSubjects(db, extraSourceCodes)
+	if err != nil {
+		return nil, err
+	}
+	extraTargets, err := equivalenceSubjects(db, extraTargetCodes)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateEquivalenceCredits(equivalenceType, credits, append([]models.Subject{targetSubject}, extraTargets...)); err != nil {
+		return nil, err
+	}
+
 	// Validar tipo de materia origen
 	if !models.ValidarTipologia(sourceSubjectData.Type) {
-		return nil, errors.New("invalid source subject type. Must be one of: FUND. OBLIGATORIA, FUND. OPTATIVA, DISCIPLINAR OBLIGATORIA, DISCIPLINAR OPTATIVA, LIBRE ELECCIÓN, TRABAJO DE GRADO")
+		return nil, errors.New("invalid source subject type. Must be one of: FUND. OBLIGATORIA, FUND. OPTATIVA, DISCIPLINAR OBLIGATORIA, DISCIPLINAR OPTATIVA, LIBRE ELECCIÓN, TRABAJO DE GRADO, NIVELACIÓN")
 	}
 
 	// Validar créditos
@@ -423,7 +619,7 @@ func CreateEquivalence(db *gorm.DB, sourceSubjectData struct {
 
 	// Buscar si ya existe la materia de origen por código
 	var sourceSubject models.Subject
-	err := db.Where("code = ?", sourceSubjectData.Code).First(&sourceSubject).Error
+	err = db.Where("code = ?", sourceSubjectData.Code).First(&sourceSubject).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// No existe, crearla
@@ -445,11 +641,14 @@ func CreateEquivalence(db *gorm.DB, sourceSubjectData struct {
 
 	// Crear la equivalencia
 	equivalence := models.Equivalence{
-		SourceSubjectID: sourceSubject.ID,
-		TargetSubjectID: targetSubjectID,
-		Type:            equivalenceType,
-		Notes:           notes,
-		CareerID:        careerID,
+		SourceSubjectID:     sourceSubject.ID,
+		TargetSubjectID:     targetSubjectID,
+		Type:                equivalenceType,
+		Credits:             credits,
+		Notes:               notes,
+		CareerID:            careerID,
+		ExtraSourceSubjects: extraSources,
+		ExtraTargetSubjects: extraTargets,
 	}
 
 	if err := db.Create(&equivalence).Error; err != nil {
@@ -457,7 +656,7 @@ func CreateEquivalence(db *gorm.DB, sourceSubjectData struct {
 	}
 
 	// Cargar las relaciones
-	db.Preload("SourceSubject").Preload("TargetSubject").Preload("Career").First(&equivalence, equivalence.ID)
+	db.Preload("SourceSubject").Preload("TargetSubject").Preload("ExtraSourceSubjects").Preload("ExtraTargetSubjects").Preload("Career").First(&equivalence, equivalence.ID)
 
 	return &equivalence, nil
 }
@@ -465,7 +664,7 @@ func CreateEquivalence(db *gorm.DB, sourceSubjectData struct {
 // GetEquivalenceByID obtiene una equivalencia por su ID
 func GetEquivalenceByID(db *gorm.DB, equivalenceID uint) (*models.Equivalence, error) {
 	var equivalence models.Equivalence
-	if err := db.Preload("SourceSubject").Preload("TargetSubject").Preload("Career").
+	if err := db.Preload("SourceSubject").Preload("TargetSubject").Preload("ExtraSourceSubjects").Preload("ExtraTargetSubjects").Preload("Career").
 		First(&equivalence, equivalenceID).Error; err != nil {
 		return nil, errors.New("equivalence not found")
 	}
@@ -475,7 +674,7 @@ func GetEquivalenceByID(db *gorm.DB, equivalenceID uint) (*models.Equivalence, e
 // GetAllEquivalences obtiene todas las equivalencias
 func GetAllEquivalences(db *gorm.DB) ([]models.Equivalence, error) {
 	var equivalences []models.Equivalence
-	if err := db.Preload("SourceSubject").Preload("TargetSubject").Preload("Career").
+	if err := db.Preload("SourceSubject").Preload("TargetSubject").Preload("ExtraSourceSubjects").Preload("ExtraTargetSubjects").Preload("Career").
 		Find(&equivalences).Error; err != nil {
 		return nil, errors.New("failed to fetch equivalences: " + err.Error())
 	}
@@ -485,7 +684,7 @@ func GetAllEquivalences(db *gorm.DB) ([]models.Equivalence, error) {
 // GetEquivalencesByCareer obtiene todas las equivalencias de una carrera específica
 func GetEquivalencesByCareer(db *gorm.DB, careerID uint) ([]models.Equivalence, error) {
 	var equivalences []models.Equivalence
-	if err := db.Preload("SourceSubject").Preload("TargetSubject").Preload("Career").
+	if err := db.Preload("SourceSubject").Preload("TargetSubject").Preload("ExtraSourceSubjects").Preload("ExtraTargetSubjects").Preload("Career").
 		Where("career_id = ?", careerID).Find(&equivalences).Error; err != nil {
 		return nil, errors.New("failed to fetch equivalences for career: " + err.Error())
 	}
@@ -495,7 +694,7 @@ func GetEquivalencesByCareer(db *gorm.DB, careerID uint) ([]models.Equivalence,
 // GetEquivalencesByCareerCode obtiene todas las equivalencias de una carrera por su código
 func GetEquivalencesByCareerCode(db *gorm.DB, careerCode string) ([]models.Equivalence, error) {
 	var equivalences []models.Equivalence
-	if err := db.Preload("SourceSubject").Preload("TargetSubject").Preload("Career").
+	if err := db.Preload("SourceSubject").Preload("TargetSubject").Preload("ExtraSourceSubjects").Preload("ExtraTargetSubjects").Preload("Career").
 		Joins("JOIN careers ON careers.id = equivalences.career_id").
 		Where("careers.code = ?", careerCode).Find(&equivalences).Error; err != nil {
 		return nil, errors.New("failed to fetch equivalences for career code: " + err.Error())
@@ -504,24 +703,38 @@ func GetEquivalencesByCareerCode(db *gorm.DB, careerCode string) ([]models.Equiv
 }
 
 // UpdateEquivalence actualiza una equivalencia existente
+// Si se envían materias adicionales reemplazan las actuales; una lista vacía las elimina
 func UpdateEquivalence(db *gorm.DB, equivalenceID uint, updates struct {
-	Type            string `json:"type"`
-	Notes           string `json:"notes"`
-	TargetSubjectID uint   `json:"target_subject_id"`
+	Type             string   `json:"type"`
+	Credits          int      `json:"credits"`
+	Notes            string   `json:"notes"`
+	TargetSubjectID  uint     `json:"target_subject_id"`
+	ExtraSourceCodes []string `json:"extra_source_codes"`
+	ExtraTargetCodes []string `json:"extra_target_codes"`
 }) (*models.Equivalence, error) {
 	// Verificar que la equivalencia existe
 	var equivalence models.Equivalence
-	if err := db.First(&equivalence, equivalenceID).Error; err != nil {
+	if err := db.Preload("TargetSubject").Preload("ExtraTargetSubjects").First(&equivalence, equivalenceID).Error; err != nil {
 		return nil, errors.New("equivalence not found")
 	}
 
 	// Actualizar campos
 	if updates.Type != "" {
-		equivalence.Type = updates.Type
+		if !models.ValidarTipoEquivalencia(updates.Type) {
+			return nil, errors.New("invalid equivalence type. Must be one of: " + models.EquivalenciaTotal + ", " + models.EquivalenciaParcial)
+		}
+		equivalence.Type = models.NormalizarTipoEquivalencia(updates.Type)
+		if !equivalence.IsPartial() {
+			equivalence.Credits = 0
+		}
+	}
+	if updates.Credits != 0 {
+		equivalence.Credits = updates.Credits
 	}
 	if updates.Notes != "" {
 		equivalence.Notes = updates.Notes
 	}
+	targets := append([]models.Subject{equivalence.TargetSubject}, equivalence.ExtraTargetSubjects...)
 	if updates.TargetSubjectID != 0 {
 		// Validar que la materia destino existe
 		var targetSubject models.Subject
@@ -529,14 +742,45 @@ func UpdateEquivalence(db *gorm.DB, equivalenceID uint, updates struct {
 			return nil, errors.New("target subject not found")
 		}
 		equivalence.TargetSubjectID = updates.TargetSubjectID
+		targets[0] = targetSubject
 	}
 
-	if err := db.Save(&equivalence).Error; err != nil {
+	var extraSources []models.Subject
+	if updates.ExtraSourceCodes != nil {
+		subjects, err := equivalenceSubjects(db, updates.ExtraSourceCodes)
+		if err != nil {
+			return nil, err
+		}
+		extraSources = subjects
+	}
+	if updates.ExtraTargetCodes != nil {
+		subjects, err := equivalenceSubjects(db, updates.ExtraTargetCodes)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets[:1], subjects...)
+	}
+	if err := validateEquivalenceCredits(models.NormalizarTipoEquivalencia(equivalence.Type), equivalence.Credits, targets); err != nil {
+		return nil, err
+	}
+
+	if updates.ExtraSourceCodes != nil {
+		if err := db.Model(&equivalence).Association("ExtraSourceSubjects").Replace(extraSources); err != nil {
+			return nil, errors.New("failed to update equivalence source subjects: " + err.Error())
+		}
+	}
+	if updates.ExtraTargetCodes != nil {
+		if err := db.Model(&equivalence).Association("ExtraTargetSubjects").Replace(targets[1:]); err != nil {
+			return nil, errors.New("failed to update equivalence target subjects: " + err.Error())
+		}
+	}
+
+	if err := db.Omit("TargetSubject", "ExtraSourceSubjects", "ExtraTargetSubjects").Save(&equivalence).Error; err != nil {
 		return nil, errors.New("failed to update equivalence: " + err.Error())
 	}
 
 	// Cargar las relaciones
-	db.Preload("SourceSubject").Preload("TargetSubject").Preload("Career").First(&equivalence, equivalence.ID)
+	db.Preload("SourceSubject").Preload("TargetSubject").Preload("ExtraSourceSubjects").Preload("ExtraTargetSubjects").Preload("Career").First(&equivalence, equivalence.ID)
 
 	return &equivalence, nil
 }
@@ -589,7 +833,7 @@ func UpdateSourceSubject(db *gorm.DB, equivalenceID uint, updates struct {
 	}
 
 	// Recargar equivalence con la materia actualizada
-	db.Preload("SourceSubject").Preload("TargetSubject").Preload("Career").First(&equivalence, equivalence.ID)
+	db.Preload("SourceSubject").Preload("TargetSubject").Preload("ExtraSourceSubjects").Preload("ExtraTargetSubjects").Preload("Career").First(&equivalence, equivalence.ID)
 	return &equivalence, nil
 }
 
@@ -601,7 +845,13 @@ func DeleteEquivalence(db *gorm.DB, equivalenceID uint) error {
 		return errors.New("equivalence not found")
 	}
 
-	// Eliminar solo la equivalencia
+	// Eliminar solo la equivalencia y sus vínculos con las materias adicionales
+	if err := db.Model(&equivalence).Association("ExtraSourceSubjects").Clear(); err != nil {
+		return errors.New("failed to delete equivalence source subjects: " + err.Error())
+	}
+	if err := db.Model(&equivalence).Association("ExtraTargetSubjects").Clear(); err != nil {
+		return errors.New("failed to delete equivalence target subjects: " + err.Error())
+	}
 	if err := db.Delete(&equivalence).Error; err != nil {
 		return errors.New("failed to delete equivalence: " + err.Error())
 	}
@@ -612,7 +862,7 @@ func DeleteEquivalence(db *gorm.DB, equivalenceID uint) error {
 // GetEquivalencesBySubject obtiene todas las equivalencias donde una materia específica aparece
 func GetEquivalencesBySubject(db *gorm.DB, subjectID uint) ([]models.Equivalence, error) {
 	var equivalences []models.Equivalence
-	if err := db.Preload("SourceSubject").Preload("TargetSubject").Preload("Career").
+	if err := db.Preload("SourceSubject").Preload("TargetSubject").Preload("ExtraSourceSubjects").Preload("ExtraTargetSubjects").Preload("Career").
 		Where("source_subject_id = ? OR target_subject_id = ?", subjectID, subjectID).
 		Find(&equivalences).Error; err != nil {
 		return nil, errors.New("failed to fetch equivalences for subject: " + err.Error())
@@ -625,190 +875,152 @@ func GetEquivalencesBySubject(db *gorm.DB, subjectID uint) ([]models.Equivalence
 // CompareDobleTitulacion compara dos historias académicas para determinar materias homologables
 // Ahora recibe el código de la carrera objetivo y busca el plan activo
 func CompareDobleTitulacion(db *gorm.DB, historiaOrigen, historiaDoble, codigoCarreraObjetivo string) (*models.DobleTitulacionResult, error) {
-	// 1. Obtener el plan de estudio activo de la carrera objetivo
-	planObjetivo, err := GetStudyPlanByCareerCode(db, codigoCarreraObjetivo)
-	if err != nil {
-		return nil, errors.New("plan de estudio objetivo no encontrado para la carrera: " + codigoCarreraObjetivo)
-	}
-
-	// 2. Procesar ambas historias académicas
-	materiasOrigen := procesarHistoriaAcademicaTexto(historiaOrigen)
-	materiasDoble := procesarHistoriaAcademicaTexto(historiaDoble)
-
-	// 3. Obtener equivalencias relevantes para el plan objetivo
-	var equivalencias []models.Equivalence
-	db.Preload("SourceSubject").Preload("TargetSubject").Where("career_id = ?", planObjetivo.CareerID).Find(&equivalencias)
+	// Procesar ambas historias académicas (formato tabulado) y comparar las materias parseadas
+	materiasOrigen := parser.ParseTabular(historiaOrigen).SubjectInputs()
+	materiasDoble := parser.ParseTabular(historiaDoble).SubjectInputs()
 
-	// Crear mapa de equivalencias para búsqueda rápida
-	equivalenciaMap := make(map[string]string) // código origen -> código objetivo
-	for _, equiv := range equivalencias {
-		equivalenciaMap[equiv.SourceSubject.Code] = equiv.TargetSubject.Code
-	}
-
-	// 4. Crear mapas de materias cursadas para búsqueda rápida
-	materiasCursadasOrigen := make(map[string]models.SubjectInput)
-	for _, materia := range materiasOrigen {
-		materiasCursadasOrigen[materia.Code] = materia
-	}
-
-	materiasCursadasDoble := make(map[string]models.SubjectInput)
-	for _, materia := range materiasDoble {
-		materiasCursadasDoble[materia.Code] = materia
-	}
-
-	// 5. Comparar materias del plan objetivo con la historia de origen
-	var materiasHomologables []models.MateriaHomologable
-	totalCreditos := 0
-
-	for _, materiaPlan := range planObjetivo.Subjects {
-		// Buscar si la materia está en la historia de origen (directa o por equivalencia)
-		var materiaOrigen *models.SubjectInput
-		var codigoOrigen string
-		var nombreOrigen string
-		var tipologiaOrigen string
-		var equivalenciaInfo *models.EquivalenceResult
-
-		// Verificar coincidencia directa
-		if materia, existe := materiasCursadasOrigen[materiaPlan.Code]; existe {
-			materiaOrigen = &materia
-			codigoOrigen = materia.Code
-			nombreOrigen = materia.Name
-			tipologiaOrigen = string(materia.Type)
-		} else {
-			// Verificar por equivalencia
-			for codigoOrig, codigoObj := range equivalenciaMap {
-				if codigoObj == materiaPlan.Code {
-					if materia, existe := materiasCursadasOrigen[codigoOrig]; existe {
-						materiaOrigen = &materia
-						codigoOrigen = materia.Code
-						nombreOrigen = materia.Name
-						tipologiaOrigen = string(materia.Type)
-						equivalenciaInfo = &models.EquivalenceResult{
-							Type:  "TOTAL",
-							Notes: fmt.Sprintf("Equivalencia: %s → %s", codigoOrig, materiaPlan.Code),
-						}
-						break
-					}
-				}
-			}
-		}
-
-		// Si encontramos la materia en origen y NO está en la historia de doble titulación
-		if materiaOrigen != nil {
-			if _, yaCursadaEnDoble := materiasCursadasDoble[materiaPlan.Code]; !yaCursadaEnDoble {
-				materiaHomologable := models.MateriaHomologable{
-					CodigoObjetivo:    materiaPlan.Code,
-					NombreObjetivo:    materiaPlan.Name,
-					Creditos:          materiaPlan.Credits,
-					TipologiaObjetivo: materiaPlan.Type,
-					CodigoOrigen:      codigoOrigen,
-					NombreOrigen:      nombreOrigen,
-					TipologiaOrigen:   tipologiaOrigen,
-					Periodo:           materiaOrigen.Semester,
-					Calificacion:      materiaOrigen.Grade,
-					Equivalencia:      equivalenciaInfo,
-				}
-
-				materiasHomologables = append(materiasHomologables, materiaHomologable)
-				totalCreditos += materiaPlan.Credits
-			}
-		}
-	}
-
-	// 6. Calcular resumen
-	resumen := models.ResumenDobleTitulacion{
-		MateriasCursadasOrigen: len(materiasOrigen),
-		MateriasCursadasDoble:  len(materiasDoble),
-		MateriasHomologables:   len(materiasHomologables),
-		CreditosHomologables:   totalCreditos,
-	}
-
-	// Calcular porcentaje de homologación
-	if planObjetivo.TotalCredits > 0 {
-		resumen.PorcentajeHomologacion = float64(totalCreditos) / float64(planObjetivo.TotalCredits) * 100
-	}
-
-	return &models.DobleTitulacionResult{
-		MateriasHomologables: materiasHomologables,
-		TotalMaterias:        len(materiasHomologables),
-		TotalCreditos:        totalCreditos,
-		Resumen:              resumen,
-	}, nil
+	return CompareDobleTitulacionParsed(db, materiasOrigen, materiasDoble, codigoCarreraObjetivo, "", "", false)
 }
 
-// CompareDobleTitulacionParsed compara dos listas de materias ya parseadas para doble titulación
-func CompareDobleTitulacionParsed(db *gorm.DB, materiasOrigen, materiasDoble []models.SubjectInput, codigoCarreraObjetivo string) (*models.DobleTitulacionResult, error) {
-	// 1. Obtener el plan de estudio activo de la carrera objetivo
-	planObjetivo, err := GetStudyPlanByCareerCode(db, codigoCarreraObjetivo)
+// CompareDobleTitulacionParsed compara dos listas de materias ya parseadas para doble titulación.
+// El plan objetivo es la versión indicada, el vigente en el periodo de admisión o el plan activo.
+// Con explicar se registra en orden cómo se decidió cada materia del plan objetivo.
+func CompareDobleTitulacionParsed(db *gorm.DB, materiasOrigen, materiasDoble []models.SubjectInput, codigoCarreraObjetivo, versionPlan, periodoAdmision string, explicar bool) (*models.DobleTitulacionResult, error) {
+	// 1. Obtener el plan de estudio de la carrera objetivo que aplica al estudiante
+	planObjetivo, err := ResolveStudyPlan(db, codigoCarreraObjetivo, versionPlan, periodoAdmision)
 	if err != nil {
-		return nil, errors.New("plan de estudio objetivo no encontrado para la carrera: " + codigoCarreraObjetivo)
+		return nil, errors.New("plan de estudio objetivo no encontrado: " + err.Error())
 	}
 
 	// 2. Obtener equivalencias relevantes para el plan objetivo
-	var equivalencias []models.Equivalence
-	db.Preload("SourceSubject").Preload("TargetSubject").Where("career_id = ?", planObjetivo.CareerID).Find(&equivalencias)
+	equivalencias := loadCareerEquivalences(db, planObjetivo.CareerID)
+
+	// Las equivalencias se aplican del plan de origen al plan objetivo. Se guardan todas: varias
+	// equivalencias pueden tener la misma materia origen (una a varias) o el mismo destino
+	indiceEquivalencias := newEquivalenceIndex(equivalencias, false)
 
-	// Crear mapa de equivalencias para búsqueda rápida
-	equivalenciaMap := make(map[string]string) // código origen -> código objetivo
-	for _, equiv := range equivalencias {
-		equivalenciaMap[equiv.SourceSubject.Code] = equiv.TargetSubject.Code
+	// Resolver los códigos de ambas historias (alias y variantes de formato) a los códigos del catálogo
+	codigosCanonicos := make([]string, 0, len(planObjetivo.Subjects)+len(indiceEquivalencias.codes))
+	for _, materia := range planObjetivo.Subjects {
+		codigosCanonicos = append(codigosCanonicos, materia.Code)
 	}
+	codigosCanonicos = append(codigosCanonicos, indiceEquivalencias.codes...)
+	resolver := newCodeResolver(db, codigosCanonicos)
+	decisiones := newDecisionLog(explicar)
+	materiasOrigenOriginales := materiasOrigen
+	materiasOrigen, codigosResueltos, resolucionesOrigen := resolver.resolveSubjects(materiasOrigen)
+	materiasDoble, _, _ = resolver.resolveSubjects(materiasDoble)
+	decisiones.codes(materiasOrigenOriginales, resolucionesOrigen)
 
 	// 3. Crear mapas de materias cursadas para búsqueda rápida
+	// Solo se homologan materias aprobadas en el plan de origen; en el plan doble
+	// se consideran ya cursadas las aprobadas y las que están en curso.
+	// Las materias repetidas se consolidan usando el último intento aprobado.
+	materiasOrigen = models.MergeAttempts(materiasOrigen)
+	materiasDoble = models.MergeAttempts(materiasDoble)
+	materiasOrigenPorCodigo := make(map[string]models.SubjectInput) // todas las materias de origen, para explicar descartes
 	materiasCursadasOrigen := make(map[string]models.SubjectInput)
 	for _, materia := range materiasOrigen {
-		materiasCursadasOrigen[materia.Code] = materia
+		materiasOrigenPorCodigo[materia.Code] = materia
+		if materia.IsApproved() {
+			materiasCursadasOrigen[materia.Code] = materia
+		}
 	}
 
 	materiasCursadasDoble := make(map[string]models.SubjectInput)
 	for _, materia := range materiasDoble {
-		materiasCursadasDoble[materia.Code] = materia
+		if materia.IsApproved() || materia.IsInProgress() {
+			materiasCursadasDoble[materia.Code] = materia
+		}
 	}
 
 	// 4. Comparar materias del plan objetivo con la historia de origen
 	var materiasHomologables []models.MateriaHomologable
+	materiasNoHomologables := []models.MateriaNoHomologable{}
 	totalCreditos := 0
 
 	for _, materiaPlan := range planObjetivo.Subjects {
 		// Buscar si la materia está en la historia de origen (directa o por equivalencia)
 		var materiaOrigen *models.SubjectInput
+		var materiasEquivalentes []models.SubjectInput
 		var codigoOrigen string
 		var nombreOrigen string
 		var tipologiaOrigen string
 		var equivalenciaInfo *models.EquivalenceResult
+		creditos := materiaPlan.Credits
 
 		// Verificar coincidencia directa
 		if materia, existe := materiasCursadasOrigen[materiaPlan.Code]; existe {
 			materiaOrigen = &materia
+			materiasEquivalentes = []models.SubjectInput{materia}
 			codigoOrigen = materia.Code
 			nombreOrigen = materia.Name
 			tipologiaOrigen = string(materia.Type)
-		} else {
-			// Verificar por equivalencia
-			for codigoOrig, codigoObj := range equivalenciaMap {
-				if codigoObj == materiaPlan.Code {
-					if materia, existe := materiasCursadasOrigen[codigoOrig]; existe {
-						materiaOrigen = &materia
-						codigoOrigen = materia.Code
-						nombreOrigen = materia.Name
-						tipologiaOrigen = string(materia.Type)
-						equivalenciaInfo = &models.EquivalenceResult{
-							Type:  "TOTAL",
-							Notes: "Equivalencia: " + codigoOrig + " → " + materiaPlan.Code,
-						}
-						break
-					}
-				}
-			}
+		} else if coincidencia := indiceEquivalencias.match(materiasCursadasOrigen, materiaPlan.Code); coincidencia != nil {
+			// Verificar por equivalencia: una equivalencia compuesta reúne varias materias de origen
+			materiasEquivalentes = coincidencia.subjects
+			materia := combinarMateriasOrigen(coincidencia.subjects)
+			materiaOrigen = &materia
+			codigoOrigen = materia.Code
+			nombreOrigen = materia.Name
+			tipologiaOrigen = string(materia.Type)
+			equivalenciaInfo = coincidencia.result(materiaPlan, "Equivalencia: "+coincidencia.chainDescription())
+			creditos = equivalenciaInfo.Credits
+		}
+
+		switch {
+		case materiaOrigen == nil:
+			decisiones.rejected(materiaPlan.Code, materiasOrigenPorCodigo, indiceEquivalencias, nil)
+			decisiones.add(models.Decision{
+				Kind:     models.DecisionSinCoincidencia,
+				PlanCode: materiaPlan.Code,
+				Message:  "Ninguna materia aprobada de la historia de origen cubre la materia, ni directamente ni por equivalencia",
+			})
+		case equivalenciaInfo == nil:
+			decisiones.add(models.Decision{
+				Kind:        models.DecisionCoincidenciaDirecta,
+				PlanCode:    materiaPlan.Code,
+				HistoryCode: codigoOrigen,
+				Message:     "La materia está aprobada en la historia de origen con el mismo código",
+			})
+		default:
+			decisiones.rejected(materiaPlan.Code, materiasOrigenPorCodigo, indiceEquivalencias, equivalenciaInfo)
+			decisiones.add(equivalenceDecision(materiaPlan, equivalenciaInfo))
+		}
+
+		// Una materia obtenida por homologación en origen no se vuelve a homologar
+		if materiaOrigen != nil && algunaHomologada(materiasEquivalentes) {
+			materiasNoHomologables = append(materiasNoHomologables, models.MateriaNoHomologable{
+				CodigoObjetivo: materiaPlan.Code,
+				NombreObjetivo: materiaPlan.Name,
+				CodigoOrigen:   codigoOrigen,
+				NombreOrigen:   nombreOrigen,
+				Modalidad:      models.ModalidadHomologacion,
+				Motivo:         "La materia de origen fue obtenida por homologación y no se puede homologar de nuevo",
+			})
+			decisiones.add(models.Decision{
+				Kind:        models.DecisionDescartada,
+				PlanCode:    materiaPlan.Code,
+				HistoryCode: codigoOrigen,
+				Message:     "La materia de origen fue obtenida por homologación y no se puede homologar de nuevo",
+			})
+			continue
 		}
 
 		// Si encontramos la materia en origen y NO está en la historia de doble titulación
 		if materiaOrigen != nil {
-			if _, yaCursadaEnDoble := materiasCursadasDoble[materiaPlan.Code]; !yaCursadaEnDoble {
+			if materiaDoble, yaCursadaEnDoble := materiasCursadasDoble[materiaPlan.Code]; yaCursadaEnDoble {
+				decisiones.add(models.Decision{
+					Kind:        models.DecisionDescartada,
+					PlanCode:    materiaPlan.Code,
+					HistoryCode: materiaDoble.Code,
+					Message:     "La materia ya está en la historia de doble titulación con estado " + historyStatus(materiaDoble) + " y no se homologa",
+				})
+			} else {
 				materiaHomologable := models.MateriaHomologable{
 					CodigoObjetivo:    materiaPlan.Code,
 					NombreObjetivo:    materiaPlan.Name,
-					Creditos:          materiaPlan.Credits,
+					Creditos:          creditos,
 					TipologiaObjetivo: materiaPlan.Type,
 					CodigoOrigen:      codigoOrigen,
 					NombreOrigen:      nombreOrigen,
@@ -816,10 +1028,15 @@ func CompareDobleTitulacionParsed(db *gorm.DB, materiasOrigen, materiasDoble []m
 					Periodo:           materiaOrigen.Semester,
 					Calificacion:      materiaOrigen.Grade,
 					Equivalencia:      equivalenciaInfo,
+					Modalidad:         materiaOrigen.Modality,
+					Validada:          materiaOrigen.Modality == models.ModalidadValidacion,
+					CodigoResuelto:    codigosResueltos[materiaOrigen.Code],
 				}
 
 				materiasHomologables = append(materiasHomologables, materiaHomologable)
-				totalCreditos += materiaPlan.Credits
+				if materiaPlan.Type.CuentaParaGrado() {
+					totalCreditos += creditos
+				}
 			}
 		}
 	}
@@ -838,101 +1055,53 @@ func CompareDobleTitulacionParsed(db *gorm.DB, materiasOrigen, materiasDoble []m
 	}
 
 	return &models.DobleTitulacionResult{
+		PlanObjetivoID:       planObjetivo.ID,
+		VersionPlanObjetivo:  planObjetivo.Version,
 		MateriasHomologables: materiasHomologables,
 		TotalMaterias:        len(materiasHomologables),
 		TotalCreditos:        totalCreditos,
+		MateriasRepetidas:    models.RepeatedSubjects(materiasOrigen),
+		MateriasNoHomologables: materiasNoHomologables,
 		Resumen:              resumen,
+		DecisionLog:          decisiones.entries,
 	}, nil
 }
 
-// procesarHistoriaAcademicaTexto procesa el texto de historia académica y retorna una lista de materias
-func procesarHistoriaAcademicaTexto(texto string) []models.SubjectInput {
-	var materias []models.SubjectInput
-	
-	// Dividir por líneas
-	lineas := strings.Split(texto, "\n")
-	
-	for _, linea := range lineas {
-		linea = strings.TrimSpace(linea)
-		if linea == "" {
-			continue
-		}
-		
-		// Procesar línea (asumiendo formato tabulado como en el proyecto de referencia)
-		partes := strings.Split(linea, "\t")
-		if len(partes) >= 5 {
-			nombreCompleto := partes[0]
-			
-			// Extraer código del formato "Nombre (CÓDIGO)"
-			codigo := ""
-			nombre := nombreCompleto
-			if match := regexp.MustCompile(`(.+)\s\((\d{6,}-?[A-Za-z]?)\)`).FindStringSubmatch(nombreCompleto); match != nil {
-				nombre = strings.TrimSpace(match[1])
-				codigo = strings.TrimSpace(match[2])
-			}
-			
-			// Extraer créditos
-			creditos := 0
-			if creditosStr := strings.TrimSpace(partes[1]); creditosStr != "" {
-				if c, err := strconv.Atoi(creditosStr); err == nil {
-					creditos = c
-				}
-			}
-			
-			// Extraer tipo/tipología
-			tipo := strings.TrimSpace(partes[2])
-			
-			// Extraer periodo
-			periodo := strings.TrimSpace(partes[3])
-			
-			// Extraer calificación
-			calificacion := 0.0
-			if calStr := strings.TrimSpace(partes[4]); calStr != "" {
-				if cal, err := strconv.ParseFloat(calStr, 64); err == nil {
-					calificacion = cal
-				}
-			}
-			
-			// Mapear tipología
-			tipologia := mapearTipologia(tipo)
-			
-			materia := models.SubjectInput{
-				Code:     codigo,
-				Name:     nombre,
-				Credits:  creditos,
-				Type:     tipologia,
-				Grade:    calificacion,
-				Status:   "APROBADA", // Asumimos que todas las materias en la historia están aprobadas
-				Semester: periodo,
-			}
-			
-			materias = append(materias, materia)
+// combinarMateriasOrigen reúne las materias de origen de una equivalencia compuesta en una sola:
+// códigos y nombres unidos con " + ", el periodo más reciente y el promedio ponderado por créditos
+func combinarMateriasOrigen(materias []models.SubjectInput) models.SubjectInput {
+	if len(materias) == 1 {
+		return materias[0]
+	}
+	combinada := materias[0]
+	codigos := make([]string, len(materias))
+	nombres := make([]string, len(materias))
+	creditos := 0
+	ponderado := 0.0
+	for i, materia := range materias {
+		codigos[i] = materia.Code
+		nombres[i] = materia.Name
+		creditos += materia.Credits
+		ponderado += materia.Grade * float64(materia.Credits)
+		if materia.Semester > combinada.Semester {
+			combinada.Semester = materia.Semester
 		}
 	}
-	
-	return materias
+	combinada.Code = strings.Join(codigos, " + ")
+	combinada.Name = strings.Join(nombres, " + ")
+	combinada.Credits = creditos
+	if creditos > 0 {
+		combinada.Grade = math.Round(ponderado/float64(creditos)*100) / 100
+	}
+	return combinada
 }
 
-// mapearTipologia convierte las tipologías del texto a las del modelo
-func mapearTipologia(tipo string) models.TipologiaAsignatura {
-	tipo = strings.ToUpper(tipo)
-	
-	switch {
-	case strings.Contains(tipo, "FUNDAMENTACIÓN OBLIGATORIA") || strings.Contains(tipo, "FUND. OBLIGATORIA"):
-		return models.TipologiaFundamentalObligatoria
-	case strings.Contains(tipo, "FUNDAMENTACIÓN OPTATIVA") || strings.Contains(tipo, "FUND. OPTATIVA"):
-		return models.TipologiaFundamentalOptativa
-	case strings.Contains(tipo, "DISCIPLINAR OBLIGATORIA"):
-		return models.TipologiaDisciplinarObligatoria
-	case strings.Contains(tipo, "DISCIPLINAR OPTATIVA"):
-		return models.TipologiaDisciplinarOptativa
-	case strings.Contains(tipo, "LIBRE ELECCIÓN") || strings.Contains(tipo, "LIBRE ELECCIÓN"):
-		return models.TipologiaLibreEleccion
-	case strings.Contains(tipo, "TRABAJO DE GRADO"):
-		return models.TipologiaTrabajoGrado
-	default:
-		return models.TipologiaLibreEleccion
+// algunaHomologada indica si alguna de las materias de origen fue obtenida por homologación
+func algunaHomologada(materias []models.SubjectInput) bool {
+	for _, materia := range materias {
+		if materia.Modality == models.ModalidadHomologacion {
+			return true
+		}
 	}
+	return false
 }
-
-
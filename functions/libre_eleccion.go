@@ -0,0 +1,46 @@
+package functions
+
+import "olimpo-vicedecanatura/models"
+
+// libreEleccion abona créditos a libre elección hasta el cupo del plan y guarda los que no caben
+type libreEleccion struct {
+	ledger *models.CreditLedger
+	quota  int
+	unused []models.CreditLedgerEntry
+}
+
+func newLibreEleccion(ledger *models.CreditLedger, quota int) *libreEleccion {
+	return &libreEleccion{ledger: ledger, quota: quota, unused: []models.CreditLedgerEntry{}}
+}
+
+// add abona los créditos de la entrada a libre elección; la parte que excede el cupo queda sin usar
+// con la tipología original de la entrada. Retorna los créditos abonados.
+func (l *libreEleccion) add(entry models.CreditLedgerEntry) int {
+	credits := l.quota - l.ledger.Completed(models.TipologiaLibreEleccion)
+	if credits > entry.Credits {
+		credits = entry.Credits
+	}
+	if credits < 0 {
+		credits = 0
+	}
+
+	if credits > 0 {
+		abono := entry
+		abono.Tipologia = models.TipologiaLibreEleccion
+		abono.Credits = credits
+		l.ledger.Add(abono)
+	}
+	if rest := entry.Credits - credits; rest > 0 {
+		sobrante := entry
+		sobrante.Credits = rest
+		sobrante.Notes = "Excede el cupo de libre elección del plan"
+		l.unused = append(l.unused, sobrante)
+	}
+	return credits
+}
+
+// discard reporta una entrada como créditos sin usar
+func (l *libreEleccion) discard(entry models.CreditLedgerEntry, notes string) {
+	entry.Notes = notes
+	l.unused = append(l.unused, entry)
+}
@@ -0,0 +1,93 @@
+package functions
+
+import (
+	"strings"
+
+	"olimpo-vicedecanatura/models"
+)
+
+// ReconcileCreditsSummary compara el resumen de créditos reportado por el SIA con el calculado
+// por el motor de comparación y marca cada tipología en la que ambos no coinciden.
+// Una discrepancia indica un error en el catálogo de materias o en las equivalencias.
+func ReconcileCreditsSummary(sia []models.ResumenCreditos, computed models.CreditsSummary) models.CreditReconciliation {
+	reconciliation := models.CreditReconciliation{
+		SIA:           sia,
+		Computed:      computed,
+		Discrepancies: []models.CreditDiscrepancy{},
+		Unmatched:     []models.TipologiaAsignatura{},
+	}
+	if reconciliation.SIA == nil {
+		reconciliation.SIA = []models.ResumenCreditos{}
+	}
+
+	for _, row := range sia {
+		info, ok := creditInfoForTipologia(computed, row.Tipologia)
+		if !ok {
+			reconciliation.Unmatched = append(reconciliation.Unmatched, row.Tipologia)
+			continue
+		}
+
+		// Los créditos de nivelación exigidos dependen de la admisión de cada estudiante y el sistema
+		// solo conoce los cursos que ya aparecen en la historia: se comparan únicamente los aprobados.
+		// Lo mismo aplica al total del estudiante, que suma la nivelación al total del plan
+		if row.Exigidos != info.Required && row.Tipologia.CuentaParaGrado() && !esTotalEstudiante(row.Tipologia) {
+			reconciliation.Discrepancies = append(reconciliation.Discrepancies, models.CreditDiscrepancy{
+				Tipologia:  row.Tipologia,
+				Field:      "exigidos",
+				SIA:        row.Exigidos,
+				Computed:   info.Required,
+				Difference: info.Required - row.Exigidos,
+			})
+		}
+		if row.Aprobados != info.Completed {
+			reconciliation.Discrepancies = append(reconciliation.Discrepancies, models.CreditDiscrepancy{
+				Tipologia:  row.Tipologia,
+				Field:      "aprobados",
+				SIA:        row.Aprobados,
+				Computed:   info.Completed,
+				Difference: info.Completed - row.Aprobados,
+			})
+		}
+	}
+
+	reconciliation.Consistent = len(sia) > 0 && len(reconciliation.Discrepancies) == 0 && len(reconciliation.Unmatched) == 0
+	return reconciliation
+}
+
+// creditInfoForTipologia obtiene del resumen calculado la fila equivalente a una tipología del SIA
+func creditInfoForTipologia(summary models.CreditsSummary, tipologia models.TipologiaAsignatura) (models.CreditTypeInfo, bool) {
+	switch models.TipologiaAsignatura(strings.ToUpper(string(tipologia))) {
+	case models.TipologiaFundamentalObligatoria:
+		return summary.FundObligatoria, true
+	case models.TipologiaFundamentalOptativa:
+		return summary.FundOptativa, true
+	case models.TipologiaDisciplinarObligatoria:
+		return summary.DisObligatoria, true
+	case models.TipologiaDisciplinarOptativa:
+		return summary.DisOptativa, true
+	case models.TipologiaLibreEleccion:
+		return summary.Libre, true
+	case models.TipologiaTrabajoGrado:
+		return summary.TrabajoGrado, true
+	case models.TipologiaNivelacion:
+		return summary.Nivelacion, true
+	case "TOTAL":
+		return summary.Total, true
+	case totalEstudiante:
+		return models.CreditTypeInfo{
+			Required:  summary.Total.Required + summary.Nivelacion.Required,
+			Completed: summary.Total.Completed + summary.Nivelacion.Completed,
+			Missing:   summary.Total.Missing + summary.Nivelacion.Missing,
+		}, true
+	default:
+		return models.CreditTypeInfo{}, false
+	}
+}
+
+// totalEstudiante es la fila del SIA que suma los créditos del plan y los de nivelación
+const totalEstudiante models.TipologiaAsignatura = "TOTAL ESTUDIANTE"
+
+// esTotalEstudiante indica si la fila del SIA es el total del estudiante
+func esTotalEstudiante(tipologia models.TipologiaAsignatura) bool {
+	return models.TipologiaAsignatura(strings.ToUpper(string(tipologia))) == totalEstudiante
+}
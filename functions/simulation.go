@@ -0,0 +1,218 @@
+package functions
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"gorm.io/gorm"
+	"olimpo-vicedecanatura/models"
+)
+
+// SimulateAcademicHistory compara la historia académica con su plan y vuelve a compararla con los
+// cambios hipotéticos (materias por cursar, materias retiradas o un plan objetivo distinto), para que
+// el asesor planee los próximos semestres del estudiante. Retorna ambas comparaciones y sus diferencias.
+func SimulateAcademicHistory(db *gorm.DB, input models.SimulationInput) (*models.SimulationResult, error) {
+	history := input.History
+	baselinePlan, err := ResolveStudyPlan(db, history.CareerCode, history.PlanVersion, history.AdmissionPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sin plan objetivo la simulación usa el mismo plan que la comparación base
+	targetPlan := baselinePlan
+	targetCareer := history.CareerCode
+	if input.TargetCareerCode != "" || input.TargetPlanVersion != "" || input.TargetAdmissionPeriod != "" {
+		if input.TargetCareerCode != "" {
+			targetCareer = input.TargetCareerCode
+		}
+		if targetPlan, err = ResolveStudyPlan(db, targetCareer, input.TargetPlanVersion, input.TargetAdmissionPeriod); err != nil {
+			return nil, err
+		}
+	}
+
+	kept, err := dropSubjects(history.Subjects, input.Drop)
+	if err != nil {
+		return nil, err
+	}
+	added, err := hypotheticalSubjects(input.Add, *targetPlan, history.Subjects)
+	if err != nil {
+		return nil, err
+	}
+
+	baseline, err := CompareAcademicHistoryWithStudyPlan(db, history, baselinePlan.ID)
+	if err != nil {
+		return nil, err
+	}
+	simulatedHistory := history
+	simulatedHistory.CareerCode = targetCareer
+	simulatedHistory.Subjects = append(kept, added...)
+	simulated, err := CompareAcademicHistoryWithStudyPlan(db, simulatedHistory, targetPlan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := simulationDiff(baseline, simulated)
+	diff.Dropped = input.Drop
+	if diff.Dropped == nil {
+		diff.Dropped = []string{}
+	}
+	diff.Added = make([]string, len(added))
+	for i, subject := range added {
+		diff.Added[i] = subject.Code
+	}
+	diff.HypotheticalCredits, diff.HypotheticalAverage = weightedAverage(added)
+
+	return &models.SimulationResult{
+		BaselineStudyPlanID:  baselinePlan.ID,
+		BaselinePlanVersion:  baselinePlan.Version,
+		SimulatedStudyPlanID: targetPlan.ID,
+		SimulatedPlanVersion: targetPlan.Version,
+		Baseline:             baseline,
+		Simulated:            simulated,
+		Diff:                 diff,
+	}, nil
+}
+
+// dropSubjects retira de la historia todos los intentos de las materias indicadas
+func dropSubjects(subjects []models.SubjectInput, codes []string) ([]models.SubjectInput, error) {
+	drop := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		drop[strings.ToUpper(strings.TrimSpace(code))] = true
+	}
+
+	found := make(map[string]bool, len(codes))
+	kept := make([]models.SubjectInput, 0, len(subjects))
+	for _, subject := range subjects {
+		code := strings.ToUpper(strings.TrimSpace(subject.Code))
+		if drop[code] {
+			found[code] = true
+			continue
+		}
+		kept = append(kept, subject)
+	}
+	for _, code := range codes {
+		if !found[strings.ToUpper(strings.TrimSpace(code))] {
+			return nil, errors.New("la materia " + code + " no está en la historia académica")
+		}
+	}
+	return kept, nil
+}
+
+// hypotheticalSubjects completa las materias hipotéticas con los datos del plan objetivo y las ubica
+// en el periodo siguiente al último de la historia cuando no indican semestre
+func hypotheticalSubjects(subjects []models.SubjectInput, studyPlan models.StudyPlan, history []models.SubjectInput) ([]models.SubjectInput, error) {
+	planSubjects := make(map[string]models.Subject, len(studyPlan.Subjects))
+	for _, subject := range studyPlan.Subjects {
+		planSubjects[subject.Code] = subject
+	}
+
+	lastPeriod := ""
+	for _, subject := range history {
+		if lastPeriod == "" || models.ComparePeriods(subject.Semester, lastPeriod) > 0 {
+			lastPeriod = subject.Semester
+		}
+	}
+
+	added := make([]models.SubjectInput, 0, len(subjects))
+	for _, subject := range subjects {
+		subject.Code = strings.TrimSpace(subject.Code)
+		if subject.Code == "" {
+			return nil, errors.New("las materias hipotéticas requieren código")
+		}
+		if planSubject, exists := planSubjects[subject.Code]; exists {
+			if subject.Name == "" {
+				subject.Name = planSubject.Name
+			}
+			if subject.Credits == 0 {
+				subject.Credits = planSubject.Credits
+			}
+			if subject.Type == "" {
+				subject.Type = planSubject.Type
+			}
+		} else if subject.Credits <= 0 || subject.Type == "" {
+			return nil, errors.New("la materia " + subject.Code + " no está en el plan objetivo: indique sus créditos y tipología")
+		}
+		if subject.Name == "" {
+			subject.Name = subject.Code
+		}
+		if subject.Status == "" && subject.GradeLabel == "" && subject.Grade == 0 {
+			return nil, errors.New("la materia " + subject.Code + " requiere la nota esperada o su estado")
+		}
+		if subject.Semester == "" {
+			subject.Semester = models.NextPeriod(lastPeriod)
+		}
+		added = append(added, subject)
+	}
+	return added, nil
+}
+
+// simulationTipologias son las filas del resumen de créditos que se comparan en la simulación
+var simulationTipologias = []models.TipologiaAsignatura{
+	models.TipologiaFundamentalObligatoria,
+	models.TipologiaFundamentalOptativa,
+	models.TipologiaDisciplinarObligatoria,
+	models.TipologiaDisciplinarOptativa,
+	models.TipologiaLibreEleccion,
+	models.TipologiaTrabajoGrado,
+	models.TipologiaNivelacion,
+	"TOTAL",
+}
+
+// simulationDiff compara los créditos por tipología, el avance y las materias pendientes de dos comparaciones
+func simulationDiff(baseline, simulated *models.ComparisonResult) models.SimulationDiff {
+	diff := models.SimulationDiff{
+		Credits:          make([]models.CreditsDiff, 0, len(simulationTipologias)),
+		CompletionBefore: baseline.CreditsSummary.CompletionPercentage(),
+		CompletionAfter:  simulated.CreditsSummary.CompletionPercentage(),
+		PendingBefore:    len(baseline.MissingSubjects),
+		PendingAfter:     len(simulated.MissingSubjects),
+		NewlyCovered:     pendingOnlyIn(baseline.MissingSubjects, simulated.MissingSubjects),
+		NewlyPending:     pendingOnlyIn(simulated.MissingSubjects, baseline.MissingSubjects),
+	}
+	for _, tipologia := range simulationTipologias {
+		before, _ := creditInfoForTipologia(baseline.CreditsSummary, tipologia)
+		after, _ := creditInfoForTipologia(simulated.CreditsSummary, tipologia)
+		diff.Credits = append(diff.Credits, models.CreditsDiff{
+			Tipologia:       tipologia,
+			CompletedBefore: before.Completed,
+			CompletedAfter:  after.Completed,
+			CompletedDelta:  after.Completed - before.Completed,
+			MissingBefore:   before.Missing,
+			MissingAfter:    after.Missing,
+		})
+	}
+	return diff
+}
+
+// pendingOnlyIn retorna los códigos de las materias pendientes en a que no están pendientes en b
+func pendingOnlyIn(a, b []models.SubjectResult) []string {
+	pending := make(map[string]bool, len(b))
+	for _, subject := range b {
+		pending[subject.Code] = true
+	}
+	codes := []string{}
+	for _, subject := range a {
+		if !pending[subject.Code] {
+			codes = append(codes, subject.Code)
+		}
+	}
+	return codes
+}
+
+// weightedAverage calcula el promedio ponderado por créditos de las materias con nota numérica
+func weightedAverage(subjects []models.SubjectInput) (int, float64) {
+	credits := 0
+	weighted := 0.0
+	for _, subject := range subjects {
+		if subject.GradeLabel != "" || subject.Grade <= 0 {
+			continue
+		}
+		credits += subject.Credits
+		weighted += subject.Grade * float64(subject.Credits)
+	}
+	if credits == 0 {
+		return 0, 0
+	}
+	return credits, math.Round(weighted/float64(credits)*100) / 100
+}
@@ -0,0 +1,139 @@
+package functions
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"olimpo-vicedecanatura/models"
+)
+
+// ResolveStudyPlan obtiene el plan de estudio de la carrera que aplica al estudiante. Con version se
+// usa esa versión del plan, esté activa o no; con admissionPeriod se usa el plan de admisión más
+// reciente que no sea posterior al periodo; sin ninguno de los dos se usa el plan activo.
+func ResolveStudyPlan(db *gorm.DB, careerCode, version, admissionPeriod string) (*models.StudyPlan, error) {
+	version, admissionPeriod = strings.TrimSpace(version), strings.TrimSpace(admissionPeriod)
+	if version == "" && admissionPeriod == "" {
+		return GetStudyPlanByCareerCode(db, careerCode)
+	}
+
+	plans, err := careerStudyPlans(db, careerCode)
+	if err != nil {
+		return nil, err
+	}
+	if version != "" {
+		for i := range plans {
+			if strings.EqualFold(plans[i].Version, version) {
+				return &plans[i], nil
+			}
+		}
+		return nil, errors.New("la carrera " + careerCode + " no tiene un plan de estudio con la versión " + version)
+	}
+	return planForAdmissionPeriod(plans, careerCode, admissionPeriod)
+}
+
+// careerStudyPlans carga todos los planes de estudio de una carrera, activos o no, en orden de creación
+func careerStudyPlans(db *gorm.DB, careerCode string) ([]models.StudyPlan, error) {
+	var plans []models.StudyPlan
+	err := db.Preload("Subjects").Preload("Career").
+		Joins("JOIN careers ON careers.id = study_plans.career_id").
+		Where("careers.code = ?", careerCode).
+		Order("study_plans.id").
+		Find(&plans).Error
+	if err != nil {
+		return nil, errors.New("error obteniendo los planes de estudio de la carrera " + careerCode + ": " + err.Error())
+	}
+	if len(plans) == 0 {
+		return nil, errors.New("no se encontraron planes de estudio para la carrera: " + careerCode)
+	}
+	return plans, nil
+}
+
+// planForAdmissionPeriod elige el plan cuyo primer periodo de admisión es el más reciente sin superar
+// el periodo indicado. Los planes sin periodo de admisión conocido no se tienen en cuenta.
+func planForAdmissionPeriod(plans []models.StudyPlan, careerCode, admissionPeriod string) (*models.StudyPlan, error) {
+	if !models.ValidarPeriodo(admissionPeriod) {
+		return nil, errors.New("periodo de admisión inválido: " + admissionPeriod + " (se espera la forma 2021-1S o 2021-1)")
+	}
+
+	var selected *models.StudyPlan
+	for i := range plans {
+		start := plans[i].AdmissionStart()
+		if start == "" || models.ComparePeriods(start, admissionPeriod) > 0 {
+			continue
+		}
+		// A igual periodo de inicio se prefiere el plan creado después
+		if selected == nil || models.ComparePeriods(start, selected.AdmissionStart()) >= 0 {
+			selected = &plans[i]
+		}
+	}
+	if selected == nil {
+		return nil, errors.New("la carrera " + careerCode + " no tiene un plan de estudio vigente para el periodo de admisión " + admissionPeriod)
+	}
+	return selected, nil
+}
+
+// CompareAcademicHistoryAllVersions compara la historia académica con cada versión del plan de estudio
+// de la carrera, para los estudiantes que siguen en un pensum anterior. Las versiones se ordenan por
+// su primer periodo de admisión (las que no lo tienen van al final) y se marca la que aplica al
+// estudiante según plan_version, admission_period o, sin ellos, el plan activo.
+func CompareAcademicHistoryAllVersions(db *gorm.DB, academicHistory models.AcademicHistoryInput) ([]models.PlanVersionComparison, error) {
+	plans, err := careerStudyPlans(db, academicHistory.CareerCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var appliesID uint
+	if applies, err := ResolveStudyPlan(db, academicHistory.CareerCode, academicHistory.PlanVersion, academicHistory.AdmissionPeriod); err == nil {
+		appliesID = applies.ID
+	} else if academicHistory.PlanVersion != "" || academicHistory.AdmissionPeriod != "" {
+		return nil, err
+	}
+
+	sort.SliceStable(plans, func(i, j int) bool {
+		a, b := plans[i].AdmissionStart(), plans[j].AdmissionStart()
+		if a == "" || b == "" {
+			return a != "" && b == ""
+		}
+		return models.ComparePeriods(a, b) < 0
+	})
+
+	comparisons := make([]models.PlanVersionComparison, 0, len(plans))
+	for _, plan := range plans {
+		result, err := CompareAcademicHistoryWithStudyPlan(db, academicHistory, plan.ID)
+		if err != nil {
+			return nil, errors.New("error comparando con el plan " + plan.Version + ": " + err.Error())
+		}
+		comparisons = append(comparisons, models.PlanVersionComparison{
+			StudyPlanID:          plan.ID,
+			Version:              plan.Version,
+			IsActive:             plan.IsActive,
+			AdmissionStart:       plan.AdmissionStart(),
+			Applies:              plan.ID == appliesID,
+			CompletionPercentage: result.CreditsSummary.CompletionPercentage(),
+			ApprovedSubjects:     len(result.EquivalentSubjects),
+			MissingSubjects:      len(result.MissingSubjects),
+			Result:               result,
+		})
+	}
+	return comparisons, nil
+}
+
+// UpdateStudyPlanAdmissionFrom cambia el primer periodo de admisión al que aplica el plan.
+// Un periodo vacío lo borra y el plan vuelve a usar su versión si tiene forma de periodo.
+func UpdateStudyPlanAdmissionFrom(db *gorm.DB, studyPlanID uint, period string) (*models.StudyPlan, error) {
+	period = strings.ToUpper(strings.TrimSpace(period))
+	if period != "" && !models.ValidarPeriodo(period) {
+		return nil, errors.New("invalid admission_from. Must be an academic period like 2021-1S or 2021-1")
+	}
+
+	var studyPlan models.StudyPlan
+	if err := db.First(&studyPlan, studyPlanID).Error; err != nil {
+		return nil, errors.New("study plan not found")
+	}
+	if err := db.Model(&studyPlan).Update("admission_from", period).Error; err != nil {
+		return nil, errors.New("failed to update study plan: " + err.Error())
+	}
+	return &studyPlan, nil
+}
@@ -0,0 +1,129 @@
+package functions
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"olimpo-vicedecanatura/models"
+	"olimpo-vicedecanatura/parser"
+)
+
+// LoadTypologySynonyms carga los sinónimos de tipologías de la base de datos en los parsers.
+// Si la tabla está vacía los parsers usan las etiquetas incorporadas del SIA.
+func LoadTypologySynonyms(db *gorm.DB) error {
+	var synonyms []models.TypologySynonym
+	if err := db.Find(&synonyms).Error; err != nil {
+		return errors.New("failed to load typology synonyms: " + err.Error())
+	}
+
+	labels := make(map[string]models.TipologiaAsignatura, len(synonyms))
+	for _, synonym := range synonyms {
+		labels[synonym.Label] = synonym.Tipologia
+	}
+	parser.SetTipologiaSynonyms(labels)
+	return nil
+}
+
+// ===== CRUD FUNCTIONS FOR TYPOLOGY SYNONYMS =====
+
+// CreateTypologySynonym registra una etiqueta para una tipología del modelo
+func CreateTypologySynonym(db *gorm.DB, label, tipologia, notes string) (*models.TypologySynonym, error) {
+	label = strings.ToUpper(strings.TrimSpace(label))
+	if label == "" || tipologia == "" {
+		return nil, errors.New("label and tipologia are required")
+	}
+	if !models.ValidarTipologia(tipologia) {
+		return nil, errors.New("invalid tipologia. Must be one of: FUND. OBLIGATORIA, FUND. OPTATIVA, DISCIPLINAR OBLIGATORIA, DISCIPLINAR OPTATIVA, LIBRE ELECCIÓN, TRABAJO DE GRADO, NIVELACIÓN")
+	}
+	if err := validateSynonymLabel(db, label, 0); err != nil {
+		return nil, err
+	}
+
+	synonym := models.TypologySynonym{
+		Label:     label,
+		Tipologia: models.TipologiaAsignatura(tipologia),
+		Notes:     notes,
+	}
+	if err := db.Create(&synonym).Error; err != nil {
+		return nil, errors.New("failed to create typology synonym: " + err.Error())
+	}
+
+	if err := LoadTypologySynonyms(db); err != nil {
+		return nil, err
+	}
+	return &synonym, nil
+}
+
+// validateSynonymLabel verifica que ninguna otra etiqueta sea igual ignorando tildes y espacios
+func validateSynonymLabel(db *gorm.DB, label string, excludeID uint) error {
+	var synonyms []models.TypologySynonym
+	if err := db.Where("id <> ?", excludeID).Find(&synonyms).Error; err != nil {
+		return errors.New("failed to check typology synonyms: " + err.Error())
+	}
+	key := parser.TipologiaKey(label)
+	for _, synonym := range synonyms {
+		if parser.TipologiaKey(synonym.Label) == key {
+			return errors.New("typology synonym already exists: " + synonym.Label)
+		}
+	}
+	return nil
+}
+
+// GetAllTypologySynonyms obtiene todos los sinónimos de tipologías
+func GetAllTypologySynonyms(db *gorm.DB) ([]models.TypologySynonym, error) {
+	var synonyms []models.TypologySynonym
+	if err := db.Order("tipologia, label").Find(&synonyms).Error; err != nil {
+		return nil, errors.New("failed to fetch typology synonyms: " + err.Error())
+	}
+	return synonyms, nil
+}
+
+// UpdateTypologySynonym actualiza la etiqueta, la tipología o las notas de un sinónimo
+func UpdateTypologySynonym(db *gorm.DB, synonymID uint, updates struct {
+	Label     string `json:"label"`
+	Tipologia string `json:"tipologia"`
+	Notes     string `json:"notes"`
+}) (*models.TypologySynonym, error) {
+	var synonym models.TypologySynonym
+	if err := db.First(&synonym, synonymID).Error; err != nil {
+		return nil, errors.New("typology synonym not found")
+	}
+
+	if label := strings.ToUpper(strings.TrimSpace(updates.Label)); label != "" {
+		if err := validateSynonymLabel(db, label, synonym.ID); err != nil {
+			return nil, err
+		}
+		synonym.Label = label
+	}
+	if updates.Tipologia != "" {
+		if !models.ValidarTipologia(updates.Tipologia) {
+			return nil, errors.New("invalid tipologia")
+		}
+		synonym.Tipologia = models.TipologiaAsignatura(updates.Tipologia)
+	}
+	if updates.Notes != "" {
+		synonym.Notes = updates.Notes
+	}
+
+	if err := db.Save(&synonym).Error; err != nil {
+		return nil, errors.New("failed to update typology synonym: " + err.Error())
+	}
+
+	if err := LoadTypologySynonyms(db); err != nil {
+		return nil, err
+	}
+	return &synonym, nil
+}
+
+// DeleteTypologySynonym elimina un sinónimo; las historias con esa etiqueta quedarán con la tipología sin reconocer
+func DeleteTypologySynonym(db *gorm.DB, synonymID uint) error {
+	var synonym models.TypologySynonym
+	if err := db.First(&synonym, synonymID).Error; err != nil {
+		return errors.New("typology synonym not found")
+	}
+	if err := db.Delete(&synonym).Error; err != nil {
+		return errors.New("failed to delete typology synonym: " + err.Error())
+	}
+	return LoadTypologySynonyms(db)
+}
@@ -0,0 +1,38 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+// readCSV lee un archivo CSV con una fila de encabezado. El separador puede ser
+// coma o punto y coma (Excel en español exporta con punto y coma).
+func readCSV(data []byte) (*table, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+	r := csv.NewReader(bytes.NewReader(data))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	t := &table{}
+	for number := 1; ; number++ {
+		cells, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV inválido: %v", err)
+		}
+		t.records = append(t.records, record{number: number, cells: cells})
+	}
+	return t, nil
+}
@@ -0,0 +1,378 @@
+// Package importer convierte historias académicas estructuradas (CSV, XLSX o JSON), como las
+// que llegan de estudiantes que vienen de otras universidades, en materias para los motores de comparación.
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"olimpo-vicedecanatura/models"
+	"olimpo-vicedecanatura/parser"
+)
+
+// Formatos de archivo soportados
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatJSON = "json"
+)
+
+// Campos de models.SubjectInput que se pueden asignar a una columna
+const (
+	FieldCode     = "code"
+	FieldName     = "name"
+	FieldCredits  = "credits"
+	FieldType     = "type"
+	FieldGrade    = "grade"
+	FieldStatus   = "status"
+	FieldSemester = "semester"
+	FieldModality = "modality"
+)
+
+// Fields lista los campos en el orden en que se validan
+var Fields = []string{FieldCode, FieldName, FieldCredits, FieldType, FieldGrade, FieldStatus, FieldSemester, FieldModality}
+
+// requiredFields son los campos que deben tener una columna asignada
+var requiredFields = []string{FieldCode, FieldName, FieldCredits}
+
+// fieldAliases son los encabezados que se reconocen automáticamente cuando el campo no está en el mapeo
+var fieldAliases = map[string][]string{
+	FieldCode:     {"code", "codigo", "cod", "codigo asignatura", "codigo materia"},
+	FieldName:     {"name", "nombre", "asignatura", "materia", "nombre asignatura", "nombre materia"},
+	FieldCredits:  {"credits", "creditos", "cred", "numero de creditos"},
+	FieldType:     {"type", "tipo", "tipologia", "componente"},
+	FieldGrade:    {"grade", "nota", "calificacion", "nota final", "definitiva"},
+	FieldStatus:   {"status", "estado", "resultado"},
+	FieldSemester: {"semester", "semestre", "periodo", "periodo academico"},
+	FieldModality: {"modality", "modalidad"},
+}
+
+// semesterRe valida periodos académicos como "2021-1", "2021-2S" o "2021-03"
+var semesterRe = regexp.MustCompile(`^\d{4}-\d{1,2}S?$`)
+
+// Mapping asigna a cada campo el encabezado de la columna que lo contiene
+// (por ejemplo {"code": "Código", "grade": "Nota final"}). También se acepta el número
+// de la columna empezando en 1. Los campos sin mapeo se buscan por encabezados conocidos.
+type Mapping map[string]string
+
+// RowError describe un error de validación en una fila del archivo
+type RowError struct {
+	Row     int    `json:"row"`              // Fila en CSV/XLSX (el encabezado es la fila 1) o posición del objeto en JSON
+	Field   string `json:"field,omitempty"`  // Campo de SubjectInput con el error
+	Column  string `json:"column,omitempty"` // Encabezado de la columna
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
+
+// Result contiene las materias válidas, los errores de las filas descartadas y las advertencias
+// de las filas importadas con datos incompletos
+type Result struct {
+	Columns  map[string]string     `json:"columns"` // Campo → encabezado usado
+	Rows     int                   `json:"rows"`    // Filas de datos leídas
+	Subjects []models.SubjectInput `json:"subjects"`
+	Errors   []RowError            `json:"errors"`
+	Warnings []RowError            `json:"warnings"` // Filas importadas a las que les falta un dato que no se supone (por ejemplo la tipología)
+}
+
+// maxHeaderScan es el número de filas con datos en las que se busca el encabezado;
+// las hojas de cálculo suelen tener un título o datos del estudiante antes de la tabla
+const maxHeaderScan = 10
+
+// record es una fila del archivo junto con su número de fila
+type record struct {
+	number int
+	cells  []string
+}
+
+// table es el contenido de un archivo en el orden original, incluido el encabezado
+type table struct {
+	records []record
+}
+
+// Import lee el archivo en el formato indicado y valida cada fila con el mapeo de columnas.
+// sheet solo aplica a XLSX; si está vacío se usa la primera hoja.
+func Import(format string, data []byte, mapping Mapping, sheet string) (*Result, error) {
+	var t *table
+	var err error
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case FormatCSV:
+		t, err = readCSV(data)
+	case FormatXLSX:
+		t, err = readXLSX(data, sheet)
+	case FormatJSON:
+		t, err = readJSON(data)
+	default:
+		return nil, fmt.Errorf("formato no soportado: %q (usa csv, xlsx o json)", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t.importRows(mapping)
+}
+
+// FormatFromFilename deduce el formato a partir de la extensión del archivo
+func FormatFromFilename(filename string) string {
+	lower := strings.ToLower(filename)
+	for _, format := range []string{FormatCSV, FormatXLSX, FormatJSON} {
+		if strings.HasSuffix(lower, "."+format) {
+			return format
+		}
+	}
+	return ""
+}
+
+// importRows ubica el encabezado, resuelve las columnas y convierte cada fila en una materia
+func (t *table) importRows(mapping Mapping) (*Result, error) {
+	start, columns, err := t.findHeader(mapping)
+	if err != nil {
+		return nil, err
+	}
+	header := t.records[start].cells
+
+	result := &Result{
+		Columns:  make(map[string]string),
+		Subjects: []models.SubjectInput{},
+		Errors:   []RowError{},
+		Warnings: []RowError{},
+	}
+	for field, index := range columns {
+		result.Columns[field] = header[index]
+	}
+
+	for _, rec := range t.records[start+1:] {
+		if isBlankRow(rec.cells) {
+			continue
+		}
+		result.Rows++
+		subject, rowErrors, rowWarnings := convertRow(rec, header, columns)
+		if len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+		result.Subjects = append(result.Subjects, subject)
+		result.Warnings = append(result.Warnings, rowWarnings...)
+	}
+	return result, nil
+}
+
+// findHeader retorna la posición de la primera fila en la que se encuentran las columnas requeridas.
+// Si ninguna de las primeras filas sirve se reporta el error de la primera fila con datos.
+func (t *table) findHeader(mapping Mapping) (int, map[string]int, error) {
+	var firstErr error
+	scanned := 0
+	for i, rec := range t.records {
+		if isBlankRow(rec.cells) {
+			continue
+		}
+		columns, err := resolveColumns(rec.cells, mapping)
+		if err == nil {
+			return i, columns, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if scanned++; scanned == maxHeaderScan {
+			break
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("el archivo no tiene filas")
+	}
+	return 0, nil, firstErr
+}
+
+// resolveColumns obtiene el índice de columna de cada campo, primero con el mapeo explícito
+// y luego por encabezados conocidos
+func resolveColumns(header []string, mapping Mapping) (map[string]int, error) {
+	byHeader := make(map[string]int)
+	for i, h := range header {
+		key := normalizeHeader(h)
+		if _, exists := byHeader[key]; !exists {
+			byHeader[key] = i
+		}
+	}
+
+	columns := make(map[string]int)
+	for field, column := range mapping {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !isField(field) {
+			return nil, fmt.Errorf("campo desconocido en el mapeo: %q (campos válidos: %s)", field, strings.Join(Fields, ", "))
+		}
+		if index, ok := byHeader[normalizeHeader(column)]; ok {
+			columns[field] = index
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(column)); err == nil && n >= 1 && n <= len(header) {
+			columns[field] = n - 1
+			continue
+		}
+		return nil, fmt.Errorf("la columna %q asignada a %s no existe en el archivo", column, field)
+	}
+
+	for _, field := range Fields {
+		if _, mapped := columns[field]; mapped {
+			continue
+		}
+		for _, alias := range fieldAliases[field] {
+			if index, ok := byHeader[alias]; ok {
+				columns[field] = index
+				break
+			}
+		}
+	}
+
+	var missing []string
+	for _, field := range requiredFields {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no se encontró columna para: %s; indícala en el mapeo de columnas", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// convertRow valida una fila y la convierte en SubjectInput, acumulando todos sus errores. Las
+// advertencias son datos faltantes que no impiden importar la fila.
+func convertRow(rec record, header []string, columns map[string]int) (models.SubjectInput, []RowError, []RowError) {
+	row := rec.cells
+	var errors, warnings []RowError
+	value := func(field string) string {
+		index, ok := columns[field]
+		if !ok || index >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[index])
+	}
+	rowError := func(field, message string) RowError {
+		rowError := RowError{Row: rec.number, Field: field, Value: value(field), Message: message}
+		if index, ok := columns[field]; ok {
+			rowError.Column = header[index]
+		}
+		return rowError
+	}
+	fail := func(field, message string) {
+		errors = append(errors, rowError(field, message))
+	}
+
+	subject := models.SubjectInput{
+		Code:     value(FieldCode),
+		Name:     value(FieldName),
+		Semester: value(FieldSemester),
+		Modality: models.NormalizarModalidad(value(FieldModality)),
+	}
+	if subject.Code == "" {
+		fail(FieldCode, "el código es obligatorio")
+	}
+	if subject.Name == "" {
+		fail(FieldName, "el nombre es obligatorio")
+	}
+
+	if credits, err := parseInteger(value(FieldCredits)); err != nil || credits <= 0 {
+		fail(FieldCredits, "los créditos deben ser un número entero mayor que cero")
+	} else {
+		subject.Credits = credits
+	}
+
+	// Sin tipología la materia se importa sin ella y se advierte, en lugar de suponer libre elección;
+	// una tipología desconocida es un error
+	if tipo := value(FieldType); tipo == "" {
+		warnings = append(warnings, rowError(FieldType, "la fila no tiene tipología"))
+	} else if mapped, ok := parser.LookupTipologia(tipo); ok {
+		subject.Type = mapped
+	} else {
+		fail(FieldType, "tipología no reconocida")
+	}
+
+	hasGrade := false
+	if grade := strings.ToUpper(value(FieldGrade)); grade != "" {
+		switch grade {
+		case models.CalificacionAprobada, models.CalificacionNoAprobada:
+			subject.GradeLabel = grade
+			hasGrade = true
+		default:
+			g, err := strconv.ParseFloat(strings.ReplaceAll(grade, ",", "."), 64)
+			if err != nil || g < parser.MinGrade || g > parser.MaxGrade {
+				fail(FieldGrade, fmt.Sprintf("la calificación debe ser AP, NA o un número entre %.1f y %.1f", parser.MinGrade, parser.MaxGrade))
+			} else {
+				subject.Grade = g
+				hasGrade = true
+			}
+		}
+	}
+
+	if status := value(FieldStatus); status != "" {
+		subject.Status = models.NormalizarEstado(status)
+		if !isKnownStatus(subject.Status) {
+			fail(FieldStatus, "estado no reconocido (usa APROBADA, REPROBADA, CANCELADA o INSCRITA)")
+		}
+	} else if hasGrade {
+		// Sin estado explícito se deduce de la calificación
+		if subject.IsApproved() {
+			subject.Status = models.EstadoAprobada
+		} else {
+			subject.Status = models.EstadoReprobada
+		}
+	} else if value(FieldGrade) == "" {
+		fail(FieldStatus, "la fila no tiene estado ni calificación")
+	}
+
+	if subject.Semester != "" && !semesterRe.MatchString(strings.ToUpper(subject.Semester)) {
+		fail(FieldSemester, "el periodo debe tener el formato AAAA-N (por ejemplo 2021-1 o 2021-2S)")
+	}
+	if subject.Modality != "" && !models.ValidarModalidad(subject.Modality) {
+		fail(FieldModality, "modalidad no reconocida")
+	}
+
+	return subject, errors, warnings
+}
+
+// isKnownStatus indica si el estado normalizado es uno de los estados del modelo
+func isKnownStatus(status string) bool {
+	switch status {
+	case models.EstadoAprobada, models.EstadoReprobada, models.EstadoCancelada, models.EstadoInscrita:
+		return true
+	}
+	return false
+}
+
+// isField indica si el nombre corresponde a un campo que se puede mapear
+func isField(field string) bool {
+	for _, f := range Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// parseInteger acepta enteros escritos como "3" o "3.0" (las hojas de cálculo guardan números como reales)
+func parseInteger(text string) (int, error) {
+	if n, err := strconv.Atoi(text); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(strings.ReplaceAll(text, ",", "."), 64)
+	if err != nil || f != float64(int(f)) {
+		return 0, fmt.Errorf("no es un entero: %q", text)
+	}
+	return int(f), nil
+}
+
+// normalizeHeader compara encabezados sin distinguir mayúsculas, tildes ni espacios repetidos
+func normalizeHeader(h string) string {
+	h = strings.ToLower(strings.Join(strings.Fields(h), " "))
+	return strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", ".", "").Replace(h)
+}
+
+// isBlankRow indica si todas las celdas de la fila están vacías
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
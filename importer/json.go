@@ -0,0 +1,104 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// readJSON lee un arreglo de objetos (una materia por objeto). Los encabezados son las claves
+// de los objetos en el orden en que aparecen por primera vez. También se acepta un objeto con
+// el arreglo en "rows" o "subjects".
+func readJSON(data []byte) (*table, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		var wrapper map[string]json.RawMessage
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, fmt.Errorf("JSON inválido: %v", err)
+		}
+		if rows, ok := wrapper["rows"]; ok {
+			data = rows
+		} else if subjects, ok := wrapper["subjects"]; ok {
+			data = subjects
+		} else {
+			return nil, fmt.Errorf("el JSON debe ser un arreglo de objetos o tener el arreglo en \"rows\"")
+		}
+	}
+
+	var objects []json.RawMessage
+	if err := json.Unmarshal(data, &objects); err != nil {
+		return nil, fmt.Errorf("JSON inválido: se esperaba un arreglo de objetos: %v", err)
+	}
+
+	var header []string
+	index := make(map[string]int)
+	var objectValues []map[string]string
+	for i, raw := range objects {
+		keys, values, err := decodeObject(raw)
+		if err != nil {
+			return nil, fmt.Errorf("JSON inválido en el elemento %d: %v", i+1, err)
+		}
+		for _, key := range keys {
+			if _, exists := index[key]; !exists {
+				index[key] = len(header)
+				header = append(header, key)
+			}
+		}
+		objectValues = append(objectValues, values)
+	}
+
+	// Las claves forman el encabezado (fila 0); cada objeto es una fila numerada desde 1
+	t := &table{records: []record{{number: 0, cells: header}}}
+	for i, values := range objectValues {
+		cells := make([]string, len(header))
+		for key, value := range values {
+			cells[index[key]] = value
+		}
+		t.records = append(t.records, record{number: i + 1, cells: cells})
+	}
+	return t, nil
+}
+
+// decodeObject lee un objeto JSON conservando el orden de sus claves y convierte los valores a texto
+func decodeObject(raw json.RawMessage) ([]string, map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, nil, fmt.Errorf("se esperaba un objeto")
+	}
+
+	var keys []string
+	values := make(map[string]string)
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := keyTok.(string)
+
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		switch v := value.(type) {
+		case nil:
+			values[key] = ""
+		case string:
+			values[key] = v
+		case json.Number:
+			values[key] = v.String()
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		default:
+			return nil, nil, fmt.Errorf("el valor de %q debe ser texto o número", key)
+		}
+	}
+	return keys, values, nil
+}
@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"encoding/csv"
+	"strings"
+
+	"olimpo-vicedecanatura/parser"
+)
+
+// FormatGenericTable identifica las tablas genéricas pegadas como texto en el registro de formatos del parser
+const FormatGenericTable = "generic_table"
+
+// TableParser reconoce una tabla pegada como texto, con columnas separadas por tabulación, punto y
+// coma o coma y una fila de encabezado con los campos conocidos ("Código;Asignatura;Créditos;Nota").
+// Se registra en el parser con parser.Register para que /api/parse y las comparaciones la detecten.
+type TableParser struct{}
+
+// Format implementa parser.HistoryParser
+func (TableParser) Format() string { return FormatGenericTable }
+
+// Detect reconoce el texto si alguna de las primeras filas es un encabezado con código, nombre y créditos.
+// Esas columnas no aparecen juntas en la historia del SIA.
+func (TableParser) Detect(text string) float64 {
+	if _, _, err := readText(text).findHeader(nil); err != nil {
+		return 0
+	}
+	return 0.95
+}
+
+// Parse convierte cada fila válida en una materia; las filas con errores se descartan y se
+// reportan como advertencias de su línea, con las mismas validaciones de Import
+func (TableParser) Parse(text string) (*parser.Result, error) {
+	t := readText(text)
+	start, columns, err := t.findHeader(nil)
+	if err != nil {
+		return nil, err
+	}
+	header := t.records[start].cells
+	lines := strings.Split(text, "\n")
+
+	result := parser.NewResult()
+	for _, rec := range t.records[start+1:] {
+		if isBlankRow(rec.cells) {
+			continue
+		}
+		subject, rowErrors, rowWarnings := convertRow(rec, header, columns)
+		line := strings.TrimSpace(lines[rec.number-1])
+		if len(rowErrors) == 0 {
+			result.AddSubject(rec.number, subject)
+			// La única advertencia de una fila importada es la tipología faltante
+			for _, rowWarning := range rowWarnings {
+				result.Warn(rec.number, parser.WarnMissingType, line, subject.Code, rowWarning.Message)
+			}
+			continue
+		}
+		for _, rowError := range rowErrors {
+			result.Warn(rec.number, warningCode(rowError.Field), line, subject.Code, rowError.Message)
+		}
+	}
+	result.Finish()
+	return result, nil
+}
+
+// readText separa el texto en filas conservando el número de línea. El separador es la tabulación
+// si el texto tiene alguna; si no, el punto y coma o la coma, según cuál aparezca más.
+func readText(text string) *table {
+	comma := '\t'
+	if !strings.Contains(text, "\t") {
+		comma = ','
+		if strings.Count(text, ";") > strings.Count(text, ",") {
+			comma = ';'
+		}
+	}
+
+	t := &table{}
+	for i, line := range strings.Split(text, "\n") {
+		r := csv.NewReader(strings.NewReader(line))
+		r.Comma = comma
+		r.LazyQuotes = true
+		cells, err := r.Read()
+		if err != nil {
+			// Línea vacía o con comillas sin cerrar: se toma tal cual
+			cells = strings.Split(line, string(comma))
+		}
+		t.records = append(t.records, record{number: i + 1, cells: cells})
+	}
+	return t
+}
+
+// warningCode traduce el campo con error a la advertencia equivalente del parser
+func warningCode(field string) string {
+	switch field {
+	case FieldCredits:
+		return parser.WarnMissingCredits
+	case FieldType:
+		return parser.WarnUnknownTipologia
+	case FieldGrade:
+		return parser.WarnGradeOutOfRange
+	case FieldStatus:
+		return parser.WarnMissingStatus
+	case FieldSemester:
+		return parser.WarnMissingPeriod
+	case FieldModality:
+		return parser.WarnUnknownModality
+	}
+	return parser.WarnUnrecognizedLine
+}
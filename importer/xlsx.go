@@ -0,0 +1,194 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxXLSXPartSize limita el tamaño descomprimido de cada parte del libro para evitar archivos maliciosos
+const maxXLSXPartSize = 50 << 20
+
+// Estructuras mínimas de SpreadsheetML (Office Open XML) necesarias para leer celdas
+type (
+	xlsxWorkbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	xlsxRelationships struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	xlsxRichText struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	}
+	xlsxSharedStrings struct {
+		Items []xlsxRichText `xml:"si"`
+	}
+	xlsxSheet struct {
+		Rows []struct {
+			Number int `xml:"r,attr"`
+			Cells  []struct {
+				Ref    string       `xml:"r,attr"`
+				Type   string       `xml:"t,attr"`
+				Value  string       `xml:"v"`
+				Inline xlsxRichText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+)
+
+// String une el texto simple o los fragmentos con formato de una cadena
+func (r xlsxRichText) String() string {
+	if len(r.Runs) == 0 {
+		return r.Text
+	}
+	var b strings.Builder
+	for _, run := range r.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+// readXLSX lee la hoja indicada (o la primera) de un libro de Excel
+func readXLSX(data []byte, sheet string) (*table, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("el archivo no es un XLSX válido: %v", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var workbook xlsxWorkbook
+	if err := readXMLPart(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := readXMLPart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	var shared xlsxSharedStrings
+	if _, exists := files["xl/sharedStrings.xml"]; exists {
+		if err := readXMLPart(files, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("el libro no tiene hojas")
+	}
+	chosen := workbook.Sheets[0]
+	if sheet != "" {
+		found := false
+		for _, s := range workbook.Sheets {
+			if strings.EqualFold(strings.TrimSpace(s.Name), strings.TrimSpace(sheet)) {
+				chosen, found = s, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("la hoja %q no existe en el libro", sheet)
+		}
+	}
+
+	target := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == chosen.RID {
+			target = rel.Target
+			break
+		}
+	}
+	if target == "" {
+		return nil, fmt.Errorf("no se encontró el contenido de la hoja %q", chosen.Name)
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+
+	var ws xlsxSheet
+	if err := readXMLPart(files, target, &ws); err != nil {
+		return nil, err
+	}
+
+	t := &table{}
+	for i, row := range ws.Rows {
+		number := row.Number
+		if number == 0 {
+			number = i + 1
+		}
+
+		var cells []string
+		for j, cell := range row.Cells {
+			col := j
+			if c, ok := columnIndex(cell.Ref); ok {
+				col = c
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+			switch cell.Type {
+			case "s":
+				if idx, err := strconv.Atoi(cell.Value); err == nil && idx >= 0 && idx < len(shared.Items) {
+					cells[col] = shared.Items[idx].String()
+				}
+			case "inlineStr":
+				cells[col] = cell.Inline.String()
+			default:
+				cells[col] = cell.Value
+			}
+		}
+		t.records = append(t.records, record{number: number, cells: cells})
+	}
+	return t, nil
+}
+
+// readXMLPart decodifica una parte XML del paquete
+func readXMLPart(files map[string]*zip.File, name string, v interface{}) error {
+	f, exists := files[name]
+	if !exists {
+		return fmt.Errorf("el archivo XLSX no contiene %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("no se pudo leer %s: %v", name, err)
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(io.LimitReader(rc, maxXLSXPartSize)).Decode(v); err != nil {
+		return fmt.Errorf("no se pudo leer %s: %v", name, err)
+	}
+	return nil
+}
+
+// columnIndex convierte la referencia de una celda ("C12") en el índice de su columna (2)
+func columnIndex(ref string) (int, bool) {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return col - 1, true
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
@@ -10,8 +13,10 @@ import (
 	"olimpo-vicedecanatura/database"
 	"olimpo-vicedecanatura/models"
 	"olimpo-vicedecanatura/functions"
+	"olimpo-vicedecanatura/importer"
+	"olimpo-vicedecanatura/parser"
+	"olimpo-vicedecanatura/pdftext"
 	"strings"
-	"regexp"
 	"fmt"
 	"github.com/gin-contrib/cors"
 )
@@ -26,6 +31,7 @@ const (
 	TipologiaDisciplinarObligatoria TipologiaAsignatura = "DISCIPLINAR OBLIGATORIA"
 	TipologiaLibreEleccion         TipologiaAsignatura = "LIBRE ELECCIÓN"
 	TipologiaTrabajoGrado          TipologiaAsignatura = "TRABAJO DE GRADO"
+	TipologiaNivelacion            TipologiaAsignatura = "NIVELACIÓN"
 )
 
 // ValidarTipologia verifica si una tipología es válida     
@@ -36,7 +42,8 @@ func ValidarTipologia(tipo string) bool {
 		 TipologiaFundamentalOptativa,
 		 TipologiaDisciplinarObligatoria,
 		 TipologiaLibreEleccion,
-		 TipologiaTrabajoGrado:
+		 TipologiaTrabajoGrado,
+		 TipologiaNivelacion:
 		return true
 	default:
 		return false
@@ -45,6 +52,7 @@ func ValidarTipologia(tipo string) bool {
 
 type HistoriaAcademicaRequest struct {
 	Historia string `json:"historia" binding:"required"`
+	Plan     string `json:"plan"` // Plan de la historia (posición o nombre) cuando tiene varios
 }
 
 type Asignatura struct {
@@ -54,26 +62,27 @@ type Asignatura struct {
 	Tipo        TipologiaAsignatura `json:"tipo"`
 	Periodo     string            `json:"periodo"`
 	Calificacion float64           `json:"calificacion"`
+	CalificacionTexto string      `json:"calificacion_texto,omitempty"` // Calificación no numérica: AP (aprobada) o NA (no aprobada)
 	Estado      string            `json:"estado"`
-}
-
-type ResumenCreditos struct {
-	Tipologia  TipologiaAsignatura `json:"tipologia"`
-	Exigidos   int                 `json:"exigidos"`
-	Aprobados  int                 `json:"aprobados"`
-	Pendientes int                 `json:"pendientes"`
-	Inscritos  int                 `json:"inscritos"`
-	Cursados   int                 `json:"cursados"`
+	Modalidad   string            `json:"modalidad,omitempty"`          // Ordinaria, Validación, Homologación o Habilitación
 }
 
 type HistoriaAcademicaResponse struct {
 	PlanEstudios      string            `json:"plan_estudios"`
 	Facultad          string            `json:"facultad"`
+	NumeroHistoria    string            `json:"numero_historia"`
+	Estado            string            `json:"estado"`
+	Bloqueada         bool              `json:"bloqueada"`
+	CausasBloqueo     []string          `json:"causas_bloqueo"`
 	PAPA              float64           `json:"papa"`
+	PeriodoPAPA       string            `json:"periodo_papa"`
 	Promedio          float64           `json:"promedio"`
+	PeriodoPromedio   string            `json:"periodo_promedio"`
 	Asignaturas       []Asignatura      `json:"asignaturas"`
-	ResumenCreditos   []ResumenCreditos `json:"resumen_creditos"`
+	ResumenCreditos   []models.ResumenCreditos `json:"resumen_creditos"`
 	PorcentajeAvance  float64           `json:"porcentaje_avance"`
+	Advertencias      []parser.Warning  `json:"advertencias"`
+	Formato           string            `json:"formato"`
 }
 
 func main() {
@@ -106,6 +115,18 @@ func main() {
 	database.SeedInitialData(config.DB)
 	log.Println("✅ Datos iniciales cargados (si era necesario)")
 
+	// Cargar los sinónimos de tipologías que usan los parsers
+	database.SeedTypologySynonyms(config.DB)
+	if err := functions.LoadTypologySynonyms(config.DB); err != nil {
+		log.Printf("⚠️  No se pudieron cargar los sinónimos de tipologías, se usan las etiquetas del SIA: %v", err)
+	} else {
+		log.Println("✅ Sinónimos de tipologías cargados")
+	}
+
+	// Registrar los formatos de historia académica adicionales a los del SIA
+	parser.Register(importer.TableParser{})
+	log.Printf("✅ Formatos de historia académica: %s", strings.Join(parser.Formats(), ", "))
+
 	// Configurar CORS y middlewares
 	r := gin.Default()
 	r.Use(cors.New(cors.Config{
@@ -132,9 +153,17 @@ func main() {
 				"GET /api/careers - Obtener todas las carreras",
 				"GET /api/careers/:code/study-plans - Obtener planes de estudio de una carrera",
 				"GET /api/study-plans/:id - Obtener detalles de un plan de estudio",
-				"POST /api/compare - Comparar historia académica con plan de estudio",
-				"POST /api/compare-by-career - Comparar por código de carrera",
-				"POST /api/api-compare - Comparar historia académica en texto plano",
+				"POST /api/compare - Comparar historia académica con plan de estudio (?explain=true registra cómo se decidió cada materia)",
+				"POST /api/compare-by-career - Comparar por código de carrera; plan_version o admission_period eligen el plan (?explain=true registra cómo se decidió cada materia)",
+				"POST /api/compare-plan-versions - Comparar una historia con todas las versiones del plan de la carrera",
+				"POST /api/simulate - Simular materias por cursar, materias retiradas o un plan distinto y ver la diferencia con la comparación actual",
+				"POST /api/api-compare - Comparar historia académica en texto plano (?explain=true registra cómo se decidió cada materia)",
+				"POST /api/explore-careers - Comparar una historia con todas las carreras, ordenadas por avance",
+				"POST /api/historia-academica - Extraer encabezado y asignaturas de la historia académica",
+				"POST /api/import-compare - Importar historia académica desde CSV, XLSX o JSON y comparar (?explain=true registra cómo se decidió cada materia)",
+				"POST /api/parse - Vista previa de la historia académica parseada, con confianza por materia",
+				"GET /api/parse/formats - Formatos de historia académica que se detectan automáticamente",
+				"POST /api/doble-titulacion/estructurada - Doble titulación con historias ya estructuradas",
 				"POST /api/careers - Crear nueva carrera",
 				"POST /api/study-plans - Crear nuevo plan de estudio",
 				"POST /api/subjects - Crear nueva materia",
@@ -147,6 +176,25 @@ func main() {
 				"PUT /api/equivalences/:id - Actualizar equivalencia",
 				"PUT /api/equivalences/:id/source-subject - Actualizar materia origen",
 				"DELETE /api/equivalences/:id - Eliminar equivalencia",
+
+				"GET /api/subject-aliases - Obtener todos los alias de códigos de materias",
+				"GET /api/subject-aliases/:id - Obtener alias por ID",
+				"POST /api/subject-aliases - Crear alias de código de materia",
+				"PUT /api/subject-aliases/:id - Actualizar alias",
+				"DELETE /api/subject-aliases/:id - Eliminar alias",
+
+				"GET /api/typology-synonyms - Obtener los sinónimos de tipologías",
+				"POST /api/typology-synonyms - Crear sinónimo de tipología",
+				"PUT /api/typology-synonyms/:id - Actualizar sinónimo de tipología",
+				"DELETE /api/typology-synonyms/:id - Eliminar sinónimo de tipología",
+
+				"PUT /api/study-plans/:id/elective-overflow - Regla para los créditos de optativas que exceden la cuota",
+				"PUT /api/study-plans/:id/admission-period - Primer periodo de admisión al que aplica el plan",
+				"GET /api/study-plans/:id/elective-groups - Obtener las agrupaciones de optativas de un plan",
+				"POST /api/study-plans/:id/elective-groups - Crear agrupación de optativas",
+				"GET /api/elective-groups/:id - Obtener agrupación de optativas por ID",
+				"PUT /api/elective-groups/:id - Actualizar agrupación de optativas",
+				"DELETE /api/elective-groups/:id - Eliminar agrupación de optativas",
 			},
 		})
 	})
@@ -169,10 +217,31 @@ func main() {
 		
 		// Endpoint adicional para comparar por código de carrera (más simple)
 		api.POST("/compare-by-career", compareByCareerCode)
+
+		// Comparar con todas las versiones del plan de la carrera
+		api.POST("/compare-plan-versions", comparePlanVersions)
+
+		// Simular cambios hipotéticos sobre una comparación
+		api.POST("/simulate", simulateAcademicHistory)
 		
 		// Nuevo endpoint para comparar historia académica en texto plano
 		api.POST("/api-compare", compareAcademicHistoryFromText)
 
+		// Extraer los datos de la historia académica (encabezado del estudiante y asignaturas)
+		api.POST("/historia-academica", getHistoriaAcademica)
+
+		// Importar historia académica estructurada (CSV, XLSX o JSON) y comparar con el pensum
+		api.POST("/import-compare", importAndCompareAcademicHistory)
+
+		// Comparar una historia con el plan activo de todas las carreras (cambio de carrera)
+		api.POST("/explore-careers", exploreCareers)
+
+		// Solo parsear la historia académica para revisarla y corregirla antes de comparar
+		api.POST("/parse", parseAcademicHistory)
+
+		// Formatos de historia académica registrados en el parser
+		api.GET("/parse/formats", getHistoryFormats)
+
 
 
 		//endpoint para crear carrera
@@ -202,6 +271,45 @@ func main() {
 		api.PUT("/equivalences/:id/source-subject", updateEquivalenceSourceSubject)
 		// Eliminar equivalencia
 		api.DELETE("/equivalences/:id", deleteEquivalence)
+
+		// ===== SUBJECT ALIASES CRUD ENDPOINTS =====
+		// Obtener todos los alias de códigos
+		api.GET("/subject-aliases", getSubjectAliases)
+		// Obtener alias por ID
+		api.GET("/subject-aliases/:id", getSubjectAliasByID)
+		// Crear alias (código histórico o variante -> código canónico)
+		api.POST("/subject-aliases", createSubjectAlias)
+		// Actualizar alias
+		api.PUT("/subject-aliases/:id", updateSubjectAlias)
+		// Eliminar alias
+		api.DELETE("/subject-aliases/:id", deleteSubjectAlias)
+
+		// ===== TYPOLOGY SYNONYMS CRUD ENDPOINTS =====
+		// Obtener los sinónimos de tipologías
+		api.GET("/typology-synonyms", getTypologySynonyms)
+		// Crear sinónimo (etiqueta de la historia -> tipología del modelo)
+		api.POST("/typology-synonyms", createTypologySynonym)
+		// Actualizar sinónimo
+		api.PUT("/typology-synonyms/:id", updateTypologySynonym)
+		// Eliminar sinónimo
+		api.DELETE("/typology-synonyms/:id", deleteTypologySynonym)
+
+		// Regla del plan para los créditos de optativas que exceden la cuota (LIBRE ELECCIÓN o NINGUNO)
+		api.PUT("/study-plans/:id/elective-overflow", updateStudyPlanElectiveOverflow)
+		// Periodo de admisión desde el que aplica el plan
+		api.PUT("/study-plans/:id/admission-period", updateStudyPlanAdmissionFrom)
+
+		// ===== ELECTIVE GROUPS CRUD ENDPOINTS =====
+		// Obtener las agrupaciones de optativas de un plan
+		api.GET("/study-plans/:id/elective-groups", getElectiveGroupsByStudyPlan)
+		// Crear agrupación (materias optativas del plan y créditos mínimos)
+		api.POST("/study-plans/:id/elective-groups", createElectiveGroup)
+		// Obtener agrupación por ID
+		api.GET("/elective-groups/:id", getElectiveGroupByID)
+		// Actualizar agrupación
+		api.PUT("/elective-groups/:id", updateElectiveGroup)
+		// Eliminar agrupación
+		api.DELETE("/elective-groups/:id", deleteElectiveGroup)
 	}
 
 	// Endpoint para doble titulación
@@ -214,11 +322,23 @@ func main() {
 				return
 			}
 		} else if strings.HasPrefix(contentType, "multipart/form-data") || strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
-			req.HistoriaOrigen = c.PostForm("historia_origen")
-			req.HistoriaDoble = c.PostForm("historia_doble")
+			var err error
+			// Cada historia puede enviarse como texto o como el PDF oficial del SIA
+			if req.HistoriaOrigen, err = historyTextFromForm(c, "historia_origen", "historia_origen_pdf"); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Error leyendo historia_origen_pdf: " + err.Error()})
+				return
+			}
+			if req.HistoriaDoble, err = historyTextFromForm(c, "historia_doble", "historia_doble_pdf"); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Error leyendo historia_doble_pdf: " + err.Error()})
+				return
+			}
 			req.CodigoCarreraObjetivo = c.PostForm("codigo_carrera_objetivo")
+			req.PlanOrigen = c.PostForm("plan_origen")
+			req.PlanDoble = c.PostForm("plan_doble")
+			req.PlanVersion = c.PostForm("plan_version")
+			req.AdmissionPeriod = c.PostForm("admission_period")
 			if req.HistoriaOrigen == "" || req.HistoriaDoble == "" || req.CodigoCarreraObjetivo == "" {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Faltan campos en el formulario: historia_origen, historia_doble y codigo_carrera_objetivo son requeridos"})
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Faltan campos en el formulario: historia_origen (o historia_origen_pdf), historia_doble (o historia_doble_pdf) y codigo_carrera_objetivo son requeridos"})
 				return
 			}
 		} else {
@@ -226,49 +346,35 @@ func main() {
 			return
 		}
 
-		// Preprocesar y parsear ambos textos igual que en cambio de carrera
-		cleanedOrigen := preprocessAcademicHistoryText(req.HistoriaOrigen)
-		cleanedDoble := preprocessAcademicHistoryText(req.HistoriaDoble)
-
-		parsedOrigen, err := parseAcademicHistoryTextFlexible(cleanedOrigen)
+		// Parsear ambos textos igual que en cambio de carrera
+		parsedOrigen, err := parser.Parse(req.HistoriaOrigen)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Error parseando historia_origen: " + err.Error()})
 			return
 		}
-		parsedDoble, err := parseAcademicHistoryTextFlexible(cleanedDoble)
+		// Si el estudiante cambió de carrera, la historia de origen trae varios planes y se usa el elegido
+		historiaOrigen, err := parsedOrigen.SelectPlan(req.PlanOrigen)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Error parseando historia_doble: " + err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "planes_origen": parsedOrigen.PlanNames()})
 			return
 		}
-
-		// Convertir a SubjectInput
-		materiasOrigen := make([]models.SubjectInput, 0, len(parsedOrigen))
-		for _, ps := range parsedOrigen {
-			materiasOrigen = append(materiasOrigen, models.SubjectInput{
-				Code:     strings.TrimSpace(ps.Code),
-				Name:     ps.Name,
-				Credits:  ps.Credits,
-				Type:     models.TipologiaAsignatura(ps.Type),
-				Grade:    ps.Grade,
-				Status:   ps.Status,
-				Semester: ps.Semester,
-			})
+		parsedDoble, err := parser.Parse(req.HistoriaDoble)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error parseando historia_doble: " + err.Error()})
+			return
 		}
-		materiasDoble := make([]models.SubjectInput, 0, len(parsedDoble))
-		for _, ps := range parsedDoble {
-			materiasDoble = append(materiasDoble, models.SubjectInput{
-				Code:     strings.TrimSpace(ps.Code),
-				Name:     ps.Name,
-				Credits:  ps.Credits,
-				Type:     models.TipologiaAsignatura(ps.Type),
-				Grade:    ps.Grade,
-				Status:   ps.Status,
-				Semester: ps.Semester,
-			})
+		historiaDoble, err := parsedDoble.SelectPlan(req.PlanDoble)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "planes_doble": parsedDoble.PlanNames()})
+			return
 		}
 
+		materiasOrigen := historiaOrigen.SubjectInputs()
+		materiasDoble := historiaDoble.SubjectInputs()
+
 		// Realizar la comparación de doble titulación usando las materias parseadas
-		resultado, err := functions.CompareDobleTitulacionParsed(config.DB, materiasOrigen, materiasDoble, req.CodigoCarreraObjetivo)
+		req.Explain = req.Explain || explainRequested(c)
+		resultado, err := functions.CompareDobleTitulacionParsed(config.DB, materiasOrigen, materiasDoble, req.CodigoCarreraObjetivo, req.PlanVersion, req.AdmissionPeriod, req.Explain)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
@@ -277,9 +383,22 @@ func main() {
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
 			"resultado": resultado,
+			"planes_origen": parsedOrigen.PlanNames(),
+			"plan_origen": historiaOrigen.Metadata.PlanName,
+			"formatos": gin.H{
+				"historia_origen": parsedOrigen.Format,
+				"historia_doble":  parsedDoble.Format,
+			},
+			"advertencias": gin.H{
+				"historia_origen": historiaOrigen.Warnings,
+				"historia_doble":  historiaDoble.Warnings,
+			},
 		})
 	})
 
+	// Doble titulación con las historias ya estructuradas (salida corregida de /api/parse)
+	r.POST("/api/doble-titulacion/estructurada", compareDobleTitulacionStructured)
+
 	// Ejecutar servidor
 	log.Println("🚀 Servidor iniciado en http://localhost:8080")
 	if err := r.Run(); err != nil {
@@ -370,6 +489,7 @@ func compareAcademicHistory(c *gin.Context) {
 	}
 	
 	// Realizar la comparación usando la función que creamos
+	req.AcademicHistory.Explain = req.AcademicHistory.Explain || explainRequested(c)
 	result, err := functions.CompareAcademicHistoryWithStudyPlan(config.DB, req.AcademicHistory, req.StudyPlanID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -391,7 +511,8 @@ func compareAcademicHistory(c *gin.Context) {
 			"total_subjects_in_plan":     len(result.EquivalentSubjects) + len(result.MissingSubjects),
 			"approved_subjects":          len(result.EquivalentSubjects),
 			"missing_subjects":           len(result.MissingSubjects),
-			"completion_percentage":      calculateCompletionPercentage(result.CreditsSummary),
+			"pending_elective_groups":    countPendingElectiveGroups(result.ElectiveGroups),
+			"completion_percentage":      result.CreditsSummary.CompletionPercentage(),
 		},
 	})
 }
@@ -428,6 +549,7 @@ func createStudyPlan(c *gin.Context) {
 		DisObligatoriaCredits   int    `json:"dis_obligatoria_credits" binding:"required"`
 		DisOptativaCredits      int    `json:"dis_optativa_credits" binding:"required"`
 		LibreCredits            int    `json:"libre_credits" binding:"required"`
+		TrabajoGradoCredits     int    `json:"trabajo_grado_credits"` // Opcional: no todos los planes exigen trabajo de grado
 	}
 	
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -444,6 +566,7 @@ func createStudyPlan(c *gin.Context) {
 		req.DisObligatoriaCredits,
 		req.DisOptativaCredits,
 		req.LibreCredits,
+		req.TrabajoGradoCredits,
 	)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -496,6 +619,7 @@ func createCompleteStudyPlan(c *gin.Context) {
 		DisObligatoriaCredits   int    `json:"dis_obligatoria_credits" binding:"required"`
 		DisOptativaCredits      int    `json:"dis_optativa_credits" binding:"required"`
 		LibreCredits            int    `json:"libre_credits" binding:"required"`
+		TrabajoGradoCredits     int    `json:"trabajo_grado_credits"` // Opcional: no todos los planes exigen trabajo de grado
 		Subjects                []struct {
 			Code        string `json:"code" binding:"required"`
 			Name        string `json:"name" binding:"required"`
@@ -544,6 +668,7 @@ func createCompleteStudyPlan(c *gin.Context) {
 		req.DisObligatoriaCredits,
 		req.DisOptativaCredits,
 		req.LibreCredits,
+		req.TrabajoGradoCredits,
 		subjects,
 	)
 	if err != nil {
@@ -563,6 +688,7 @@ func compareByCareerCode(c *gin.Context) {
 	}
 	
 	// Realizar la comparación usando el código de carrera
+	academicHistory.Explain = academicHistory.Explain || explainRequested(c)
 	result, err := functions.CompareAcademicHistoryByCareerCode(config.DB, academicHistory)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -570,187 +696,115 @@ func compareByCareerCode(c *gin.Context) {
 	}
 	
 	// Obtener información del plan de estudio usado
-	studyPlan, _ := functions.GetStudyPlanByCareerCode(config.DB, academicHistory.CareerCode)
+	studyPlan, _ := functions.ResolveStudyPlan(config.DB, academicHistory.CareerCode, academicHistory.PlanVersion, academicHistory.AdmissionPeriod)
 	
 	c.JSON(http.StatusOK, gin.H{
 		"comparison_result": result,
-		"study_plan_info": gin.H{
-			"id":      studyPlan.ID,
-			"version": studyPlan.Version,
-			"career":  studyPlan.Career.Name,
-		},
+		"study_plan_info":   studyPlanInfo(studyPlan),
 		"summary": gin.H{
 			"total_subjects_in_plan":     len(result.EquivalentSubjects) + len(result.MissingSubjects),
 			"approved_subjects":          len(result.EquivalentSubjects),
 			"missing_subjects":           len(result.MissingSubjects),
-			"completion_percentage":      calculateCompletionPercentage(result.CreditsSummary),
+			"pending_elective_groups":    countPendingElectiveGroups(result.ElectiveGroups),
+			"completion_percentage":      result.CreditsSummary.CompletionPercentage(),
 		},
 	})
 }
 
-// calculateCompletionPercentage calcula el porcentaje de completitud basado en créditos
-func calculateCompletionPercentage(summary models.CreditsSummary) float64 {
-	if summary.Total.Required == 0 {
-		return 0.0
+// comparePlanVersions compara la historia académica con todas las versiones del plan de la carrera,
+// para los estudiantes que siguen en un pensum anterior
+func comparePlanVersions(c *gin.Context) {
+	var academicHistory models.AcademicHistoryInput
+	if err := c.ShouldBindJSON(&academicHistory); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos: " + err.Error()})
+		return
 	}
-	return (float64(summary.Total.Completed) / float64(summary.Total.Required)) * 100.0
-}
 
-// APICompareRequest estructura para la solicitud de comparación desde texto
-type APICompareRequest struct {
-	AcademicHistoryText string `json:"academic_history_text" binding:"required"`
-	TargetCareerCode    string `json:"target_career_code" binding:"required"`
-}
+	academicHistory.Explain = academicHistory.Explain || explainRequested(c)
+	versions, err := functions.CompareAcademicHistoryAllVersions(config.DB, academicHistory)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-// ParsedSubject representa una materia extraída del texto de historia académica
-type ParsedSubject struct {
-	Code        string  `json:"code"`
-	Name        string  `json:"name"`
-	Credits     int     `json:"credits"`
-	Type        string  `json:"type"`
-	Grade       float64 `json:"grade"`
-	Status      string  `json:"status"`
-	Semester    string  `json:"semester"`
-}
-
-// Parser alternativo más flexible para historia académica
-func parseAcademicHistoryTextFlexible(text string) ([]ParsedSubject, error) {
-	fmt.Println("[DEBUG] Usando parser flexible")
-	fmt.Println("=== INICIO DEL TEXTO ===")
-	fmt.Println(text)
-	fmt.Println("=== FIN DEL TEXTO ===")
-	
-	lines := strings.Split(text, "\n")
-	var subjects []ParsedSubject
-	
-	// Buscar patrones de materias en el texto
-	// Patrón 1: Código entre paréntesis al inicio de línea
-	codePattern := regexp.MustCompile(`^([^(]+)\s*\(([^)]+)\)`)
-	// Patrón 2: Línea que contiene créditos (número)
-	creditsPattern := regexp.MustCompile(`^\s*(\d+)\s*$`)
-	// Patrón 3: Línea que contiene calificación (número decimal)
-	gradePattern := regexp.MustCompile(`^\s*(\d+\.?\d*)\s*$`)
-	
-	var currentSubject *ParsedSubject
-	var lineCount int
-	
-	for i, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+	summary := gin.H{"versions_compared": len(versions)}
+	best := -1
+	for i, version := range versions {
+		if version.Applies {
+			summary["applicable_version"] = version.Version
 		}
-		
-		fmt.Printf("[DEBUG] Procesando línea %d: '%s'\n", i+1, line)
-		
-		// Si encontramos un código de materia, empezar nueva materia
-		if match := codePattern.FindStringSubmatch(line); match != nil {
-			if currentSubject != nil {
-				// Guardar la materia anterior si existe
-				subjects = append(subjects, *currentSubject)
-			}
-			
-			name := strings.TrimSpace(match[1])
-			code := strings.TrimSpace(match[2])
-			
-			currentSubject = &ParsedSubject{
-				Code:     code,
-				Name:     name,
-				Status:   "APROBADA",
-				Credits:  0,
-				Grade:    0.0,
-				Type:     "",
-				Semester: "",
-			}
-			lineCount = 0
-			fmt.Printf("[DEBUG] Nueva materia encontrada: %s (%s)\n", name, code)
-			continue
-		}
-		
-		// Si tenemos una materia en progreso, procesar las líneas siguientes
-		if currentSubject != nil {
-			lineCount++
-			
-			switch lineCount {
-			case 1: // Créditos
-				if match := creditsPattern.FindStringSubmatch(line); match != nil {
-					if credits, err := strconv.Atoi(match[1]); err == nil {
-						currentSubject.Credits = credits
-						fmt.Printf("[DEBUG] Créditos: %d\n", credits)
-					}
-				}
-			case 2: // Tipo
-				currentSubject.Type = line
-				fmt.Printf("[DEBUG] Tipo: %s\n", line)
-			case 3: // Período
-				currentSubject.Semester = line
-				fmt.Printf("[DEBUG] Período: %s\n", line)
-			case 4: // Calificación
-				if match := gradePattern.FindStringSubmatch(line); match != nil {
-					if grade, err := strconv.ParseFloat(match[1], 64); err == nil {
-						currentSubject.Grade = grade
-						fmt.Printf("[DEBUG] Calificación: %.1f\n", grade)
-					}
-				}
-				// Después de procesar la calificación, guardar la materia
-				subjects = append(subjects, *currentSubject)
-				currentSubject = nil
-				lineCount = 0
-			}
+		if best < 0 || version.CompletionPercentage > versions[best].CompletionPercentage {
+			best = i
 		}
 	}
-	
-	// Guardar la última materia si existe
-	if currentSubject != nil {
-		subjects = append(subjects, *currentSubject)
+	if best >= 0 {
+		summary["best_version"] = versions[best].Version
 	}
-	
-	fmt.Printf("[DEBUG] Total materias parseadas (flexible): %d\n", len(subjects))
-	return subjects, nil
+	c.JSON(http.StatusOK, gin.H{
+		"versions": versions,
+		"summary":  summary,
+	})
 }
 
-// Limpieza y normalización del texto de historia académica
-func preprocessAcademicHistoryText(raw string) string {
-	// 1. Reemplazar saltos de línea de Windows por Unix
-	cleaned := strings.ReplaceAll(raw, "\r\n", "\n")
-	cleaned = strings.ReplaceAll(cleaned, "\r", "\n")
-
-	// 2. Insertar salto de línea antes de cada materia (NOMBRE (CÓDIGO))
-	// Esto detecta patrones como: Nombre de materia (código)
-	cleaned = regexp.MustCompile(`([A-Za-zÁÉÍÓÚÑáéíóúüÜ0-9\- ]+\([0-9A-Z\-]+\))`).ReplaceAllString(cleaned, "\n$1")
-
-	// 3. Insertar salto de línea antes de cada número de créditos (1 o 2 dígitos)
-	cleaned = regexp.MustCompile(`([A-Za-zÁÉÍÓÚÑáéíóúüÜ)]+)(\d{1,2})F`).ReplaceAllString(cleaned, "$1\n$2F")
-	// Y también antes de cada número de créditos suelto
-	cleaned = regexp.MustCompile(`([A-Za-zÁÉÍÓÚÑáéíóúüÜ)]+)(\d{1,2})\b`).ReplaceAllString(cleaned, "$1\n$2")
+// simulateAcademicHistory compara la historia académica con y sin los cambios hipotéticos (materias por
+// cursar con la nota esperada, materias retiradas o un plan objetivo distinto) y retorna las diferencias
+func simulateAcademicHistory(c *gin.Context) {
+	var input models.SimulationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos: " + err.Error()})
+		return
+	}
 
-	// 4. Insertar salto de línea antes de cada tipo de materia
-	cleaned = regexp.MustCompile(`(\d{1,2})((FUND\. OBLIGATORIA|FUND\. OPTATIVA|DISCIPLINAR OBLIGATORIA|DISCIPLINAR OPTATIVA|LIBRE ELECCIÓN|NIVELACIÓN|TRABAJO DE GRADO))`).ReplaceAllString(cleaned, "$1\n$2")
+	input.History.Explain = input.History.Explain || explainRequested(c)
+	result, err := functions.SimulateAcademicHistory(config.DB, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// 5. Insertar salto de línea antes de cada periodo (año-semestre)
-	cleaned = regexp.MustCompile(`(OBLIGATORIA|OPTATIVA|ELECCIÓN|NIVELACIÓN|GRADO)(\d{4}-\dS|\d{4}-\dS|\d{4}-\d{1,2}S|\d{4}-\d{1,2})`).ReplaceAllString(cleaned, "$1\n$2")
+	c.JSON(http.StatusOK, gin.H{"simulation": result})
+}
 
-	// 6. Insertar salto de línea antes de cada calificación (número decimal)
-	cleaned = regexp.MustCompile(`(\d{4}-\dS|\d{4}-\d{1,2}S|\d{4}-\d{1,2})( Ordinaria)?([0-9]\.[0-9])`).ReplaceAllString(cleaned, "$1$2\n$3")
+// studyPlanInfo resume el plan de estudio con el que se comparó la historia
+func studyPlanInfo(studyPlan *models.StudyPlan) gin.H {
+	return gin.H{
+		"id":              studyPlan.ID,
+		"version":         studyPlan.Version,
+		"career":          studyPlan.Career.Name,
+		"is_active":       studyPlan.IsActive,
+		"admission_start": studyPlan.AdmissionStart(),
+	}
+}
 
-	// 7. Insertar salto de línea antes de cada "APROBADA" o "APROBAD" (por si hay variantes)
-	cleaned = regexp.MustCompile(`([0-9]\.[0-9])((APROBADA|APROBAD))`).ReplaceAllString(cleaned, "$1\n$2")
+// explainRequested indica si la solicitud pidió el registro de decisiones de la comparación (?explain=true)
+func explainRequested(c *gin.Context) bool {
+	explain, _ := strconv.ParseBool(c.Query("explain"))
+	return explain
+}
 
-	// 8. Reemplazar múltiples saltos de línea por uno solo
-	cleaned = regexp.MustCompile(`\n+`).ReplaceAllString(cleaned, "\n")
-	// 9. Quitar espacios en blanco al inicio y final de cada línea
-	lines := strings.Split(cleaned, "\n")
-	for i, line := range lines {
-		lines[i] = strings.TrimSpace(line)
+// countPendingElectiveGroups cuenta las agrupaciones de optativas a las que aún les faltan créditos
+func countPendingElectiveGroups(groups []models.ElectiveGroupResult) int {
+	pending := 0
+	for _, group := range groups {
+		if !group.Satisfied {
+			pending++
+		}
 	}
-	cleaned = strings.Join(lines, "\n")
-	// 10. Quitar espacios en blanco al inicio y final del texto
-	cleaned = strings.TrimSpace(cleaned)
-	return cleaned
+	return pending
+}
+
+// APICompareRequest estructura para la solicitud de comparación desde texto
+type APICompareRequest struct {
+	AcademicHistoryText string `json:"academic_history_text" binding:"required"`
+	TargetCareerCode    string `json:"target_career_code" binding:"required"`
+	Plan                string `json:"plan"` // Plan de la historia a comparar (posición o nombre) cuando tiene varios
+	PlanVersion         string `json:"plan_version"`     // Versión del plan de la carrera objetivo en lugar del plan activo
+	AdmissionPeriod     string `json:"admission_period"` // Periodo de admisión del estudiante para elegir el plan vigente
 }
 
 // compareAcademicHistoryFromText compara historia académica en texto con el pensum
 func compareAcademicHistoryFromText(c *gin.Context) {
-	var academicHistoryText, targetCareerCode string
+	var academicHistoryText, targetCareerCode, plan, planVersion, admissionPeriod string
 
 	contentType := c.GetHeader("Content-Type")
 	if strings.HasPrefix(contentType, "application/json") {
@@ -761,14 +815,23 @@ func compareAcademicHistoryFromText(c *gin.Context) {
 		}
 		academicHistoryText = req.AcademicHistoryText
 		targetCareerCode = req.TargetCareerCode
+		plan = req.Plan
+		planVersion = req.PlanVersion
+		admissionPeriod = req.AdmissionPeriod
 	} else if strings.HasPrefix(contentType, "multipart/form-data") || strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
-		// Leer desde form-data o x-www-form-urlencoded
-		academicHistoryText = c.PostForm("academic_history_text")
+		// Leer desde form-data o x-www-form-urlencoded; la historia puede venir como texto o como PDF
+		var err error
+		academicHistoryText, err = historyTextFromForm(c, "academic_history_text", "academic_history_pdf")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error leyendo academic_history_pdf: " + err.Error()})
+			return
+		}
 		targetCareerCode = c.PostForm("target_career_code")
-		fmt.Printf("[DEBUG] academic_history_text recibido: '%s'\n", academicHistoryText)
-		fmt.Printf("[DEBUG] target_career_code recibido: '%s'\n", targetCareerCode)
+		plan = c.PostForm("plan")
+		planVersion = c.PostForm("plan_version")
+		admissionPeriod = c.PostForm("admission_period")
 		if academicHistoryText == "" || targetCareerCode == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Faltan campos en el formulario: academic_history_text y target_career_code son requeridos"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Faltan campos en el formulario: academic_history_text (o academic_history_pdf) y target_career_code son requeridos"})
 			return
 		}
 	} else {
@@ -776,37 +839,29 @@ func compareAcademicHistoryFromText(c *gin.Context) {
 		return
 	}
 
-	// Limpieza y normalización del texto
-	cleanedText := preprocessAcademicHistoryText(academicHistoryText)
-
-	// Parsear la historia académica del texto limpio
-	parsedSubjects, err := parseAcademicHistoryTextFlexible(cleanedText)
+	// Parsear la historia académica del texto
+	fullHistory, err := parser.Parse(academicHistoryText)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Error parseando historia académica: " + err.Error()})
 		return
 	}
+	// Con varios planes (cambio de carrera) se compara solo el plan elegido
+	parsed, err := fullHistory.SelectPlan(plan)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "available_plans": fullHistory.PlanNames()})
+		return
+	}
 
 	// Convertir a formato de entrada de la API
-	var subjects []models.SubjectInput
-	for _, ps := range parsedSubjects {
-		subject := models.SubjectInput{
-			Code:     strings.TrimSpace(ps.Code),
-			Name:     ps.Name,
-			Credits:  ps.Credits,
-			Type:     models.TipologiaAsignatura(ps.Type),
-			Grade:    ps.Grade,
-			Status:   ps.Status,
-			Semester: ps.Semester,
-		}
-		subjects = append(subjects, subject)
-	}
-	fmt.Printf("[DEBUG] Subjects parseados para comparar: %+v\n", subjects)
+	subjects := parsed.SubjectInputs()
 
 	academicHistory := models.AcademicHistoryInput{
-		CareerCode: targetCareerCode,
-		Subjects:   subjects,
+		CareerCode:      targetCareerCode,
+		Subjects:        subjects,
+		Explain:         explainRequested(c),
+		PlanVersion:     planVersion,
+		AdmissionPeriod: admissionPeriod,
 	}
-	fmt.Printf("[DEBUG] DTO enviado a comparación: %+v\n", academicHistory)
 
 	// Realizar la comparación
 	result, err := functions.CompareAcademicHistoryByCareerCode(config.DB, academicHistory)
@@ -816,23 +871,398 @@ func compareAcademicHistoryFromText(c *gin.Context) {
 	}
 
 	// Obtener información del plan de estudio usado
-	studyPlan, _ := functions.GetStudyPlanByCareerCode(config.DB, targetCareerCode)
+	studyPlan, _ := functions.ResolveStudyPlan(config.DB, targetCareerCode, planVersion, admissionPeriod)
 
 	c.JSON(http.StatusOK, gin.H{
-		"parsed_subjects": parsedSubjects,
+		"parsed_subjects": parsed.Subjects,
+		"parse_warnings": parsed.Warnings,
+		"parse_format": fullHistory.Format,
+		"parse_normalizations": fullHistory.Normalizations,
+		"available_plans": fullHistory.PlanNames(),
+		"selected_plan": parsed.Metadata.PlanName,
 		"comparison_result": result,
-		"study_plan_info": gin.H{
-			"id":      studyPlan.ID,
-			"version": studyPlan.Version,
-			"career":  studyPlan.Career.Name,
-		},
+		"credit_reconciliation": functions.ReconcileCreditsSummary(parsed.CreditSummary, result.CreditsSummary),
+		"study_plan_info": studyPlanInfo(studyPlan),
 		"summary": gin.H{
-			"total_subjects_parsed":     len(parsedSubjects),
+			"total_subjects_parsed":     len(parsed.Subjects),
 			"total_subjects_in_plan":    len(result.EquivalentSubjects) + len(result.MissingSubjects),
 			"approved_subjects":         len(result.EquivalentSubjects),
 			"missing_subjects":          len(result.MissingSubjects),
-			"completion_percentage":     calculateCompletionPercentage(result.CreditsSummary),
+			"pending_elective_groups":   countPendingElectiveGroups(result.ElectiveGroups),
+			"completion_percentage":     result.CreditsSummary.CompletionPercentage(),
+		},
+	})
+}
+
+// ExploreCareersRequest estructura para comparar una historia académica con todas las carreras.
+// La historia se envía estructurada (subjects) o como texto del SIA (academic_history_text).
+type ExploreCareersRequest struct {
+	Subjects            []models.SubjectInput `json:"subjects"`
+	AcademicHistoryText string                `json:"academic_history_text"`
+	Plan                string                `json:"plan"` // Plan de la historia en texto a comparar cuando tiene varios
+}
+
+// exploreCareers compara la historia académica con el plan activo de cada carrera y las ordena por
+// porcentaje de avance y créditos homologables
+func exploreCareers(c *gin.Context) {
+	var req ExploreCareersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos: " + err.Error()})
+		return
+	}
+
+	subjects := req.Subjects
+	if strings.TrimSpace(req.AcademicHistoryText) != "" {
+		fullHistory, err := parser.Parse(req.AcademicHistoryText)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error parseando historia académica: " + err.Error()})
+			return
+		}
+		parsed, err := fullHistory.SelectPlan(req.Plan)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "available_plans": fullHistory.PlanNames()})
+			return
+		}
+		subjects = parsed.SubjectInputs()
+	}
+	if len(subjects) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Se requiere subjects o academic_history_text con al menos una materia"})
+		return
+	}
+
+	options, skipped, err := functions.ExploreCareers(config.DB, subjects)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo carreras: " + err.Error()})
+		return
+	}
+
+	summary := gin.H{
+		"total_subjects":   len(subjects),
+		"careers_compared": len(options),
+		"careers_skipped":  len(skipped),
+	}
+	if len(options) > 0 {
+		summary["best_career"] = options[0].CareerCode
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"careers":         options,
+		"skipped_careers": skipped,
+		"summary":         summary,
+	})
+}
+
+// ParseRequest estructura para la solicitud de vista previa del parseo
+type ParseRequest struct {
+	AcademicHistoryText string `json:"academic_history_text" binding:"required"`
+	CareerCode          string `json:"career_code"`
+}
+
+// parseAcademicHistory solo ejecuta el parser y retorna la historia estructurada con la confianza
+// de cada materia. El campo "history" se puede corregir y enviar tal cual a /api/compare-by-career.
+// Si la historia tiene varios planes, las materias y el "history" de cada uno están en "plans".
+func parseAcademicHistory(c *gin.Context) {
+	var academicHistoryText, careerCode string
+
+	contentType := c.GetHeader("Content-Type")
+	if strings.HasPrefix(contentType, "application/json") {
+		var req ParseRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos: " + err.Error()})
+			return
+		}
+		academicHistoryText = req.AcademicHistoryText
+		careerCode = req.CareerCode
+	} else if strings.HasPrefix(contentType, "multipart/form-data") || strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		var err error
+		academicHistoryText, err = historyTextFromForm(c, "academic_history_text", "academic_history_pdf")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Error leyendo academic_history_pdf: " + err.Error()})
+			return
+		}
+		careerCode = c.PostForm("career_code")
+		if academicHistoryText == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Faltan campos en el formulario: academic_history_text (o academic_history_pdf) es requerido"})
+			return
+		}
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Content-Type no soportado. Usa application/json o form-data."})
+		return
+	}
+
+	parsed, err := parser.Parse(academicHistoryText)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error parseando historia académica: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"format":             parsed.Format,
+		"metadata":           parsed.Metadata,
+		"subjects":           parsed.Subjects,
+		"credit_summary":     parsed.CreditSummary,
+		"warnings":           parsed.Warnings,
+		"normalizations":     parsed.Normalizations,
+		"average_confidence": parsed.AverageConfidence(),
+		"history": models.AcademicHistoryInput{
+			CareerCode: careerCode,
+			Subjects:   parsed.SubjectInputs(),
 		},
+		"plans": planPreviews(parsed, careerCode),
+	})
+}
+
+// planPreviews arma la vista previa de cada plan de la historia para que el asesor elija cuál usar
+// como origen ("plan" en /api/compare, "plan_origen" en /api/doble-titulacion) o envíe su "history"
+func planPreviews(parsed *parser.Result, careerCode string) []gin.H {
+	plans := parsed.Plans
+	if len(plans) == 0 {
+		plans = []*parser.Result{parsed}
+	}
+	previews := make([]gin.H, len(plans))
+	for i, plan := range plans {
+		previews[i] = gin.H{
+			"index":              i + 1,
+			"metadata":           plan.Metadata,
+			"subjects":           plan.Subjects,
+			"credit_summary":     plan.CreditSummary,
+			"warnings":           plan.Warnings,
+			"average_confidence": plan.AverageConfidence(),
+			"history": models.AcademicHistoryInput{
+				CareerCode: careerCode,
+				Subjects:   plan.SubjectInputs(),
+			},
+		}
+	}
+	return previews
+}
+
+// getHistoryFormats lista los formatos de historia académica en el orden en que se evalúan al detectar
+func getHistoryFormats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"formats": parser.Formats()})
+}
+
+// compareDobleTitulacionStructured compara doble titulación a partir de listas de materias ya estructuradas
+func compareDobleTitulacionStructured(c *gin.Context) {
+	var req models.DobleTitulacionEstructuradaInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	req.Explain = req.Explain || explainRequested(c)
+	resultado, err := functions.CompareDobleTitulacionParsed(config.DB, req.MateriasOrigen, req.MateriasDoble, req.CodigoCarreraObjetivo, req.PlanVersion, req.AdmissionPeriod, req.Explain)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"resultado": resultado,
+	})
+}
+
+// ImportCompareRequest estructura para importar una historia académica en JSON sin subir un archivo
+type ImportCompareRequest struct {
+	CareerCode      string           `json:"career_code" binding:"required"`
+	Mapping         importer.Mapping `json:"mapping"`
+	Rows            json.RawMessage  `json:"rows" binding:"required"`
+	PlanVersion     string           `json:"plan_version"`     // Versión del plan de la carrera en lugar del plan activo
+	AdmissionPeriod string           `json:"admission_period"` // Periodo de admisión del estudiante para elegir el plan vigente
+}
+
+// maxImportFileSize es el tamaño máximo aceptado para los archivos de historia académica estructurada (10 MB)
+const maxImportFileSize = 10 << 20
+
+// importAndCompareAcademicHistory importa una historia académica desde CSV, XLSX o JSON con un
+// mapeo de columnas a los campos de SubjectInput, valida cada fila y compara las filas válidas con el pensum.
+// Acepta form-data (file, career_code, format, mapping, sheet) o JSON (career_code, mapping, rows); en
+// ambos casos plan_version o admission_period eligen el plan de la carrera.
+func importAndCompareAcademicHistory(c *gin.Context) {
+	var imported *importer.Result
+	var careerCode, planVersion, admissionPeriod string
+	var err error
+
+	contentType := c.GetHeader("Content-Type")
+	if strings.HasPrefix(contentType, "application/json") {
+		var req ImportCompareRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Datos de entrada inválidos: " + err.Error()})
+			return
+		}
+		careerCode = req.CareerCode
+		planVersion = req.PlanVersion
+		admissionPeriod = req.AdmissionPeriod
+		imported, err = importer.Import(importer.FormatJSON, req.Rows, req.Mapping, "")
+	} else if strings.HasPrefix(contentType, "multipart/form-data") {
+		careerCode = c.PostForm("career_code")
+		planVersion = c.PostForm("plan_version")
+		admissionPeriod = c.PostForm("admission_period")
+		fileHeader, fileErr := c.FormFile("file")
+		if fileErr != nil || careerCode == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Faltan campos en el formulario: file y career_code son requeridos"})
+			return
+		}
+		if fileHeader.Size > maxImportFileSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("El archivo supera el tamaño máximo de %d MB", maxImportFileSize>>20)})
+			return
+		}
+
+		format := c.PostForm("format")
+		if format == "" {
+			format = importer.FormatFromFilename(fileHeader.Filename)
+		}
+
+		var mapping importer.Mapping
+		if raw := c.PostForm("mapping"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "El mapeo de columnas debe ser un objeto JSON: " + err.Error()})
+				return
+			}
+		}
+
+		file, openErr := fileHeader.Open()
+		if openErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo: " + openErr.Error()})
+			return
+		}
+		data, readErr := io.ReadAll(file)
+		file.Close()
+		if readErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo: " + readErr.Error()})
+			return
+		}
+		imported, err = importer.Import(format, data, mapping, c.PostForm("sheet"))
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Content-Type no soportado. Usa application/json o form-data."})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error importando la historia académica: " + err.Error()})
+		return
+	}
+	if len(imported.Subjects) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "Ninguna fila del archivo es válida",
+			"import": imported,
+		})
+		return
+	}
+
+	academicHistory := models.AcademicHistoryInput{
+		CareerCode:      careerCode,
+		Subjects:        imported.Subjects,
+		Explain:         explainRequested(c),
+		PlanVersion:     planVersion,
+		AdmissionPeriod: admissionPeriod,
+	}
+	result, err := functions.CompareAcademicHistoryByCareerCode(config.DB, academicHistory)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	studyPlan, _ := functions.ResolveStudyPlan(config.DB, careerCode, planVersion, admissionPeriod)
+
+	c.JSON(http.StatusOK, gin.H{
+		"import":            imported,
+		"comparison_result": result,
+		"study_plan_info":   studyPlanInfo(studyPlan),
+		"summary": gin.H{
+			"total_rows":              imported.Rows,
+			"imported_subjects":       len(imported.Subjects),
+			"rows_with_errors":        imported.Rows - len(imported.Subjects),
+			"total_subjects_in_plan":  len(result.EquivalentSubjects) + len(result.MissingSubjects),
+			"approved_subjects":       len(result.EquivalentSubjects),
+			"missing_subjects":        len(result.MissingSubjects),
+			"pending_elective_groups": countPendingElectiveGroups(result.ElectiveGroups),
+			"completion_percentage":   result.CreditsSummary.CompletionPercentage(),
+		},
+	})
+}
+
+// maxHistoryPDFSize es el tamaño máximo aceptado para el PDF de la historia académica (10 MB)
+const maxHistoryPDFSize = 10 << 20
+
+// historyTextFromForm obtiene el texto de la historia académica enviada en un formulario.
+// Si se adjuntó el archivo pdfField se extrae su texto; de lo contrario se usa el campo textField.
+func historyTextFromForm(c *gin.Context, textField, pdfField string) (string, error) {
+	fileHeader, err := c.FormFile(pdfField)
+	if err != nil {
+		if errors.Is(err, http.ErrMissingFile) || errors.Is(err, http.ErrNotMultipart) {
+			return c.PostForm(textField), nil
+		}
+		return "", err
+	}
+	if fileHeader.Size > maxHistoryPDFSize {
+		return "", fmt.Errorf("el archivo supera el tamaño máximo de %d MB", maxHistoryPDFSize>>20)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	return pdftext.ExtractText(data)
+}
+
+// getHistoriaAcademica parsea el texto de la historia académica y retorna el encabezado del estudiante
+func getHistoriaAcademica(c *gin.Context) {
+	var req HistoriaAcademicaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El campo 'historia' es requerido"})
+		return
+	}
+
+	fullHistory, err := parser.Parse(req.Historia)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Error parseando historia académica: " + err.Error()})
+		return
+	}
+	// Con varios planes (cambio de carrera) el encabezado y las asignaturas son los del plan elegido
+	parsed, err := fullHistory.SelectPlan(req.Plan)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "available_plans": fullHistory.PlanNames()})
+		return
+	}
+
+	asignaturas := make([]Asignatura, 0, len(parsed.Subjects))
+	for _, s := range parsed.Subjects {
+		asignaturas = append(asignaturas, Asignatura{
+			Nombre:       s.Name,
+			Codigo:       s.Code,
+			Creditos:     s.Credits,
+			Tipo:         TipologiaAsignatura(s.Type),
+			Periodo:      s.Semester,
+			Calificacion: s.Grade,
+			CalificacionTexto: s.GradeLabel,
+			Estado:       s.Status,
+			Modalidad:    s.Modality,
+		})
+	}
+
+	meta := parsed.Metadata
+	c.JSON(http.StatusOK, HistoriaAcademicaResponse{
+		PlanEstudios:     meta.PlanName,
+		Facultad:         meta.Faculty,
+		NumeroHistoria:   meta.HistoryNumber,
+		Estado:           meta.Status,
+		Bloqueada:        meta.Blocked,
+		CausasBloqueo:    meta.BlockCauses,
+		PAPA:             meta.PAPA,
+		PeriodoPAPA:      meta.PAPAPeriod,
+		Promedio:         meta.Promedio,
+		PeriodoPromedio:  meta.PromedioPeriod,
+		Asignaturas:      asignaturas,
+		ResumenCreditos:  parsed.CreditSummary,
+		PorcentajeAvance: meta.ProgressPercentage,
+		Advertencias:     parsed.Warnings,
+		Formato:          parsed.Format,
 	})
 }
 
@@ -893,10 +1323,13 @@ func createEquivalence(c *gin.Context) {
 			Credits     int    `json:"credits" binding:"required"`
 			Description string `json:"description"`
 		} `json:"source_subject" binding:"required"`
-		TargetSubjectID uint   `json:"target_subject_id" binding:"required"`
-		CareerID        uint   `json:"career_id" binding:"required"`
-		Type            string `json:"type" binding:"required"`
-		Notes           string `json:"notes"`
+		TargetSubjectID  uint     `json:"target_subject_id" binding:"required"`
+		CareerID         uint     `json:"career_id" binding:"required"`
+		Type             string   `json:"type" binding:"required"` // TOTAL o PARCIAL
+		Credits          int      `json:"credits"`                 // Créditos que abona una equivalencia PARCIAL
+		Notes            string   `json:"notes"`
+		ExtraSourceCodes []string `json:"extra_source_codes"` // Otras materias origen que se exigen junto con la principal
+		ExtraTargetCodes []string `json:"extra_target_codes"` // Otras materias destino que cubre la equivalencia
 	}
 	
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -910,7 +1343,10 @@ func createEquivalence(c *gin.Context) {
 		req.TargetSubjectID,
 		req.CareerID,
 		req.Type,
+		req.Credits,
 		req.Notes,
+		req.ExtraSourceCodes,
+		req.ExtraTargetCodes,
 	)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -929,9 +1365,12 @@ func updateEquivalence(c *gin.Context) {
 	}
 	
 	var req struct {
-		Type            string `json:"type"`
-		Notes           string `json:"notes"`
-		TargetSubjectID uint   `json:"target_subject_id"`
+		Type             string   `json:"type"`
+		Credits          int      `json:"credits"`
+		Notes            string   `json:"notes"`
+		TargetSubjectID  uint     `json:"target_subject_id"`
+		ExtraSourceCodes []string `json:"extra_source_codes"`
+		ExtraTargetCodes []string `json:"extra_target_codes"`
 	}
 	
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -994,6 +1433,348 @@ func deleteEquivalence(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Equivalencia eliminada exitosamente"})
 }
 
+// getSubjectAliases obtiene todos los alias de códigos de materias
+func getSubjectAliases(c *gin.Context) {
+	aliases, err := functions.GetAllSubjectAliases(config.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo alias: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"aliases": aliases,
+	})
+}
+
+// getSubjectAliasByID obtiene un alias por ID
+func getSubjectAliasByID(c *gin.Context) {
+	aliasID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de alias inválido"})
+		return
+	}
+
+	alias, err := functions.GetSubjectAliasByID(config.DB, uint(aliasID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alias no encontrado: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alias": alias,
+	})
+}
+
+// createSubjectAlias registra un código histórico o variante para una materia existente
+func createSubjectAlias(c *gin.Context) {
+	var req struct {
+		Alias       string `json:"alias" binding:"required"`
+		SubjectCode string `json:"subject_code" binding:"required"`
+		Notes       string `json:"notes"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	alias, err := functions.CreateSubjectAlias(config.DB, req.Alias, req.SubjectCode, req.Notes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"alias": alias})
+}
+
+// updateSubjectAlias actualiza un alias
+func updateSubjectAlias(c *gin.Context) {
+	aliasID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de alias inválido"})
+		return
+	}
+
+	var req struct {
+		Alias       string `json:"alias"`
+		SubjectCode string `json:"subject_code"`
+		Notes       string `json:"notes"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	alias, err := functions.UpdateSubjectAlias(config.DB, uint(aliasID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"alias": alias})
+}
+
+// deleteSubjectAlias elimina un alias
+func deleteSubjectAlias(c *gin.Context) {
+	aliasID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de alias inválido"})
+		return
+	}
+
+	if err := functions.DeleteSubjectAlias(config.DB, uint(aliasID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alias eliminado exitosamente"})
+}
+
+// getTypologySynonyms obtiene los sinónimos de tipologías
+func getTypologySynonyms(c *gin.Context) {
+	synonyms, err := functions.GetAllTypologySynonyms(config.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo sinónimos: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"synonyms": synonyms,
+	})
+}
+
+// createTypologySynonym registra una etiqueta de tipología
+func createTypologySynonym(c *gin.Context) {
+	var req struct {
+		Label     string `json:"label" binding:"required"`
+		Tipologia string `json:"tipologia" binding:"required"`
+		Notes     string `json:"notes"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	synonym, err := functions.CreateTypologySynonym(config.DB, req.Label, req.Tipologia, req.Notes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"synonym": synonym})
+}
+
+// updateTypologySynonym actualiza un sinónimo de tipología
+func updateTypologySynonym(c *gin.Context) {
+	synonymID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de sinónimo inválido"})
+		return
+	}
+
+	var req struct {
+		Label     string `json:"label"`
+		Tipologia string `json:"tipologia"`
+		Notes     string `json:"notes"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	synonym, err := functions.UpdateTypologySynonym(config.DB, uint(synonymID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"synonym": synonym})
+}
+
+// deleteTypologySynonym elimina un sinónimo de tipología
+func deleteTypologySynonym(c *gin.Context) {
+	synonymID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de sinónimo inválido"})
+		return
+	}
+
+	if err := functions.DeleteTypologySynonym(config.DB, uint(synonymID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sinónimo eliminado exitosamente"})
+}
+
+// updateStudyPlanElectiveOverflow cambia la regla del plan para los créditos de optativas excedentes
+func updateStudyPlanElectiveOverflow(c *gin.Context) {
+	studyPlanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de plan de estudio inválido"})
+		return
+	}
+
+	var req struct {
+		ElectiveOverflow string `json:"elective_overflow" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	studyPlan, err := functions.UpdateStudyPlanElectiveOverflow(config.DB, uint(studyPlanID), req.ElectiveOverflow)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"study_plan": studyPlan})
+}
+
+// updateStudyPlanAdmissionFrom cambia el primer periodo de admisión al que aplica el plan
+func updateStudyPlanAdmissionFrom(c *gin.Context) {
+	studyPlanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de plan de estudio inválido"})
+		return
+	}
+
+	var req struct {
+		AdmissionFrom string `json:"admission_from"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	studyPlan, err := functions.UpdateStudyPlanAdmissionFrom(config.DB, uint(studyPlanID), req.AdmissionFrom)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"study_plan": studyPlan})
+}
+
+// getElectiveGroupsByStudyPlan obtiene las agrupaciones de optativas de un plan de estudio
+func getElectiveGroupsByStudyPlan(c *gin.Context) {
+	studyPlanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de plan de estudio inválido"})
+		return
+	}
+
+	groups, err := functions.GetElectiveGroupsByStudyPlan(config.DB, uint(studyPlanID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo agrupaciones: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"elective_groups": groups,
+		"count":           len(groups),
+	})
+}
+
+// getElectiveGroupByID obtiene una agrupación de optativas por su ID
+func getElectiveGroupByID(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de agrupación inválido"})
+		return
+	}
+
+	group, err := functions.GetElectiveGroupByID(config.DB, uint(groupID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Agrupación no encontrada: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"elective_group": group})
+}
+
+// createElectiveGroup crea una agrupación de optativas en un plan de estudio
+func createElectiveGroup(c *gin.Context) {
+	studyPlanID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de plan de estudio inválido"})
+		return
+	}
+
+	var req struct {
+		Name         string   `json:"name" binding:"required"`
+		Tipologia    string   `json:"tipologia" binding:"required"`
+		MinCredits   int      `json:"min_credits" binding:"required"`
+		SubjectCodes []string `json:"subject_codes" binding:"required"`
+		Notes        string   `json:"notes"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	group, err := functions.CreateElectiveGroup(config.DB, uint(studyPlanID), req.Name, req.Tipologia, req.MinCredits, req.SubjectCodes, req.Notes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"elective_group": group})
+}
+
+// updateElectiveGroup actualiza una agrupación de optativas
+func updateElectiveGroup(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de agrupación inválido"})
+		return
+	}
+
+	var req struct {
+		Name         string   `json:"name"`
+		Tipologia    string   `json:"tipologia"`
+		MinCredits   int      `json:"min_credits"`
+		SubjectCodes []string `json:"subject_codes"`
+		Notes        string   `json:"notes"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos: " + err.Error()})
+		return
+	}
+
+	group, err := functions.UpdateElectiveGroup(config.DB, uint(groupID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"elective_group": group})
+}
+
+// deleteElectiveGroup elimina una agrupación de optativas
+func deleteElectiveGroup(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de agrupación inválido"})
+		return
+	}
+
+	if err := functions.DeleteElectiveGroup(config.DB, uint(groupID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Agrupación eliminada exitosamente"})
+}
+
 // getAllSubjects obtiene todas las asignaturas de la base de datos
 func getAllSubjects(c *gin.Context) {
 	var subjects []models.Subject
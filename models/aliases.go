@@ -0,0 +1,71 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SubjectAlias relaciona un código histórico o una variante de código con la materia canónica.
+// Se usa cuando la universidad reemplaza un código por otro o cuando la historia académica
+// reporta el código con un formato distinto al del catálogo.
+type SubjectAlias struct {
+	ID        uint   `gorm:"primaryKey"`
+	Alias     string `gorm:"size:20;unique;not null"` // Código alterno, guardado normalizado
+	SubjectID uint   `gorm:"not null"`                // Materia canónica
+	Notes     string `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Relaciones
+	Subject Subject `gorm:"foreignKey:SubjectID"`
+}
+
+// Reglas con las que se resuelve el código de una materia de la historia académica
+const (
+	ResolucionAlias         = "ALIAS"         // El código está registrado en la tabla de alias
+	ResolucionNormalizacion = "NORMALIZACIÓN" // El código coincide después de normalizarlo
+)
+
+// CodeResolution indica cómo se resolvió el código de la historia académica al código canónico
+type CodeResolution struct {
+	HistoryCode   string `json:"history_code"`   // Código tal como aparece en la historia académica
+	CanonicalCode string `json:"canonical_code"` // Código de la materia en el catálogo
+	Rule          string `json:"rule"`           // ALIAS o NORMALIZACIÓN
+}
+
+// NormalizarCodigo convierte un código de materia a su forma comparable: sin espacios, en
+// mayúsculas, con guiones simples y sin ceros a la izquierda en la parte numérica
+// ("  0001000003 – m" -> "1000003-M").
+func NormalizarCodigo(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		switch {
+		case unicode.IsSpace(r):
+			continue
+		case r == '_' || unicode.Is(unicode.Pd, r):
+			b.WriteRune('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	normalized := b.String()
+
+	digits := 0
+	for digits < len(normalized) && normalized[digits] >= '0' && normalized[digits] <= '9' {
+		digits++
+	}
+	zeros := 0
+	for zeros < digits-1 && normalized[zeros] == '0' {
+		zeros++
+	}
+	return normalized[zeros:]
+}
+
+// CodigoBase retorna el código normalizado sin el sufijo de sede ("1000003-M" -> "1000003")
+func CodigoBase(code string) string {
+	normalized := NormalizarCodigo(code)
+	if i := strings.Index(normalized, "-"); i > 0 {
+		return normalized[:i]
+	}
+	return normalized
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Attempt representa un intento de cursar una materia (periodo, calificación y estado)
+type Attempt struct {
+	Semester   string  `json:"semester"`
+	Grade      float64 `json:"grade"`
+	GradeLabel string  `json:"grade_label,omitempty"`
+	Status     string  `json:"status"`
+	Modality   string  `json:"modality,omitempty"`
+}
+
+// RepeatedSubject representa una materia que el estudiante cursó más de una vez
+type RepeatedSubject struct {
+	Code     string    `json:"code"`
+	Name     string    `json:"name"`
+	Attempts []Attempt `json:"attempts"`
+	Approved bool      `json:"approved"` // Si alguno de los intentos fue aprobado
+}
+
+// periodRe reconoce periodos académicos como "2021-2S", "2021-1" o "2021-03"
+var periodRe = regexp.MustCompile(`^(\d{4})-(\d{1,2})S?`)
+
+// periodOnlyRe reconoce un texto que es solo un periodo académico
+var periodOnlyRe = regexp.MustCompile(`(?i)^\d{4}-\d{1,2}S?$`)
+
+// ValidarPeriodo verifica que el texto sea un periodo académico completo ("2021-2S", "2021-1")
+func ValidarPeriodo(period string) bool {
+	return periodOnlyRe.MatchString(strings.TrimSpace(period))
+}
+
+// ComparePeriods compara dos periodos académicos. Retorna -1 si a es anterior a b,
+// 1 si es posterior y 0 si son iguales. Los periodos no reconocidos se comparan como texto.
+func ComparePeriods(a, b string) int {
+	ma := periodRe.FindStringSubmatch(strings.TrimSpace(a))
+	mb := periodRe.FindStringSubmatch(strings.TrimSpace(b))
+	if ma == nil || mb == nil {
+		return strings.Compare(a, b)
+	}
+
+	yearA, _ := strconv.Atoi(ma[1])
+	yearB, _ := strconv.Atoi(mb[1])
+	if yearA != yearB {
+		if yearA < yearB {
+			return -1
+		}
+		return 1
+	}
+
+	termA, _ := strconv.Atoi(ma[2])
+	termB, _ := strconv.Atoi(mb[2])
+	switch {
+	case termA < termB:
+		return -1
+	case termA > termB:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// NextPeriod retorna el periodo académico siguiente ("2021-1S" → "2021-2S", "2021-2S" → "2022-1S").
+// Los periodos no reconocidos se retornan sin cambios.
+func NextPeriod(period string) string {
+	m := periodRe.FindStringSubmatch(strings.TrimSpace(period))
+	if m == nil {
+		return period
+	}
+	year, _ := strconv.Atoi(m[1])
+	term, _ := strconv.Atoi(m[2])
+	suffix := ""
+	if strings.HasSuffix(strings.ToUpper(m[0]), "S") {
+		suffix = "S"
+	}
+	if term >= 2 {
+		return strconv.Itoa(year+1) + "-1" + suffix
+	}
+	return m[1] + "-" + strconv.Itoa(term+1) + suffix
+}
+
+// attempt construye el intento que representa la materia
+func (s SubjectInput) attempt() Attempt {
+	return Attempt{
+		Semester:   s.Semester,
+		Grade:      s.Grade,
+		GradeLabel: s.GradeLabel,
+		Status:     s.Status,
+		Modality:   s.Modality,
+	}
+}
+
+// withAttempt retorna la materia con los datos del intento indicado
+func (s SubjectInput) withAttempt(a Attempt) SubjectInput {
+	s.Semester = a.Semester
+	s.Grade = a.Grade
+	s.GradeLabel = a.GradeLabel
+	s.Status = a.Status
+	s.Modality = a.Modality
+	return s
+}
+
+// MergeAttempts agrupa los intentos de una misma materia (mismo código) en una sola entrada.
+// Los datos principales de la materia corresponden al último intento aprobado o, si no hay
+// ninguno aprobado, al último intento. Todos los intentos quedan en Attempts ordenados por periodo.
+func MergeAttempts(subjects []SubjectInput) []SubjectInput {
+	var order []string
+	groups := make(map[string][]SubjectInput)
+	for _, s := range subjects {
+		code := strings.TrimSpace(s.Code)
+		if _, exists := groups[code]; !exists {
+			order = append(order, code)
+		}
+		groups[code] = append(groups[code], s)
+	}
+
+	merged := make([]SubjectInput, 0, len(order))
+	for _, code := range order {
+		group := groups[code]
+
+		var attempts []Attempt
+		for _, s := range group {
+			if len(s.Attempts) > 0 {
+				attempts = append(attempts, s.Attempts...)
+			} else {
+				attempts = append(attempts, s.attempt())
+			}
+		}
+		sort.SliceStable(attempts, func(i, j int) bool {
+			return ComparePeriods(attempts[i].Semester, attempts[j].Semester) < 0
+		})
+
+		base := group[0]
+		base.Code = code
+		chosen := attempts[len(attempts)-1]
+		for i := len(attempts) - 1; i >= 0; i-- {
+			if base.withAttempt(attempts[i]).IsApproved() {
+				chosen = attempts[i]
+				break
+			}
+		}
+
+		result := base.withAttempt(chosen)
+		result.Attempts = nil
+		if len(attempts) > 1 {
+			result.Attempts = attempts
+		}
+		merged = append(merged, result)
+	}
+
+	return merged
+}
+
+// RepeatedSubjects retorna las materias con más de un intento de una historia ya consolidada con MergeAttempts
+func RepeatedSubjects(subjects []SubjectInput) []RepeatedSubject {
+	repeated := []RepeatedSubject{}
+	for _, s := range subjects {
+		if len(s.Attempts) < 2 {
+			continue
+		}
+		approved := false
+		for _, a := range s.Attempts {
+			if s.withAttempt(a).IsApproved() {
+				approved = true
+				break
+			}
+		}
+		repeated = append(repeated, RepeatedSubject{
+			Code:     s.Code,
+			Name:     s.Name,
+			Attempts: s.Attempts,
+			Approved: approved,
+		})
+	}
+	return repeated
+}
@@ -0,0 +1,25 @@
+package models
+
+// CareerOption es el resultado de comparar una historia académica con el plan activo de una carrera.
+// Este es un DTO y no se almacena en la base de datos.
+type CareerOption struct {
+	Rank                 int            `json:"rank"`
+	CareerCode           string         `json:"career_code"`
+	CareerName           string         `json:"career_name"`
+	StudyPlanID          uint           `json:"study_plan_id"`
+	StudyPlanVersion     string         `json:"study_plan_version"`
+	CompletionPercentage float64        `json:"completion_percentage"`
+	HomologableCredits   int            `json:"homologable_credits"` // Créditos de la historia que se abonan al plan
+	MissingCredits       int            `json:"missing_credits"`
+	UnusedCredits        int            `json:"unused_credits"` // Créditos aprobados que el plan no reconoce
+	ApprovedSubjects     int            `json:"approved_subjects"`
+	MissingSubjects      int            `json:"missing_subjects"`
+	CreditsSummary       CreditsSummary `json:"credits_summary"`
+}
+
+// SkippedCareer es una carrera que no se pudo comparar, con el motivo
+type SkippedCareer struct {
+	CareerCode string `json:"career_code"`
+	CareerName string `json:"career_name"`
+	Reason     string `json:"reason"`
+}
@@ -0,0 +1,30 @@
+package models
+
+// Tipos de decisión del registro de la comparación (?explain=true)
+const (
+	DecisionCodigoNormalizado   = "CÓDIGO NORMALIZADO"   // El código de la historia se comparó con otra forma (espacios, alias, formato)
+	DecisionCoincidenciaDirecta = "COINCIDENCIA DIRECTA" // La materia del plan está en la historia con el mismo código
+	DecisionEquivalencia        = "EQUIVALENCIA"         // La materia del plan se cubrió con una equivalencia
+	DecisionDescartada          = "CANDIDATA DESCARTADA" // Una materia de la historia o una equivalencia no cubrió la materia
+	DecisionEnCurso             = "EN CURSO"             // La materia del plan se cubriría con materias en curso
+	DecisionSinCoincidencia     = "SIN COINCIDENCIA"     // Ninguna materia de la historia cubre la materia del plan
+	DecisionFueraDelPlan        = "FUERA DEL PLAN"       // Materia aprobada que no cubre ninguna materia del plan
+)
+
+// Sentido en que se aplicó una equivalencia
+const (
+	DireccionOrigenDestino = "ORIGEN → DESTINO"
+	DireccionDestinoOrigen = "DESTINO → ORIGEN" // Equivalencia total aplicada en sentido inverso
+)
+
+// Decision es una entrada del registro que explica cómo se decidió una materia en la comparación.
+// Este es un DTO y no se almacena en la base de datos.
+type Decision struct {
+	Seq           int    `json:"seq"`                      // Orden de la decisión dentro de la comparación
+	Kind          string `json:"kind"`                     // Tipo de decisión (COINCIDENCIA DIRECTA, EQUIVALENCIA, ...)
+	PlanCode      string `json:"plan_code,omitempty"`      // Materia del plan a la que se refiere
+	HistoryCode   string `json:"history_code,omitempty"`   // Materia de la historia involucrada
+	EquivalenceID uint   `json:"equivalence_id,omitempty"` // Equivalencia aplicada o descartada
+	Direction     string `json:"direction,omitempty"`      // Sentido en que se aplicó la equivalencia
+	Message       string `json:"message"`
+}
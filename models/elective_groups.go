@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ElectiveGroup es una agrupación de asignaturas optativas del plan de estudio: el estudiante
+// debe aprobar al menos MinCredits créditos entre las asignaturas de la agrupación, sin que
+// ninguna de ellas sea obligatoria por sí sola
+type ElectiveGroup struct {
+	ID          uint                `gorm:"primaryKey"`
+	StudyPlanID uint                `gorm:"not null"`
+	Name        string              `gorm:"size:100;not null"`
+	Tipologia   TipologiaAsignatura `gorm:"size:50;not null"`
+	MinCredits  int                 `gorm:"not null"`
+	Notes       string              `gorm:"type:text"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	// Relaciones
+	Subjects []Subject `gorm:"many2many:elective_group_subjects;"`
+}
+
+// ElectiveGroupResult es el estado de una agrupación de optativas en la comparación.
+// Este es un DTO y no se almacena en la base de datos.
+type ElectiveGroupResult struct {
+	ID               uint                `json:"id,omitempty"` // 0 para la agrupación implícita de las optativas sin agrupación
+	Name             string              `json:"name"`
+	Tipologia        TipologiaAsignatura `json:"tipologia"`
+	MinCredits       int                 `json:"min_credits"`
+	CompletedCredits int                 `json:"completed_credits"`
+	ProjectedCredits int                 `json:"projected_credits"` // Créditos de las optativas en curso
+	MissingCredits   int                 `json:"missing_credits"`
+	Satisfied        bool                `json:"satisfied"`
+	Completed        []SubjectResult     `json:"completed"` // Optativas aprobadas que suman a la agrupación
+	InProgress       []SubjectResult     `json:"in_progress"`
+	Options          []SubjectResult     `json:"options"` // Optativas disponibles que el estudiante aún no ha cursado
+}
@@ -0,0 +1,50 @@
+package models
+
+import "strings"
+
+// Tipos de equivalencia
+const (
+	EquivalenciaTotal   = "TOTAL"   // Las materias origen cubren por completo las materias destino
+	EquivalenciaParcial = "PARCIAL" // Las materias origen abonan solo parte de los créditos de cada destino
+)
+
+// NormalizarTipoEquivalencia convierte el tipo de equivalencia a mayúsculas sin espacios
+func NormalizarTipoEquivalencia(tipo string) string {
+	return strings.ToUpper(strings.TrimSpace(tipo))
+}
+
+// ValidarTipoEquivalencia verifica que el tipo de equivalencia sea uno de los permitidos
+func ValidarTipoEquivalencia(tipo string) bool {
+	switch NormalizarTipoEquivalencia(tipo) {
+	case EquivalenciaTotal, EquivalenciaParcial:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsPartial indica si la equivalencia abona solo parte de los créditos de las materias destino
+func (e Equivalence) IsPartial() bool {
+	return NormalizarTipoEquivalencia(e.Type) == EquivalenciaParcial
+}
+
+// SourceCodes retorna los códigos de todas las materias origen, empezando por la principal
+func (e Equivalence) SourceCodes() []string {
+	return subjectCodes(e.SourceSubject, e.ExtraSourceSubjects)
+}
+
+// TargetCodes retorna los códigos de todas las materias destino, empezando por la principal
+func (e Equivalence) TargetCodes() []string {
+	return subjectCodes(e.TargetSubject, e.ExtraTargetSubjects)
+}
+
+func subjectCodes(main Subject, extra []Subject) []string {
+	codes := make([]string, 0, 1+len(extra))
+	codes = append(codes, main.Code)
+	for _, subject := range extra {
+		if subject.Code != main.Code {
+			codes = append(codes, subject.Code)
+		}
+	}
+	return codes
+}
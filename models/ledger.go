@@ -0,0 +1,130 @@
+package models
+
+// Vías por las que una materia de la historia aporta créditos al plan de estudio
+const (
+	ViaDirecta      = "DIRECTA"        // La materia del plan está aprobada en la historia
+	ViaEquivalencia = "EQUIVALENCIA"   // La materia del plan se cubre con una equivalencia
+	ViaFueraDelPlan = "FUERA DEL PLAN" // Materia aprobada que no está en el plan: se abona a libre elección
+	ViaExcedente    = "EXCEDENTE"      // Créditos de optativas por encima de la cuota de su tipología
+)
+
+// Reglas del plan para los créditos de optativas que exceden la cuota de su tipología
+const (
+	ExcedenteALibre    = "LIBRE ELECCIÓN" // Se abonan a libre elección mientras haya cupo
+	ExcedenteSinAbonar = "NINGUNO"        // No se abonan a ninguna tipología
+)
+
+// ValidarReglaExcedente verifica que la regla de excedentes sea una de las permitidas
+func ValidarReglaExcedente(regla string) bool {
+	return regla == ExcedenteALibre || regla == ExcedenteSinAbonar
+}
+
+// CreditLedgerEntry registra los créditos que una materia de la historia aportó a una tipología del plan.
+// Este es un DTO y no se almacena en la base de datos.
+type CreditLedgerEntry struct {
+	Tipologia     TipologiaAsignatura `json:"tipologia"`
+	Credits       int                 `json:"credits"`
+	PlanCode      string              `json:"plan_code"`                // Materia del plan a la que se abonan los créditos
+	HistoryCode   string              `json:"history_code"`             // Materia de la historia que aporta los créditos
+	Via           string              `json:"via"`                      // DIRECTA, EQUIVALENCIA, FUERA DEL PLAN o EXCEDENTE
+	ResolvedAlias *CodeResolution     `json:"resolved_alias,omitempty"` // Código de la historia resuelto por alias o normalización
+	ElectiveGroup string              `json:"elective_group,omitempty"` // Agrupación de optativas a la que suman los créditos
+	Notes         string              `json:"notes,omitempty"`
+}
+
+// CreditLedger acumula los créditos aprobados por tipología junto con la materia y la vía de
+// cada abono, de modo que cada total del resumen de créditos se pueda auditar
+type CreditLedger struct {
+	entries []CreditLedgerEntry
+	totals  map[TipologiaAsignatura]int
+}
+
+// NewCreditLedger crea un libro de créditos vacío
+func NewCreditLedger() *CreditLedger {
+	return &CreditLedger{
+		entries: []CreditLedgerEntry{},
+		totals:  make(map[TipologiaAsignatura]int),
+	}
+}
+
+// Add abona los créditos de una entrada a su tipología
+func (l *CreditLedger) Add(entry CreditLedgerEntry) {
+	l.entries = append(l.entries, entry)
+	l.totals[entry.Tipologia] += entry.Credits
+}
+
+// Completed retorna los créditos abonados a una tipología
+func (l *CreditLedger) Completed(tipologia TipologiaAsignatura) int {
+	return l.totals[tipologia]
+}
+
+// Total retorna los créditos abonados a las tipologías que cuentan para el grado
+// (incluido el trabajo de grado, que no tiene fila propia en el resumen)
+func (l *CreditLedger) Total() int {
+	total := 0
+	for tipologia, credits := range l.totals {
+		if tipologia.CuentaParaGrado() {
+			total += credits
+		}
+	}
+	return total
+}
+
+// Excess retorna los créditos abonados a una tipología que superan la cuota (0 si no la superan)
+func (l *CreditLedger) Excess(tipologia TipologiaAsignatura, quota int) int {
+	if excess := l.totals[tipologia] - quota; excess > 0 {
+		return excess
+	}
+	return 0
+}
+
+// Take retira hasta credits créditos de los abonos de una tipología y los retorna como entradas
+// separadas para abonarlos a otra tipología o reportarlos sin usar. El llamador elige los abonos
+// con selected (nil permite cualquiera); entre los elegidos se retira primero de los últimos
+// registrados, y un abono puede quedar retirado en parte.
+func (l *CreditLedger) Take(tipologia TipologiaAsignatura, credits int, selected func(CreditLedgerEntry) bool) []CreditLedgerEntry {
+	var taken []CreditLedgerEntry
+	for i := len(l.entries) - 1; i >= 0 && credits > 0; i-- {
+		entry := l.entries[i]
+		if entry.Tipologia != tipologia || (selected != nil && !selected(entry)) {
+			continue
+		}
+		part := entry
+		if part.Credits > credits {
+			part.Credits = credits
+		}
+		taken = append([]CreditLedgerEntry{part}, taken...)
+
+		l.entries[i].Credits -= part.Credits
+		if l.entries[i].Credits == 0 {
+			l.entries = append(l.entries[:i], l.entries[i+1:]...)
+		}
+		l.totals[tipologia] -= part.Credits
+		credits -= part.Credits
+	}
+	return taken
+}
+
+// Entries retorna los abonos en el orden en que se registraron
+func (l *CreditLedger) Entries() []CreditLedgerEntry {
+	return l.entries
+}
+
+// CreditInfo arma la fila del resumen de créditos de una tipología con los créditos exigidos por el plan
+func (l *CreditLedger) CreditInfo(tipologia TipologiaAsignatura, required int) CreditTypeInfo {
+	return newCreditTypeInfo(required, l.Completed(tipologia))
+}
+
+// TotalInfo arma la fila del total del resumen de créditos con los créditos exigidos por el plan
+func (l *CreditLedger) TotalInfo(required int) CreditTypeInfo {
+	return newCreditTypeInfo(required, l.Total())
+}
+
+// newCreditTypeInfo calcula los créditos faltantes sin dejar valores negativos
+func newCreditTypeInfo(required, completed int) CreditTypeInfo {
+	missing := required - completed
+	if missing < 0 {
+		missing = 0
+	}
+	return CreditTypeInfo{Required: required, Completed: completed, Missing: missing}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
@@ -14,6 +15,7 @@ const (
 	TipologiaDisciplinarObligatoria TipologiaAsignatura = "DISCIPLINAR OBLIGATORIA"
 	TipologiaLibreEleccion         TipologiaAsignatura = "LIBRE ELECCIÓN"
 	TipologiaTrabajoGrado          TipologiaAsignatura = "TRABAJO DE GRADO"
+	TipologiaNivelacion            TipologiaAsignatura = "NIVELACIÓN" // Cursos de nivelación: no cuentan para los créditos del grado
 )
 
 // ValidarTipologia verifica si una tipología es válida
@@ -24,13 +26,30 @@ func ValidarTipologia(tipo string) bool {
 		 TipologiaFundamentalOptativa,
 		 TipologiaDisciplinarObligatoria,
 		 TipologiaLibreEleccion,
-		 TipologiaTrabajoGrado:
+		 TipologiaTrabajoGrado,
+		 TipologiaNivelacion:
 		return true
 	default:
 		return false
 	}
 }
 
+// CuentaParaGrado indica si los créditos de la tipología suman a los créditos exigidos para el grado.
+// Los cursos de nivelación se exigen según la admisión de cada estudiante y se reportan aparte.
+func (t TipologiaAsignatura) CuentaParaGrado() bool {
+	return TipologiaAsignatura(strings.ToUpper(strings.TrimSpace(string(t)))) != TipologiaNivelacion
+}
+
+// EsOptativa indica si la tipología es optativa (fundamentación o disciplinar): esas materias se
+// exigen por créditos dentro de una agrupación y no una por una
+func (t TipologiaAsignatura) EsOptativa() bool {
+	switch TipologiaAsignatura(strings.ToUpper(strings.TrimSpace(string(t)))) {
+	case TipologiaFundamentalOptativa, TipologiaDisciplinarOptativa:
+		return true
+	}
+	return false
+}
+
 // Career representa una carrera en la universidad
 type Career struct {
 	ID          uint      `gorm:"primaryKey"`
@@ -62,6 +81,23 @@ type StudyPlan struct {
 	DisObligatoriaCredits  int `gorm:"not null"`
 	DisOptativaCredits     int `gorm:"not null"`
 	LibreCredits           int `gorm:"not null"`
+	TrabajoGradoCredits    int `gorm:"not null;default:0"`
+	// Regla para los créditos de optativas que exceden la cuota (ExcedenteALibre o ExcedenteSinAbonar)
+	ElectiveOverflow string `gorm:"size:20;default:LIBRE ELECCIÓN"`
+	// Primer periodo de admisión al que aplica el plan (ej. "2023-1S"); vacío si no se ha definido
+	AdmissionFrom string `gorm:"size:10"`
+}
+
+// AdmissionStart retorna el primer periodo de admisión al que aplica el plan: AdmissionFrom o, si
+// no está definido, la versión cuando tiene forma de periodo ("2023-1"). Retorna "" si no se conoce.
+func (p StudyPlan) AdmissionStart() string {
+	if p.AdmissionFrom != "" {
+		return p.AdmissionFrom
+	}
+	if ValidarPeriodo(p.Version) {
+		return p.Version
+	}
+	return ""
 }
 
 // Subject representa una materia del plan de estudio
@@ -84,7 +120,8 @@ type Equivalence struct {
 	ID              uint      `gorm:"primaryKey"`
 	SourceSubjectID uint      `gorm:"not null"` // Materia origen (nueva materia que se crea)
 	TargetSubjectID uint      `gorm:"not null"` // Materia destino (materia existente del plan)
-	Type            string    `gorm:"size:20;not null"` // Tipo de equivalencia (total, parcial, etc)
+	Type            string    `gorm:"size:20;not null"` // Tipo de equivalencia: TOTAL o PARCIAL
+	Credits         int       `gorm:"not null;default:0"` // Créditos que abona a cada materia destino una equivalencia PARCIAL
 	Notes           string    `gorm:"type:text"`
 	CareerID        uint      `gorm:"not null"` // Carrera a la que aplica la equivalencia
 	CreatedAt       time.Time
@@ -93,6 +130,21 @@ type Equivalence struct {
 	SourceSubject Subject `gorm:"foreignKey:SourceSubjectID"`
 	TargetSubject Subject `gorm:"foreignKey:TargetSubjectID"`
 	Career        Career  `gorm:"foreignKey:CareerID"`
+	// Materias adicionales de equivalencias compuestas: se exigen todas las materias origen
+	// (varias a una) y se cubren todas las materias destino (una a varias)
+	ExtraSourceSubjects []Subject `gorm:"many2many:equivalence_source_subjects;"`
+	ExtraTargetSubjects []Subject `gorm:"many2many:equivalence_target_subjects;"`
+}
+
+// TypologySynonym relaciona una etiqueta de tipología (del SIA, de otra sede o de posgrado)
+// con la tipología del modelo. Los parsers solo reconocen las etiquetas registradas.
+type TypologySynonym struct {
+	ID        uint                `gorm:"primaryKey"`
+	Label     string              `gorm:"size:100;unique;not null"` // Etiqueta en mayúsculas, tal como aparece en la historia
+	Tipologia TipologiaAsignatura `gorm:"size:50;not null"`         // Tipología del modelo a la que corresponde
+	Notes     string              `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 // AcademicHistoryInput representa la entrada de historia académica para procesar
@@ -100,6 +152,9 @@ type Equivalence struct {
 type AcademicHistoryInput struct {
 	CareerCode    string   `json:"career_code" binding:"required"`
 	Subjects      []SubjectInput `json:"subjects" binding:"required"`
+	Explain       bool     `json:"explain,omitempty"` // Registrar cómo se decidió cada materia (?explain=true)
+	PlanVersion   string   `json:"plan_version,omitempty"`     // Versión del plan de la carrera a usar en lugar del plan activo
+	AdmissionPeriod string `json:"admission_period,omitempty"` // Periodo de admisión del estudiante: se usa el plan vigente en ese periodo
 }
 
 // SubjectInput representa una materia en la historia académica de entrada
@@ -108,9 +163,107 @@ type SubjectInput struct {
 	Name        string            `json:"name" binding:"required"`
 	Credits     int               `json:"credits" binding:"required"`
 	Type        TipologiaAsignatura `json:"type" binding:"required"`
-	Grade       float64           `json:"grade" binding:"required"`
+	Grade       float64           `json:"grade"`                       // 0 cuando la calificación no es numérica
+	GradeLabel  string            `json:"grade_label,omitempty"`       // Calificación no numérica: AP (aprobada) o NA (no aprobada)
 	Status      string            `json:"status" binding:"required"` // Aprobada, Reprobada, En curso, etc.
 	Semester    string            `json:"semester" binding:"required"` // Semestre en que se cursó
+	Modality    string            `json:"modality,omitempty"`           // Ordinaria, Validación, Homologación o Habilitación
+	Attempts    []Attempt         `json:"attempts,omitempty"`           // Todos los intentos cuando la materia se cursó más de una vez
+}
+
+// Estados de una materia en la historia académica
+const (
+	EstadoAprobada  = "APROBADA"
+	EstadoReprobada = "REPROBADA"
+	EstadoCancelada = "CANCELADA"
+	EstadoInscrita  = "INSCRITA" // En curso en el periodo actual
+)
+
+// Calificaciones no numéricas que usa el SIA
+const (
+	CalificacionAprobada   = "AP"
+	CalificacionNoAprobada = "NA"
+)
+
+// Modalidades en las que se obtiene la calificación de una materia
+const (
+	ModalidadOrdinaria    = "ORDINARIA"
+	ModalidadValidacion   = "VALIDACIÓN"
+	ModalidadHomologacion = "HOMOLOGACIÓN"
+	ModalidadHabilitacion = "HABILITACIÓN"
+)
+
+// NormalizarModalidad convierte el texto que acompaña al periodo en el SIA
+// (por ejemplo "Validacion por suficiencia") a una de las modalidades del modelo.
+// Las modalidades desconocidas se retornan en mayúsculas sin modificar.
+func NormalizarModalidad(text string) string {
+	modalidad := strings.ToUpper(strings.TrimSpace(text))
+	switch {
+	case modalidad == "":
+		return ""
+	case strings.HasPrefix(modalidad, "ORDINARIA"):
+		return ModalidadOrdinaria
+	case strings.HasPrefix(modalidad, "VALIDACION"), strings.HasPrefix(modalidad, "VALIDACIÓN"):
+		return ModalidadValidacion
+	case strings.HasPrefix(modalidad, "HOMOLOGACION"), strings.HasPrefix(modalidad, "HOMOLOGACIÓN"):
+		return ModalidadHomologacion
+	case strings.HasPrefix(modalidad, "HABILITACION"), strings.HasPrefix(modalidad, "HABILITACIÓN"):
+		return ModalidadHabilitacion
+	default:
+		return modalidad
+	}
+}
+
+// ValidarModalidad verifica si una modalidad es una de las modalidades conocidas
+func ValidarModalidad(modalidad string) bool {
+	switch modalidad {
+	case ModalidadOrdinaria, ModalidadValidacion, ModalidadHomologacion, ModalidadHabilitacion:
+		return true
+	default:
+		return false
+	}
+}
+
+// NotaMinimaAprobatoria es la calificación mínima para aprobar una materia
+const NotaMinimaAprobatoria = 3.0
+
+// NormalizarEstado convierte las variantes de estado del SIA y de la API a los estados del modelo.
+// Los estados desconocidos se retornan en mayúsculas sin modificar.
+func NormalizarEstado(status string) string {
+	estado := strings.ToUpper(strings.TrimSpace(status))
+	switch estado {
+	case "APROBADA", "APROBADO", "APROBAD":
+		return EstadoAprobada
+	case "REPROBADA", "REPROBADO", "NO APROBADA", "PERDIDA":
+		return EstadoReprobada
+	case "CANCELADA", "CANCELADO":
+		return EstadoCancelada
+	case "INSCRITA", "INSCRITO", "EN CURSO", "CURSANDO":
+		return EstadoInscrita
+	default:
+		return estado
+	}
+}
+
+// IsApproved indica si la materia fue aprobada y por lo tanto otorga créditos
+func (s SubjectInput) IsApproved() bool {
+	switch NormalizarEstado(s.Status) {
+	case EstadoAprobada:
+		return strings.ToUpper(s.GradeLabel) != CalificacionNoAprobada
+	case "":
+		// Sin estado explícito se decide por la calificación
+		if s.GradeLabel != "" {
+			return strings.ToUpper(s.GradeLabel) == CalificacionAprobada
+		}
+		return s.Grade >= NotaMinimaAprobatoria
+	default:
+		return false
+	}
+}
+
+// IsInProgress indica si la materia está inscrita en el periodo actual
+func (s SubjectInput) IsInProgress() bool {
+	return NormalizarEstado(s.Status) == EstadoInscrita
 }
 
 // ComparisonResult representa el resultado de la comparación de planes
@@ -118,9 +271,18 @@ type SubjectInput struct {
 type ComparisonResult struct {
 	EquivalentSubjects []SubjectResult `json:"equivalent_subjects"`
 	MissingSubjects    []SubjectResult `json:"missing_subjects"`
+	ProjectedSubjects  []SubjectResult `json:"projected_subjects"` // Materias del plan que se cubrirían con las materias en curso
+	ProjectedCredits   int             `json:"projected_credits"`
+	RepeatedSubjects   []RepeatedSubject `json:"repeated_subjects"` // Materias de la historia cursadas más de una vez
+	LevelingSubjects   []SubjectResult `json:"leveling_subjects"` // Cursos de nivelación de la historia (no cuentan para el grado)
 	TotalCredits       int             `json:"total_credits"`
 	MissingCredits     int             `json:"missing_credits"`
 	CreditsSummary     CreditsSummary  `json:"credits_summary"`
+	CreditLedger       []CreditLedgerEntry `json:"credit_ledger"` // Origen de cada crédito del resumen
+	ElectiveGroups     []ElectiveGroupResult `json:"elective_groups"` // Agrupaciones de optativas: créditos exigidos y opciones disponibles
+	OutOfPlanSubjects  []SubjectResult `json:"out_of_plan_subjects"` // Materias aprobadas que no están en el plan ni tienen equivalencia
+	UnusedCredits      []CreditLedgerEntry `json:"unused_credits"` // Créditos aprobados que no se abonaron por exceder las cuotas
+	DecisionLog        []Decision      `json:"decision_log,omitempty"` // Registro en orden de cada decisión (solo con explain)
 }
 
 // SubjectResult representa una materia en el resultado de la comparación
@@ -130,13 +292,30 @@ type SubjectResult struct {
 	Credits     int               `json:"credits"`
 	Type        TipologiaAsignatura `json:"type"`
 	Status      string            `json:"status"` // Equivalente, Falta, etc.
+	Modality    string            `json:"modality,omitempty"` // Modalidad de la materia de la historia que la cubre
+	Validated   bool              `json:"validated,omitempty"` // Aprobada por validación
+	ResolvedAlias *CodeResolution `json:"resolved_alias,omitempty"` // Código de la historia resuelto por alias o normalización
 	Equivalence *EquivalenceResult `json:"equivalence,omitempty"`
+	Decisions   []Decision        `json:"decisions,omitempty"` // Cómo se decidió la materia (solo con explain)
 }
 
 // EquivalenceResult representa una equivalencia en el resultado
 type EquivalenceResult struct {
-	Type  string `json:"type"`
-	Notes string `json:"notes"`
+	ID          uint     `json:"id,omitempty"` // Equivalencia aplicada
+	Type        string   `json:"type"`
+	Notes       string   `json:"notes"`
+	SourceCodes []string `json:"source_codes,omitempty"` // Materias de la historia que cumplen la equivalencia
+	Credits     int      `json:"credits"`                // Créditos abonados a la materia (menos que sus créditos si es PARCIAL)
+	Chain       []EquivalenceStep `json:"chain,omitempty"` // Equivalencias aplicadas, desde la historia hasta la materia del plan
+}
+
+// EquivalenceStep es una de las equivalencias encadenadas que justifican una materia
+type EquivalenceStep struct {
+	ID          uint     `json:"id"`
+	Type        string   `json:"type"`
+	SourceCodes []string `json:"source_codes"`
+	TargetCode  string   `json:"target_code"`
+	Direction   string   `json:"direction"` // ORIGEN → DESTINO o DESTINO → ORIGEN
 }
 
 // CreditTypeInfo representa el resumen de créditos por tipo
@@ -153,7 +332,46 @@ type CreditsSummary struct {
 	DisObligatoria    CreditTypeInfo `json:"dis_obligatoria"`
 	DisOptativa       CreditTypeInfo `json:"dis_optativa"`
 	Libre             CreditTypeInfo `json:"libre"`
+	TrabajoGrado      CreditTypeInfo `json:"trabajo_grado"`
 	Total             CreditTypeInfo `json:"total"`
+	Nivelacion        CreditTypeInfo `json:"nivelacion"` // Fuera del total: exigidos son los cursos de nivelación de la historia
+}
+
+// CompletionPercentage calcula el porcentaje de completitud basado en créditos
+func (s CreditsSummary) CompletionPercentage() float64 {
+	if s.Total.Required == 0 {
+		return 0.0
+	}
+	return (float64(s.Total.Completed) / float64(s.Total.Required)) * 100.0
+}
+
+// ResumenCreditos representa una fila de la tabla "Resumen de créditos" de la historia académica del SIA
+type ResumenCreditos struct {
+	Tipologia  TipologiaAsignatura `json:"tipologia"`
+	Exigidos   int                 `json:"exigidos"`
+	Aprobados  int                 `json:"aprobados"`
+	Pendientes int                 `json:"pendientes"`
+	Inscritos  int                 `json:"inscritos"`
+	Cursados   int                 `json:"cursados"`
+}
+
+// CreditReconciliation compara el resumen de créditos del SIA con el calculado por el sistema
+// Este es un DTO y no se almacena en la base de datos
+type CreditReconciliation struct {
+	SIA           []ResumenCreditos     `json:"sia"`
+	Computed      CreditsSummary        `json:"computed"`
+	Discrepancies []CreditDiscrepancy   `json:"discrepancies"`
+	Unmatched     []TipologiaAsignatura `json:"unmatched"` // Tipologías del SIA sin equivalente en el resumen calculado
+	Consistent    bool                  `json:"consistent"`
+}
+
+// CreditDiscrepancy representa una diferencia entre el SIA y el cálculo para una tipología
+type CreditDiscrepancy struct {
+	Tipologia  TipologiaAsignatura `json:"tipologia"`
+	Field      string              `json:"field"` // exigidos o aprobados
+	SIA        int                 `json:"sia"`
+	Computed   int                 `json:"computed"`
+	Difference int                 `json:"difference"` // Calculado - SIA
 }
 
 // DobleTitulacionInput representa la entrada para comparación de doble titulación
@@ -162,14 +380,35 @@ type DobleTitulacionInput struct {
 	HistoriaOrigen     string `json:"historia_origen" binding:"required"`     // Historia académica del primer plan
 	HistoriaDoble      string `json:"historia_doble" binding:"required"`      // Historia académica del segundo plan (doble titulación)
 	CodigoCarreraObjetivo string `json:"codigo_carrera_objetivo" binding:"required"` // Código de la carrera objetivo
+	PlanOrigen         string `json:"plan_origen"`                             // Plan de la historia de origen (posición o nombre) cuando tiene varios
+	PlanDoble          string `json:"plan_doble"`                              // Plan de la historia de doble titulación cuando tiene varios
+	PlanVersion        string `json:"plan_version,omitempty"`                  // Versión del plan de la carrera objetivo en lugar del plan activo
+	AdmissionPeriod    string `json:"admission_period,omitempty"`              // Periodo de admisión del estudiante: se usa el plan objetivo vigente en ese periodo
+	Explain            bool   `json:"explain,omitempty"`                       // Registrar cómo se decidió cada materia (?explain=true)
+}
+
+// DobleTitulacionEstructuradaInput representa la entrada de doble titulación con las historias ya
+// estructuradas (por ejemplo, corregidas por el asesor a partir de la vista previa de /api/parse)
+type DobleTitulacionEstructuradaInput struct {
+	MateriasOrigen        []SubjectInput `json:"materias_origen" binding:"required"`         // Materias del primer plan
+	MateriasDoble         []SubjectInput `json:"materias_doble"`                             // Materias ya cursadas en el plan de doble titulación
+	CodigoCarreraObjetivo string         `json:"codigo_carrera_objetivo" binding:"required"` // Código de la carrera objetivo
+	PlanVersion           string         `json:"plan_version,omitempty"`                     // Versión del plan de la carrera objetivo en lugar del plan activo
+	AdmissionPeriod       string         `json:"admission_period,omitempty"`                 // Periodo de admisión del estudiante: se usa el plan objetivo vigente en ese periodo
+	Explain               bool           `json:"explain,omitempty"`                          // Registrar cómo se decidió cada materia (?explain=true)
 }
 
 // DobleTitulacionResult representa el resultado de la comparación de doble titulación
 type DobleTitulacionResult struct {
+	PlanObjetivoID       uint                 `json:"plan_objetivo_id"`
+	VersionPlanObjetivo  string               `json:"version_plan_objetivo"` // Versión del plan de la carrera objetivo usada en la comparación
 	MateriasHomologables []MateriaHomologable `json:"materias_homologables"`
 	TotalMaterias        int                  `json:"total_materias"`
 	TotalCreditos        int                  `json:"total_creditos"`
+	MateriasRepetidas    []RepeatedSubject    `json:"materias_repetidas"` // Materias de la historia de origen cursadas más de una vez
+	MateriasNoHomologables []MateriaNoHomologable `json:"materias_no_homologables"` // Materias cursadas que no se pueden homologar
 	Resumen              ResumenDobleTitulacion `json:"resumen"`
+	DecisionLog          []Decision             `json:"decision_log,omitempty"` // Registro en orden de cada decisión (solo con explain)
 }
 
 // MateriaHomologable representa una materia que se puede homologar en doble titulación
@@ -184,6 +423,19 @@ type MateriaHomologable struct {
 	Periodo            string            `json:"periodo"`             // Periodo en que se cursó
 	Calificacion       float64           `json:"calificacion"`        // Calificación obtenida
 	Equivalencia       *EquivalenceResult `json:"equivalencia,omitempty"` // Info de equivalencia si aplica
+	Modalidad          string            `json:"modalidad,omitempty"` // Modalidad en que se aprobó en origen
+	Validada           bool              `json:"validada"`            // Aprobada por validación en el plan de origen
+	CodigoResuelto     *CodeResolution   `json:"codigo_resuelto,omitempty"` // Código de origen resuelto por alias o normalización
+}
+
+// MateriaNoHomologable representa una materia aprobada en origen que no se puede homologar
+type MateriaNoHomologable struct {
+	CodigoObjetivo string `json:"codigo_objetivo"`
+	NombreObjetivo string `json:"nombre_objetivo"`
+	CodigoOrigen   string `json:"codigo_origen"`
+	NombreOrigen   string `json:"nombre_origen"`
+	Modalidad      string `json:"modalidad"`
+	Motivo         string `json:"motivo"`
 }
 
 // ResumenDobleTitulacion representa el resumen de la comparación
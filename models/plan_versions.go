@@ -0,0 +1,15 @@
+package models
+
+// PlanVersionComparison es el resultado de comparar una historia académica con una de las versiones
+// del plan de estudio de la carrera. Este es un DTO y no se almacena en la base de datos.
+type PlanVersionComparison struct {
+	StudyPlanID          uint              `json:"study_plan_id"`
+	Version              string            `json:"version"`
+	IsActive             bool              `json:"is_active"`
+	AdmissionStart       string            `json:"admission_start,omitempty"` // Primer periodo de admisión al que aplica el plan
+	Applies              bool              `json:"applies"`                   // Es el plan que aplica al estudiante según la solicitud
+	CompletionPercentage float64           `json:"completion_percentage"`
+	ApprovedSubjects     int               `json:"approved_subjects"`
+	MissingSubjects      int               `json:"missing_subjects"`
+	Result               *ComparisonResult `json:"comparison_result"`
+}
@@ -0,0 +1,53 @@
+package models
+
+// SimulationInput es una historia académica con cambios hipotéticos: materias por cursar con la
+// nota esperada, materias que se retiran de la historia o un plan objetivo distinto.
+// Este es un DTO y no se almacena en la base de datos.
+type SimulationInput struct {
+	History AcademicHistoryInput `json:"history" binding:"required"`
+	// Materias hipotéticas. Solo el código es obligatorio si la materia está en el plan objetivo:
+	// nombre, créditos y tipología se toman del plan; sin estado se decide por la nota esperada
+	// y sin semestre se ubican en el periodo siguiente al último de la historia.
+	Add  []SubjectInput `json:"add"`
+	Drop []string       `json:"drop"` // Códigos de materias que se retiran de la historia (todos sus intentos)
+	// Plan objetivo de la simulación; vacío usa el mismo plan que la comparación base
+	TargetCareerCode      string `json:"target_career_code,omitempty"`
+	TargetPlanVersion     string `json:"target_plan_version,omitempty"`
+	TargetAdmissionPeriod string `json:"target_admission_period,omitempty"`
+}
+
+// SimulationResult es la comparación base, la comparación con los cambios hipotéticos y sus diferencias
+type SimulationResult struct {
+	BaselineStudyPlanID  uint              `json:"baseline_study_plan_id"`
+	BaselinePlanVersion  string            `json:"baseline_plan_version"`
+	SimulatedStudyPlanID uint              `json:"simulated_study_plan_id"`
+	SimulatedPlanVersion string            `json:"simulated_plan_version"`
+	Baseline             *ComparisonResult `json:"baseline"`
+	Simulated            *ComparisonResult `json:"simulated"`
+	Diff                 SimulationDiff    `json:"diff"`
+}
+
+// SimulationDiff resume lo que cambia entre la comparación base y la simulada
+type SimulationDiff struct {
+	Credits             []CreditsDiff `json:"credits"` // Una fila por tipología y el total
+	CompletionBefore    float64       `json:"completion_before"`
+	CompletionAfter     float64       `json:"completion_after"`
+	PendingBefore       int           `json:"pending_before"`
+	PendingAfter        int           `json:"pending_after"`
+	NewlyCovered        []string      `json:"newly_covered"` // Materias del plan que dejan de estar pendientes
+	NewlyPending        []string      `json:"newly_pending"` // Materias del plan que pasan a estar pendientes
+	Added               []string      `json:"added"`
+	Dropped             []string      `json:"dropped"`
+	HypotheticalCredits int           `json:"hypothetical_credits"` // Créditos de las materias hipotéticas con nota numérica
+	HypotheticalAverage float64       `json:"hypothetical_average"` // Promedio ponderado por créditos de las notas esperadas
+}
+
+// CreditsDiff compara una fila del resumen de créditos antes y después de la simulación
+type CreditsDiff struct {
+	Tipologia       TipologiaAsignatura `json:"tipologia"` // "TOTAL" para la fila del total
+	CompletedBefore int                 `json:"completed_before"`
+	CompletedAfter  int                 `json:"completed_after"`
+	CompletedDelta  int                 `json:"completed_delta"`
+	MissingBefore   int                 `json:"missing_before"`
+	MissingAfter    int                 `json:"missing_after"`
+}
@@ -0,0 +1,64 @@
+package parser
+
+import "math"
+
+// confidencePenalties es cuánto baja la confianza de una materia cada advertencia asociada a ella
+var confidencePenalties = map[string]float64{
+	WarnMissingType:      0.3,
+	WarnUnknownTipologia: 0.3,
+	WarnMissingStatus:    0.3,
+	WarnMissingPeriod:    0.2,
+	WarnGradeOutOfRange:  0.2,
+	WarnUnknownModality:  0.1,
+	WarnUnrecognizedLine: 0.1,
+}
+
+// inferredFieldPenalty es cuánto baja la confianza cada campo deducido en lugar de leído del texto
+const inferredFieldPenalty = 0.1
+
+// markInferred registra que un campo de la materia se dedujo (por ejemplo el estado a partir de la nota)
+func (r *Result) markInferred(code, field string) {
+	if r.inferred == nil {
+		r.inferred = make(map[string][]string)
+	}
+	r.inferred[code] = append(r.inferred[code], field)
+}
+
+// scoreConfidence calcula la confianza de cada materia a partir de sus advertencias y campos deducidos.
+// Una materia sin problemas tiene confianza 1; el asesor debe revisar primero las de menor confianza.
+func (r *Result) scoreConfidence() {
+	penalties := make(map[string]float64)
+	issues := make(map[string][]string)
+	for _, w := range r.Warnings {
+		if w.Subject == "" {
+			continue
+		}
+		penalties[w.Subject] += confidencePenalties[w.Code]
+		issues[w.Subject] = append(issues[w.Subject], w.Code)
+	}
+	for code, fields := range r.inferred {
+		for _, field := range fields {
+			penalties[code] += inferredFieldPenalty
+			issues[code] = append(issues[code], "inferred_"+field)
+		}
+	}
+
+	for i := range r.Subjects {
+		code := r.Subjects[i].Code
+		confidence := math.Max(0, 1-penalties[code])
+		r.Subjects[i].Confidence = math.Round(confidence*100) / 100
+		r.Subjects[i].Issues = issues[code]
+	}
+}
+
+// AverageConfidence retorna la confianza promedio de las materias parseadas (0 si no hay materias)
+func (r *Result) AverageConfidence() float64 {
+	if len(r.Subjects) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, s := range r.Subjects {
+		total += s.Confidence
+	}
+	return math.Round(total/float64(len(r.Subjects))*100) / 100
+}
@@ -0,0 +1,277 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlDetectRe reconoce texto que corresponde al HTML de la página del SIA (guardada o pegada)
+var htmlDetectRe = regexp.MustCompile(`(?i)<\s*(html|body|table|tr|td|div)\b`)
+
+// htmlBlockElements son los elementos que inician una nueva línea al convertir el documento a texto
+var htmlBlockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Br: true, atom.Caption: true, atom.Dd: true, atom.Div: true, atom.Dl: true,
+	atom.Dt: true, atom.Fieldset: true, atom.Footer: true, atom.Form: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.Li: true, atom.Main: true, atom.Nav: true,
+	atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true,
+	atom.Tbody: true, atom.Thead: true, atom.Tfoot: true, atom.Tr: true, atom.Ul: true,
+}
+
+// htmlSkipElements son los elementos cuyo contenido no es texto visible
+var htmlSkipElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+}
+
+// LooksLikeHTML indica si el texto recibido es HTML en lugar del texto copiado del portal
+func LooksLikeHTML(text string) bool {
+	trimmed := strings.TrimSpace(text)
+	return strings.HasPrefix(trimmed, "<") && htmlDetectRe.MatchString(trimmed)
+}
+
+// htmlRow es una fila de tabla: cada celda se guarda como la lista de líneas de su contenido
+type htmlRow struct {
+	cells [][]string
+}
+
+// ParseHTML procesa el HTML de la página "Historia Académica" del SIA a partir de la estructura
+// de sus tablas: cada fila con una celda "Nombre (CÓDIGO)" es una materia y sus demás celdas son
+// los campos, de modo que no hace falta reconstruir saltos de línea con expresiones regulares.
+// Los datos del encabezado se leen del texto del documento separado por bloques.
+func ParseHTML(raw string) (*Result, error) {
+	doc, err := html.Parse(strings.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("HTML inválido: %v", err)
+	}
+
+	result := NewResult()
+	result.Metadata = parseMetadata(htmlText(doc))
+
+	locator := &lineLocator{raw: raw, line: 1}
+	summary := &summaryBuilder{}
+	for _, row := range htmlRows(doc) {
+		if b := subjectFromRow(row, locator); b != nil {
+			for _, cell := range row.cells[b.nameCell+1:] {
+				for _, text := range cellFields(cell) {
+					if !b.consume(sourceLine{Number: b.subject.Line, Text: text}, result) {
+						result.warn(b.subject.Line, WarnUnrecognizedLine, text, b.subject.Code,
+							"celda no reconocida en la fila de la materia")
+					}
+				}
+			}
+			result.finishSubject(&b.subjectBuilder)
+			continue
+		}
+
+		if isSummaryRow(row) {
+			fields := make([]string, len(row.cells))
+			for i, cell := range row.cells {
+				fields[i] = strings.Join(cell, " ")
+			}
+			text := strings.Join(fields, "\t")
+			summary.consume(sourceLine{Number: locator.find(fields[0]), Text: text}, result)
+		}
+	}
+	summary.flush(result)
+	result.Finish()
+
+	return result, nil
+}
+
+// htmlSubjectBuilder es el acumulador de una materia junto con la celda donde está su nombre
+type htmlSubjectBuilder struct {
+	subjectBuilder
+	nameCell int
+}
+
+// subjectFromRow inicia una materia si alguna celda de la fila tiene el formato "Nombre (CÓDIGO)"
+func subjectFromRow(row htmlRow, locator *lineLocator) *htmlSubjectBuilder {
+	for i, cell := range row.cells {
+		match := subjectHeaderRe.FindStringSubmatch(strings.Join(cell, " "))
+		if match == nil {
+			continue
+		}
+		b := &htmlSubjectBuilder{nameCell: i}
+		b.subject.Name = strings.TrimSpace(match[1])
+		b.subject.Code = strings.TrimSpace(match[2])
+		b.subject.Line = locator.find("(" + b.subject.Code + ")")
+		return b
+	}
+	return nil
+}
+
+// cellFields convierte una celda en los campos que consume subjectBuilder. El periodo puede
+// venir separado de su modalidad en dos bloques ("2021-2S" / "Ordinaria"), por lo que se unen.
+func cellFields(cell []string) []string {
+	var fields []string
+	for _, text := range cell {
+		if n := len(fields); n > 0 && periodLineRe.MatchString(fields[n-1]) &&
+			!statusLineRe.MatchString(strings.ToUpper(text)) &&
+			!gradeLineRe.MatchString(text) && !gradeLabelRe.MatchString(text) {
+			fields[n-1] += " " + text
+			continue
+		}
+		fields = append(fields, text)
+	}
+	return fields
+}
+
+// isSummaryRow indica si la fila corresponde al resumen de créditos: una tipología y cinco valores
+func isSummaryRow(row htmlRow) bool {
+	if len(row.cells) != summaryColumns+1 || summaryNumberRe.MatchString(strings.Join(row.cells[0], " ")) {
+		return false
+	}
+	for _, cell := range row.cells[1:] {
+		if !summaryNumberRe.MatchString(strings.Join(cell, " ")) {
+			return false
+		}
+	}
+	return true
+}
+
+// htmlRows recorre el documento y retorna las filas de todas las tablas en orden de aparición.
+// Las tablas anidadas (usadas para maquetar) se recorren aparte y no se mezclan con la fila que las contiene.
+func htmlRows(doc *html.Node) []htmlRow {
+	var rows []htmlRow
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && htmlSkipElements[n.DataAtom] {
+			return
+		}
+		if n.Type == html.ElementNode && n.DataAtom == atom.Tr {
+			var row htmlRow
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && (c.DataAtom == atom.Td || c.DataAtom == atom.Th) {
+					row.cells = append(row.cells, cellLines(c))
+				}
+			}
+			if len(row.cells) > 0 {
+				rows = append(rows, row)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return rows
+}
+
+// cellLines obtiene las líneas de texto de una celda, sin incluir tablas anidadas
+func cellLines(cell *html.Node) []string {
+	w := &htmlTextWriter{}
+	for c := cell.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Table {
+			continue
+		}
+		w.render(c)
+	}
+	return w.lines()
+}
+
+// htmlText convierte el documento a texto: cada bloque en su propia línea y las celdas de una fila separadas por tabulación
+func htmlText(doc *html.Node) string {
+	w := &htmlTextWriter{}
+	w.render(doc)
+	return strings.Join(w.lines(), "\n")
+}
+
+// htmlTextWriter acumula texto visible respetando los saltos de los elementos de bloque
+type htmlTextWriter struct {
+	b     strings.Builder
+	space bool // Hay un espacio pendiente entre dos fragmentos de texto en línea
+}
+
+func (w *htmlTextWriter) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		text := strings.Join(strings.Fields(n.Data), " ")
+		if text == "" {
+			w.space = w.space || n.Data != ""
+			return
+		}
+		if (w.space || unicode.IsSpace(firstRune(n.Data))) && !w.atBreak() {
+			w.b.WriteString(" ")
+		}
+		w.b.WriteString(text)
+		w.space = unicode.IsSpace(lastRune(n.Data))
+		return
+	case html.ElementNode:
+		if htmlSkipElements[n.DataAtom] {
+			return
+		}
+		if n.DataAtom == atom.Td || n.DataAtom == atom.Th {
+			w.separate("\t")
+		}
+	}
+
+	block := n.Type == html.ElementNode && htmlBlockElements[n.DataAtom]
+	if block {
+		w.separate("\n")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.render(c)
+	}
+	if block {
+		w.separate("\n")
+	}
+}
+
+// separate escribe un salto de línea o una tabulación y descarta el espacio pendiente
+func (w *htmlTextWriter) separate(sep string) {
+	w.b.WriteString(sep)
+	w.space = false
+}
+
+// atBreak indica si lo último escrito fue un separador (o nada)
+func (w *htmlTextWriter) atBreak() bool {
+	s := w.b.String()
+	return s == "" || s[len(s)-1] == '\n' || s[len(s)-1] == '\t'
+}
+
+// lines retorna las líneas no vacías, sin espacios ni tabulaciones en los extremos
+func (w *htmlTextWriter) lines() []string {
+	var lines []string
+	for _, line := range strings.Split(w.b.String(), "\n") {
+		if line = strings.Trim(line, " \t"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+// lineLocator ubica en el HTML original la línea donde aparece cada fila para reportar advertencias
+type lineLocator struct {
+	raw  string
+	pos  int
+	line int
+}
+
+// find avanza hasta la siguiente aparición del texto y retorna su número de línea.
+// Si el texto no se encuentra (por ejemplo por entidades HTML) se conserva la última línea conocida.
+func (l *lineLocator) find(text string) int {
+	idx := strings.Index(l.raw[l.pos:], text)
+	if idx < 0 {
+		return l.line
+	}
+	l.line += strings.Count(l.raw[l.pos:l.pos+idx], "\n")
+	l.pos += idx
+	return l.line
+}
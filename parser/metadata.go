@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Metadata representa los datos del encabezado de la historia académica del SIA
+type Metadata struct {
+	PlanName           string   `json:"plan_name"`
+	Faculty            string   `json:"faculty"`
+	HistoryNumber      string   `json:"history_number"`
+	Status             string   `json:"status"`
+	Blocked            bool     `json:"blocked"`
+	BlockCauses        []string `json:"block_causes"`
+	PAPA               float64  `json:"papa"`
+	PAPAPeriod         string   `json:"papa_period"`
+	Promedio           float64  `json:"promedio"`
+	PromedioPeriod     string   `json:"promedio_period"`
+	ProgressPercentage float64  `json:"progress_percentage"`
+}
+
+var (
+	planHeaderRe    = regexp.MustCompile(`(?i)^plan de estudios$`)
+	facultyRe       = regexp.MustCompile(`Facultad:\s*(.+?)\s*(?:Hist\. Acad\.|ESTADO|Causas de bloqueo|$)`)
+	historyNumberRe = regexp.MustCompile(`Hist\. Acad\.:\s*(\d+)`)
+	statusRe        = regexp.MustCompile(`ESTADO\s*([A-ZÁÉÍÓÚÑ]+?)\s*(?:Causas de bloqueo|$)`)
+	blockCausesRe   = regexp.MustCompile(`Causas de bloqueo:\s*(.+)$`)
+	blockCauseRe    = regexp.MustCompile(`[A-Z]\s*-\s*\d+\s`)
+	averageRe       = regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*\([^)]*\)\s*(?:Pregrado|Posgrado)?\s*-\s*(P\.A\.P\.A|Promedio académico)\s*(\d{4}-\d{1,2}S?)`)
+	progressRe      = regexp.MustCompile(`Porcentaje de Avance\s*(\d+(?:[.,]\d+)?)\s*%`)
+)
+
+// parseMetadata extrae los datos del encabezado (plan, facultad, bloqueos, promedios)
+// y el porcentaje de avance del texto de la historia académica
+func parseMetadata(raw string) Metadata {
+	meta := Metadata{BlockCauses: []string{}}
+
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	expectPlan := false
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if expectPlan {
+			meta.PlanName = line
+			expectPlan = false
+			continue
+		}
+		if planHeaderRe.MatchString(line) && meta.PlanName == "" {
+			expectPlan = true
+			continue
+		}
+
+		if match := facultyRe.FindStringSubmatch(line); match != nil && meta.Faculty == "" {
+			meta.Faculty = match[1]
+		}
+		if match := historyNumberRe.FindStringSubmatch(line); match != nil && meta.HistoryNumber == "" {
+			meta.HistoryNumber = match[1]
+		}
+		if match := statusRe.FindStringSubmatch(line); match != nil && meta.Status == "" {
+			meta.Status = match[1]
+			meta.Blocked = match[1] == "BLOQUEADO"
+		}
+		if match := blockCausesRe.FindStringSubmatch(line); match != nil {
+			meta.BlockCauses = append(meta.BlockCauses, splitBlockCauses(match[1])...)
+		}
+		if match := averageRe.FindStringSubmatch(line); match != nil {
+			value := parseDecimal(match[1])
+			if match[2] == "P.A.P.A" {
+				meta.PAPA, meta.PAPAPeriod = value, match[3]
+			} else {
+				meta.Promedio, meta.PromedioPeriod = value, match[3]
+			}
+		}
+		if match := progressRe.FindStringSubmatch(line); match != nil {
+			meta.ProgressPercentage = parseDecimal(match[1])
+		}
+	}
+
+	return meta
+}
+
+// splitBlockCauses separa las causas de bloqueo, que el SIA encadena con el formato "B - 41 Descripción"
+func splitBlockCauses(text string) []string {
+	starts := blockCauseRe.FindAllStringIndex(text, -1)
+	if len(starts) == 0 {
+		return []string{strings.TrimSpace(text)}
+	}
+
+	causes := make([]string, 0, len(starts))
+	for i, start := range starts {
+		end := len(text)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		if cause := strings.TrimSpace(text[start[0]:end]); cause != "" {
+			causes = append(causes, cause)
+		}
+	}
+	return causes
+}
+
+// parseDecimal convierte un número que puede venir con coma decimal ("21,1")
+func parseDecimal(text string) float64 {
+	value, _ := strconv.ParseFloat(strings.ReplaceAll(text, ",", "."), 64)
+	return value
+}
@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Correcciones que reporta Normalize
+const (
+	FixMojibake     = "mojibake"      // Texto UTF-8 que se leyó como Windows-1252 ("ELECCIÃ“N")
+	FixNFC          = "nfc"           // Tildes descompuestas en letra + marca combinante
+	FixWhitespace   = "whitespace"    // Espacios no separables, de ancho fijo o invisibles
+	FixDecimalComma = "decimal_comma" // Calificaciones con coma decimal ("4,6")
+)
+
+// whitespaceReplacer convierte los espacios especiales en espacios simples y elimina los caracteres invisibles
+var whitespaceReplacer = strings.NewReplacer(
+	"\u00a0", " ", "\u2007", " ", "\u202f", " ", "\u3000", " ",
+	"\u2000", " ", "\u2001", " ", "\u2002", " ", "\u2003", " ", "\u2004", " ",
+	"\u2005", " ", "\u2006", " ", "\u2008", " ", "\u2009", " ", "\u200a", " ",
+	"\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "", "\u00ad", "",
+)
+
+// Normalize prepara el texto pegado o extraído de la historia académica antes de parsearlo:
+// repara mojibake, compone las tildes (NFC), limpia espacios especiales y convierte la coma
+// decimal de las calificaciones en punto. Conserva el número de líneas para que las advertencias
+// sigan apuntando a la línea original. Retorna el texto y las correcciones aplicadas.
+func Normalize(text string) (string, []string) {
+	fixes := []string{}
+	apply := func(fix, normalized string) {
+		if normalized != text {
+			fixes = append(fixes, fix)
+			text = normalized
+		}
+	}
+
+	apply(FixMojibake, repairMojibake(text))
+	apply(FixNFC, norm.NFC.String(text))
+	apply(FixWhitespace, whitespaceReplacer.Replace(text))
+	apply(FixDecimalComma, replaceDecimalCommas(text))
+
+	return cleanSpaces(text), fixes
+}
+
+// repairMojibake revierte el texto UTF-8 decodificado como Windows-1252. Cada secuencia de
+// caracteres no ASCII se vuelve a convertir en bytes y, si estos forman UTF-8 válido, se
+// reemplaza por el texto decodificado ("Ã“" -> "Ó"). Las tildes legítimas ("Á", "é") no
+// forman UTF-8 válido por sí solas y se conservan.
+func repairMojibake(text string) string {
+	var b strings.Builder
+	var run []rune
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		b.WriteString(decodeMojibakeRun(run))
+		run = run[:0]
+	}
+
+	for _, r := range text {
+		if r >= utf8.RuneSelf {
+			run = append(run, r)
+			continue
+		}
+		flush()
+		b.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
+
+// decodeMojibakeRun decodifica una secuencia de caracteres no ASCII si corresponde a mojibake
+func decodeMojibakeRun(run []rune) string {
+	original := string(run)
+	if len(run) < 2 {
+		return original
+	}
+	raw := make([]byte, 0, len(run))
+	for _, r := range run {
+		c, ok := charmap.Windows1252.EncodeRune(r)
+		switch {
+		case ok:
+			raw = append(raw, c)
+		case r >= 0x80 && r <= 0x9f:
+			// Los bytes sin carácter en Windows-1252 (0x81, 0x8D, ...) quedan como controles C1
+			raw = append(raw, byte(r))
+		default:
+			return original
+		}
+	}
+	if !utf8.Valid(raw) {
+		return original
+	}
+	return string(raw)
+}
+
+// replaceDecimalCommas convierte "4,6" en "4.6" cuando la coma separa una calificación (0 a 5
+// con uno o dos decimales) que no hace parte de un número más largo ni de una lista de valores
+func replaceDecimalCommas(text string) string {
+	b := []byte(text)
+	for i := 1; i+1 < len(b); i++ {
+		if b[i] != ',' || b[i-1] < '0' || b[i-1] > '5' || !isDigit(b[i+1]) {
+			continue
+		}
+		if i >= 2 && (isDigit(b[i-2]) || b[i-2] == ',' || b[i-2] == '.') {
+			continue
+		}
+		end := i + 2
+		if end < len(b) && isDigit(b[end]) {
+			end++
+		}
+		if end < len(b) && (isDigit(b[end]) || b[end] == ',') {
+			continue
+		}
+		b[i] = '.'
+	}
+	return string(b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// cleanSpaces normaliza los saltos de línea, une los espacios repetidos y quita los espacios
+// al final de cada línea. Las tabulaciones se conservan porque separan columnas.
+func cleanSpaces(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		for strings.Contains(line, "  ") {
+			line = strings.ReplaceAll(line, "  ", " ")
+		}
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,155 @@
+// Package parser convierte el texto de la historia académica del SIA en materias
+// estructuradas, acompañadas de advertencias ligadas a la línea de origen.
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"olimpo-vicedecanatura/models"
+)
+
+// Códigos de advertencia generados durante el parseo
+const (
+	WarnUnrecognizedLine = "unrecognized_line"
+	WarnMissingCredits   = "missing_credits"
+	WarnMissingType      = "missing_type"
+	WarnMissingPeriod    = "missing_period"
+	WarnGradeOutOfRange  = "grade_out_of_range"
+	WarnMissingStatus    = "missing_status"
+	WarnUnknownModality  = "unknown_modality"
+	WarnUnknownTipologia = "unknown_tipologia"
+	WarnNoSubjects       = "no_subjects"
+	WarnMultiplePlans    = "multiple_plans"
+)
+
+// Rango válido de calificaciones en la universidad
+const (
+	MinGrade = 0.0
+	MaxGrade = 5.0
+)
+
+// Warning representa un problema encontrado en una línea del texto de entrada
+type Warning struct {
+	Line    int    `json:"line"`              // Línea (1-based) del texto original; 0 si aplica a todo el texto
+	Code    string `json:"code"`              // Código estable de la advertencia
+	Message string `json:"message"`           // Descripción legible para el asesor
+	Text    string `json:"text,omitempty"`    // Contenido de la línea que generó la advertencia
+	Subject string `json:"subject,omitempty"` // Código de la materia afectada, si aplica
+}
+
+// Subject representa una materia extraída del texto junto con la línea donde empieza
+type Subject struct {
+	models.SubjectInput
+	Line       int      `json:"line"`
+	Confidence float64  `json:"confidence"`       // Entre 0 y 1: qué tan seguro es el parseo de la materia
+	Issues     []string `json:"issues,omitempty"` // Códigos de advertencia y campos deducidos que bajan la confianza
+}
+
+// Result agrupa los datos del encabezado, las materias parseadas, el resumen de créditos
+// reportado por el SIA y las advertencias encontradas
+type Result struct {
+	Format         string                   `json:"format"` // Formato con el que se parseó la historia (ver Formats)
+	Metadata       Metadata                 `json:"metadata"`
+	Subjects       []Subject                `json:"subjects"`
+	CreditSummary  []models.ResumenCreditos `json:"credit_summary"`
+	Warnings       []Warning                `json:"warnings"`
+	Normalizations []string                 `json:"normalizations"`  // Correcciones aplicadas al texto antes de parsearlo (ver Normalize)
+	Plans          []*Result                `json:"plans,omitempty"` // Historia de cada plan cuando la página incluye varios "Plan de estudios"
+
+	inferred map[string][]string // Campos deducidos (no leídos del texto) por código de materia
+}
+
+// Parse procesa una historia académica en cualquiera de los formatos registrados (texto copiado
+// del portal del SIA, HTML de la página "Historia Académica", texto tabulado, ...). El texto se
+// normaliza y se parsea con el formato que mejor lo reconoce (ver Detect); Format indica cuál se usó.
+func Parse(text string) (*Result, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("la historia académica está vacía")
+	}
+	text, fixes := Normalize(text)
+
+	historyParser, _ := Detect(text)
+	result, err := historyParser.Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	result.Format = historyParser.Format()
+	for _, plan := range result.Plans {
+		plan.Format = result.Format
+	}
+	result.Normalizations = fixes
+	return result, nil
+}
+
+// SubjectInputs convierte las materias parseadas al DTO usado por los motores de comparación
+func (r *Result) SubjectInputs() []models.SubjectInput {
+	subjects := make([]models.SubjectInput, 0, len(r.Subjects))
+	for _, s := range r.Subjects {
+		subjects = append(subjects, s.SubjectInput)
+	}
+	return subjects
+}
+
+// NewResult crea un resultado vacío con slices inicializados para que el JSON no tenga null
+func NewResult() *Result {
+	return &Result{
+		Metadata:       Metadata{BlockCauses: []string{}},
+		Subjects:       []Subject{},
+		CreditSummary:  []models.ResumenCreditos{},
+		Warnings:       []Warning{},
+		Normalizations: []string{},
+	}
+}
+
+// warn agrega una advertencia al resultado
+func (r *Result) warn(line int, code, text, subject, format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, Warning{
+		Line:    line,
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Text:    text,
+		Subject: subject,
+	})
+}
+
+// Warn agrega una advertencia al resultado; la usan los parsers de otros paquetes
+func (r *Result) Warn(line int, code, text, subject, message string) {
+	r.warn(line, code, text, subject, "%s", message)
+}
+
+// AddSubject agrega una materia que empieza en la línea line; la usan los parsers de otros paquetes
+func (r *Result) AddSubject(line int, subject models.SubjectInput) {
+	r.Subjects = append(r.Subjects, Subject{SubjectInput: subject, Line: line})
+}
+
+// mergeAttempts consolida en una sola materia los intentos repetidos de un mismo código,
+// conservando la línea donde aparece la materia por primera vez
+func (r *Result) mergeAttempts() {
+	firstLine := make(map[string]int)
+	for _, s := range r.Subjects {
+		if _, exists := firstLine[s.Code]; !exists {
+			firstLine[s.Code] = s.Line
+		}
+	}
+
+	merged := models.MergeAttempts(r.SubjectInputs())
+	r.Subjects = make([]Subject, 0, len(merged))
+	for _, s := range merged {
+		r.Subjects = append(r.Subjects, Subject{SubjectInput: s, Line: firstLine[s.Code]})
+	}
+}
+
+// Finish consolida los intentos, valida que haya materias y calcula la confianza de cada una
+func (r *Result) Finish() {
+	r.mergeAttempts()
+	r.checkEmpty()
+	r.scoreConfidence()
+}
+
+// checkEmpty agrega una advertencia global cuando no se encontró ninguna materia
+func (r *Result) checkEmpty() {
+	if len(r.Subjects) == 0 {
+		r.warn(0, WarnNoSubjects, "", "", "no se encontró ninguna materia en la historia académica")
+	}
+}
@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// planSectionTextRe reconoce la línea "Plan de estudios" con la que empieza cada plan en el texto del portal
+	planSectionTextRe = regexp.MustCompile(`(?im)^[ \t]*plan de estudios[ \t]*$`)
+	// planSectionHTMLRe reconoce el elemento cuyo texto es "Plan de estudios" en el HTML de la página
+	planSectionHTMLRe = regexp.MustCompile(`(?i)<[a-z][^<>]*>\s*plan de estudios\s*<`)
+)
+
+// parseByPlan divide la historia en una sección por cada "Plan de estudios" (estudiantes que cambiaron
+// de carrera) y parsea cada sección por separado; la historia de cada plan queda en Plans con sus propios
+// datos de encabezado. Con un solo plan el texto se parsea completo, como siempre.
+func parseByPlan(text string, sectionRe *regexp.Regexp, parse func(string) (*Result, error)) (*Result, error) {
+	starts := sectionRe.FindAllStringIndex(text, -1)
+	if len(starts) < 2 {
+		return parse(text)
+	}
+
+	// La primera sección incluye lo que está antes del primer plan (menú del portal, datos personales)
+	bounds := []int{0}
+	for _, start := range starts[1:] {
+		bounds = append(bounds, start[0])
+	}
+	bounds = append(bounds, len(text))
+
+	plans := make([]*Result, 0, len(starts))
+	for i := 0; i+1 < len(bounds); i++ {
+		// Las líneas anteriores se reemplazan por líneas vacías para que las advertencias
+		// conserven la numeración del texto completo
+		section := strings.Repeat("\n", strings.Count(text[:bounds[i]], "\n")) + text[bounds[i]:bounds[i+1]]
+		plan, err := parse(section)
+		if err != nil {
+			return nil, fmt.Errorf("plan %d: %v", i+1, err)
+		}
+		plans = append(plans, plan)
+	}
+	return mergePlans(plans), nil
+}
+
+// mergePlans reúne las historias de varios planes. Las materias, el resumen de créditos y el encabezado
+// de cada plan solo están en Plans: mezclarlos daría una historia que no corresponde a ningún plan,
+// así que el resultado solo reúne las advertencias y pide elegir un plan con SelectPlan.
+func mergePlans(plans []*Result) *Result {
+	merged := NewResult()
+	for _, plan := range plans {
+		merged.Warnings = append(merged.Warnings, plan.Warnings...)
+	}
+	merged.Plans = plans
+	merged.warn(0, WarnMultiplePlans, "", "", "la historia académica tiene %d planes de estudio (%s): elija uno",
+		len(plans), strings.Join(merged.planList(), ", "))
+	return merged
+}
+
+// SelectPlan retorna la historia de uno de los planes. El plan se indica por su posición (desde 1)
+// o por su nombre. Sin selector se retorna la historia completa si tiene un solo plan; con varios
+// planes es un error, para no comparar una historia que mezcla planes.
+func (r *Result) SelectPlan(selector string) (*Result, error) {
+	selector = strings.TrimSpace(selector)
+	plans := r.Plans
+	if len(plans) == 0 {
+		plans = []*Result{r}
+	}
+	if selector == "" {
+		if len(plans) > 1 {
+			return nil, fmt.Errorf("la historia académica tiene %d planes de estudio, indique cuál usar; planes disponibles: %s", len(plans), strings.Join(r.planList(), ", "))
+		}
+		return plans[0], nil
+	}
+
+	if index, err := strconv.Atoi(selector); err == nil {
+		if index < 1 || index > len(plans) {
+			return nil, fmt.Errorf("el plan %d no existe: la historia académica tiene %d plan(es)", index, len(plans))
+		}
+		return plans[index-1], nil
+	}
+	for _, plan := range plans {
+		if strings.EqualFold(strings.TrimSpace(plan.Metadata.PlanName), selector) {
+			return plan, nil
+		}
+	}
+
+	return nil, fmt.Errorf("el plan %q no existe en la historia académica; planes disponibles: %s", selector, strings.Join(r.planList(), ", "))
+}
+
+// planList numera los planes de la historia como "1. NOMBRE" para los mensajes
+func (r *Result) planList() []string {
+	names := r.PlanNames()
+	for i, name := range names {
+		names[i] = fmt.Sprintf("%d. %s", i+1, name)
+	}
+	return names
+}
+
+// PlanNames retorna los nombres de los planes de la historia en orden; con un solo plan
+// retorna el del encabezado
+func (r *Result) PlanNames() []string {
+	if len(r.Plans) == 0 {
+		return []string{r.Metadata.PlanName}
+	}
+	names := make([]string, len(r.Plans))
+	for i, plan := range r.Plans {
+		names[i] = plan.Metadata.PlanName
+	}
+	return names
+}
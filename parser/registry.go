@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Formatos de historia académica incorporados
+const (
+	FormatSIAHTML    = "sia_html"    // HTML guardado de la página "Historia Académica"
+	FormatSIAText    = "sia_text"    // Texto copiado del portal o extraído del PDF oficial
+	FormatSIATabular = "sia_tabular" // Una materia por línea con columnas separadas por tabulaciones
+)
+
+// HistoryParser es un formato de historia académica. Para soportar historias de otras instituciones
+// basta con implementar la interfaz y registrarla con Register; Parse la usa cuando es el formato
+// que mejor reconoce el texto.
+type HistoryParser interface {
+	// Format es el identificador del formato que se reporta en la respuesta
+	Format() string
+	// Detect indica qué tan probable es que el texto esté en este formato: 0 si no lo reconoce, 1 si es seguro
+	Detect(text string) float64
+	// Parse procesa el texto, ya normalizado por Normalize
+	Parse(text string) (*Result, error)
+}
+
+// historyParsers guarda los formatos registrados en orden; ante un empate en Detect gana el primero
+var historyParsers = struct {
+	mu      sync.RWMutex
+	parsers []HistoryParser
+}{parsers: []HistoryParser{siaHTMLParser{}, siaTextParser{}, siaTabularParser{}}}
+
+// Register agrega un formato de historia académica; si ya hay uno con el mismo identificador lo reemplaza
+func Register(p HistoryParser) {
+	historyParsers.mu.Lock()
+	defer historyParsers.mu.Unlock()
+	for i, registered := range historyParsers.parsers {
+		if registered.Format() == p.Format() {
+			historyParsers.parsers[i] = p
+			return
+		}
+	}
+	historyParsers.parsers = append(historyParsers.parsers, p)
+}
+
+// Formats lista los identificadores de los formatos registrados
+func Formats() []string {
+	historyParsers.mu.RLock()
+	defer historyParsers.mu.RUnlock()
+	formats := make([]string, len(historyParsers.parsers))
+	for i, p := range historyParsers.parsers {
+		formats[i] = p.Format()
+	}
+	return formats
+}
+
+// Detect retorna el formato que mejor reconoce el texto y su puntaje. Si ninguno lo reconoce
+// se usa el texto del SIA, que reporta las líneas no reconocidas como advertencias.
+func Detect(text string) (HistoryParser, float64) {
+	historyParsers.mu.RLock()
+	defer historyParsers.mu.RUnlock()
+
+	var best HistoryParser = siaTextParser{}
+	bestScore := 0.0
+	for _, p := range historyParsers.parsers {
+		if score := p.Detect(text); score > bestScore {
+			best, bestScore = p, score
+		}
+	}
+	return best, bestScore
+}
+
+var (
+	// siaPortalRe reconoce los títulos de la página del SIA que no aparecen en una tabla suelta
+	siaPortalRe = regexp.MustCompile(`(?im)^[ \t]*(plan de estudios|resumen de cr[ée]ditos)[ \t]*$|P\.A\.P\.A`)
+	// siaCodeRe reconoce un código de materia del SIA entre paréntesis
+	siaCodeRe = regexp.MustCompile(`\(\d{6,}(-[A-Za-z0-9]+)?\)`)
+)
+
+// siaHTMLParser procesa el HTML de la página "Historia Académica" (ver ParseHTML)
+type siaHTMLParser struct{}
+
+func (siaHTMLParser) Format() string { return FormatSIAHTML }
+
+func (siaHTMLParser) Detect(text string) float64 {
+	if LooksLikeHTML(text) {
+		return 1
+	}
+	return 0
+}
+
+func (siaHTMLParser) Parse(text string) (*Result, error) {
+	return parseByPlan(text, planSectionHTMLRe, ParseHTML)
+}
+
+// siaTextParser procesa el texto copiado del portal o extraído del PDF (ver ParseText)
+type siaTextParser struct{}
+
+func (siaTextParser) Format() string { return FormatSIAText }
+
+func (siaTextParser) Detect(text string) float64 {
+	switch {
+	case siaPortalRe.MatchString(text):
+		return 0.9
+	case siaCodeRe.MatchString(text):
+		return 0.4
+	default:
+		return 0.1
+	}
+}
+
+func (siaTextParser) Parse(text string) (*Result, error) {
+	return parseByPlan(text, planSectionTextRe, func(section string) (*Result, error) {
+		return ParseText(section), nil
+	})
+}
+
+// siaTabularParser procesa las materias en líneas tabuladas (ver ParseTabular)
+type siaTabularParser struct{}
+
+func (siaTabularParser) Format() string { return FormatSIATabular }
+
+// Detect mide la proporción de líneas con el formato "Nombre (CÓDIGO)\tCréditos\t...". El texto del
+// PDF también tiene materias tabuladas, pero trae los títulos del portal y lo procesa mejor sia_text.
+func (siaTabularParser) Detect(text string) float64 {
+	rows, lines := 0, 0
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines++
+		if cells := strings.Split(strings.TrimSpace(line), "\t"); len(cells) >= 5 && tabularNameRe.MatchString(cells[0]) {
+			rows++
+		}
+	}
+	if rows == 0 {
+		return 0
+	}
+	return 0.5 + 0.4*float64(rows)/float64(lines)
+}
+
+func (siaTabularParser) Parse(text string) (*Result, error) {
+	return parseByPlan(text, planSectionTextRe, func(section string) (*Result, error) {
+		return parseTabularSection(section), nil
+	})
+}
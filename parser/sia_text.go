@@ -0,0 +1,287 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"olimpo-vicedecanatura/models"
+)
+
+// sourceLine es una línea ya separada junto con el número de línea del texto original
+type sourceLine struct {
+	Number int
+	Text   string
+}
+
+var (
+	// Reglas para reconstruir los saltos de línea que se pierden al copiar desde el portal
+	splitSubjectRe  = regexp.MustCompile(`([A-Za-zÁÉÍÓÚÑáéíóúüÜ][A-Za-zÁÉÍÓÚÑáéíóúüÜ0-9\- ]*\([0-9A-Z\-]+\))`)
+	splitCreditsFRe = regexp.MustCompile(`([A-Za-zÁÉÍÓÚÑáéíóúüÜ)]+)(\d{1,2})F`)
+	splitCreditsRe  = regexp.MustCompile(`([A-Za-zÁÉÍÓÚÑáéíóúüÜ)]+)(\d{1,2})($|[^\d.,])`)
+	splitTypeRe     = regexp.MustCompile(`(\d{1,2})((FUND\. OBLIGATORIA|FUND\. OPTATIVA|DISCIPLINAR OBLIGATORIA|DISCIPLINAR OPTATIVA|LIBRE ELECCIÓN|NIVELACIÓN|TRABAJO DE GRADO))`)
+	splitPeriodRe   = regexp.MustCompile(`(OBLIGATORIA|OPTATIVA|ELECCIÓN|NIVELACIÓN|GRADO)(\d{4}-\d{1,2}S|\d{4}-\d{1,2})`)
+	splitGradeRe    = regexp.MustCompile(`(\d{4}-\d{1,2}S|\d{4}-\d{1,2})( [A-Za-zÁÉÍÓÚÑáéíóúñ ]+)?([0-9]\.[0-9])`)
+	splitModalityRe = regexp.MustCompile(`([a-záéíóúñ])(APROBADA|REPROBADA|CANCELADA|INSCRITA|AP|NA)$`)
+	splitStatusRe   = regexp.MustCompile(`([0-9]\.[0-9]|\bAP|\bNA)((APROBADA|APROBAD|REPROBADA|CANCELADA|INSCRITA))`)
+	splitNextRe     = regexp.MustCompile(`^(APROBADA|REPROBADA|CANCELADA|INSCRITA)([A-Za-zÁÉÍÓÚÑáéíóúüÜ])`)
+
+	// Patrones para clasificar cada línea de una materia
+	subjectHeaderRe  = regexp.MustCompile(`^(.+?)\s*\((\d{4,}[0-9A-Za-z\-]*)\)\s*$`)
+	creditsLineRe    = regexp.MustCompile(`^(\d{1,2})$`)
+	periodLineRe     = regexp.MustCompile(`^(\d{4}-\d{1,2}S?)\b\s*(.*)$`)
+	gradeLineRe      = regexp.MustCompile(`^(\d+(?:\.\d+)?)$`)
+	gradeLabelRe     = regexp.MustCompile(`^(AP|NA)$`)
+	statusLineRe     = regexp.MustCompile(`^(APROBADA|APROBAD|REPROBADA|NO APROBADA|CANCELADA|INSCRITA|EN CURSO)$`)
+	tipologiaLabelRe = regexp.MustCompile(`^[\p{L}][\p{L}.\- ]*$`)
+	summaryStartRe   = regexp.MustCompile(`(?i)^resumen de cr[ée]ditos`)
+)
+
+// splitSourceLines normaliza los saltos de línea y separa los campos que el portal
+// pega en una misma línea, conservando el número de línea original de cada fragmento
+func splitSourceLines(raw string) []sourceLine {
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	raw = strings.ReplaceAll(raw, "\r", "\n")
+
+	var lines []sourceLine
+	for i, row := range strings.Split(raw, "\n") {
+		// Las celdas separadas por tabulaciones (tablas copiadas o texto extraído de un PDF)
+		// se tratan como líneas independientes con el mismo número de línea
+		for _, original := range strings.Split(row, "\t") {
+			lines = append(lines, splitSourceLine(i+1, original)...)
+		}
+	}
+	return lines
+}
+
+// splitSourceLine separa los campos pegados de un fragmento de la línea number
+func splitSourceLine(number int, original string) []sourceLine {
+	var lines []sourceLine
+	cleaned := original
+	if !subjectHeaderRe.MatchString(strings.TrimSpace(original)) {
+		// Solo se separa el encabezado de la materia si viene pegado a otro texto
+		cleaned = splitSubjectRe.ReplaceAllString(cleaned, "\n$1")
+	}
+	cleaned = splitCreditsFRe.ReplaceAllString(cleaned, "${1}\n${2}F")
+	cleaned = splitCreditsRe.ReplaceAllString(cleaned, "$1\n$2$3")
+	cleaned = splitTypeRe.ReplaceAllString(cleaned, "$1\n$2")
+	cleaned = splitPeriodRe.ReplaceAllString(cleaned, "$1\n$2")
+	cleaned = splitGradeRe.ReplaceAllString(cleaned, "$1$2\n$3")
+	cleaned = splitModalityRe.ReplaceAllString(cleaned, "$1\n$2")
+	cleaned = splitStatusRe.ReplaceAllString(cleaned, "$1\n$2")
+
+	for _, fragment := range strings.Split(cleaned, "\n") {
+		// El estado de la materia anterior puede quedar pegado al nombre de la siguiente
+		fragment = splitNextRe.ReplaceAllString(strings.TrimSpace(fragment), "$1\n$2")
+		for _, text := range strings.Split(fragment, "\n") {
+			if text == "" {
+				continue
+			}
+			lines = append(lines, sourceLine{Number: number, Text: text})
+		}
+	}
+	return lines
+}
+
+// subjectBuilder acumula los campos de una materia mientras se recorren sus líneas
+type subjectBuilder struct {
+	subject    Subject
+	hasCredits bool
+	hasType    bool
+	hasPeriod  bool
+	hasGrade   bool
+	validGrade bool
+	hasStatus  bool
+}
+
+// ParseText procesa la historia académica copiada desde el portal del SIA.
+// Cada materia empieza con una línea "Nombre (CÓDIGO)" seguida de créditos,
+// tipología, periodo, calificación y estado.
+func ParseText(raw string) *Result {
+	result := NewResult()
+	result.Metadata = parseMetadata(raw)
+
+	var current *subjectBuilder
+	var summary *summaryBuilder
+	inSubjects := false
+
+	for _, line := range splitSourceLines(raw) {
+		if summaryStartRe.MatchString(line.Text) {
+			// El resumen de créditos cierra la sección de asignaturas
+			result.finishSubject(current)
+			current = nil
+			inSubjects = false
+			summary = &summaryBuilder{}
+			continue
+		}
+		if summary != nil {
+			summary.consume(line, result)
+			continue
+		}
+
+		if match := subjectHeaderRe.FindStringSubmatch(line.Text); match != nil {
+			result.finishSubject(current)
+			current = &subjectBuilder{subject: Subject{
+				SubjectInput: models.SubjectInput{
+					Name: strings.TrimSpace(match[1]),
+					Code: strings.TrimSpace(match[2]),
+				},
+				Line: line.Number,
+			}}
+			inSubjects = true
+			continue
+		}
+
+		if !inSubjects {
+			// Encabezados del portal, datos personales, etc.
+			continue
+		}
+
+		if current == nil || !current.consume(line, result) {
+			code := ""
+			if current != nil {
+				code = current.subject.Code
+			}
+			result.warn(line.Number, WarnUnrecognizedLine, line.Text, code, "línea no reconocida")
+		}
+	}
+	result.finishSubject(current)
+	if summary != nil {
+		summary.flush(result)
+	}
+	result.Finish()
+
+	return result
+}
+
+// consume intenta asignar la línea a alguno de los campos pendientes de la materia
+func (b *subjectBuilder) consume(line sourceLine, result *Result) bool {
+	text := line.Text
+
+	if !b.hasCredits {
+		if match := creditsLineRe.FindStringSubmatch(text); match != nil {
+			credits, _ := strconv.Atoi(match[1])
+			b.subject.Credits = credits
+			b.hasCredits = true
+			return true
+		}
+	}
+
+	if !b.hasType {
+		if tipo, ok := LookupTipologia(text); ok {
+			b.subject.Type = tipo
+			b.hasType = true
+			return true
+		}
+		// Después de los créditos el SIA muestra la tipología: una etiqueta sin sinónimo se reporta
+		// en lugar de suponer una tipología
+		if b.hasCredits && !b.hasPeriod && tipologiaLabelRe.MatchString(text) &&
+			!statusLineRe.MatchString(strings.ToUpper(text)) && !gradeLabelRe.MatchString(text) {
+			result.warnUnknownTipologia(line.Number, text, b.subject.Code)
+			b.hasType = true
+			return true
+		}
+	}
+
+	if !b.hasPeriod {
+		if match := periodLineRe.FindStringSubmatch(text); match != nil {
+			b.subject.Semester = match[1]
+			b.subject.Modality = parseModality(match[2], line.Number, b.subject.Code, result)
+			b.hasPeriod = true
+			return true
+		}
+	}
+
+	if !b.hasGrade {
+		if match := gradeLabelRe.FindStringSubmatch(text); match != nil {
+			b.subject.GradeLabel = match[1]
+			b.hasGrade = true
+			b.validGrade = true
+			return true
+		}
+		if match := gradeLineRe.FindStringSubmatch(text); match != nil {
+			grade, err := strconv.ParseFloat(match[1], 64)
+			if err == nil {
+				b.hasGrade = true
+				if grade < MinGrade || grade > MaxGrade {
+					result.warn(line.Number, WarnGradeOutOfRange, text, b.subject.Code,
+						"calificación %s fuera de rango (%.1f - %.1f): se ignora", match[1], MinGrade, MaxGrade)
+				} else {
+					b.subject.Grade = grade
+					b.validGrade = true
+				}
+				return true
+			}
+		}
+	}
+
+	if !b.hasStatus {
+		if statusLineRe.MatchString(strings.ToUpper(text)) {
+			b.subject.Status = models.NormalizarEstado(text)
+			b.hasStatus = true
+			return true
+		}
+	}
+
+	return false
+}
+
+// finishSubject valida la materia acumulada y la agrega al resultado
+func (r *Result) finishSubject(b *subjectBuilder) {
+	if b == nil {
+		return
+	}
+	s := b.subject
+
+	if !b.hasCredits {
+		r.warn(s.Line, WarnMissingCredits, "", s.Code,
+			"la materia %s (%s) no tiene créditos: se descarta", s.Name, s.Code)
+		return
+	}
+	if !b.hasType {
+		r.warn(s.Line, WarnMissingType, "", s.Code,
+			"la materia %s (%s) no tiene tipología", s.Name, s.Code)
+	}
+	if !b.hasPeriod {
+		r.warn(s.Line, WarnMissingPeriod, "", s.Code,
+			"la materia %s (%s) no tiene periodo", s.Name, s.Code)
+	}
+	if !b.hasStatus {
+		s.Status = inferStatus(s.SubjectInput, b.validGrade)
+		if s.Status == "" {
+			r.warn(s.Line, WarnMissingStatus, "", s.Code,
+				"la materia %s (%s) no tiene estado ni calificación: no se cuenta como aprobada", s.Name, s.Code)
+		} else {
+			r.markInferred(s.Code, "status")
+		}
+	}
+
+	r.Subjects = append(r.Subjects, s)
+}
+
+// inferStatus deduce el estado de una materia a partir de su calificación cuando
+// el texto no incluye la línea de estado
+func inferStatus(s models.SubjectInput, hasGrade bool) string {
+	switch {
+	case s.GradeLabel == models.CalificacionAprobada:
+		return models.EstadoAprobada
+	case s.GradeLabel == models.CalificacionNoAprobada:
+		return models.EstadoReprobada
+	case hasGrade && s.Grade >= models.NotaMinimaAprobatoria:
+		return models.EstadoAprobada
+	case hasGrade:
+		return models.EstadoReprobada
+	default:
+		return ""
+	}
+}
+
+// parseModality normaliza la modalidad que acompaña al periodo ("Ordinaria",
+// "Validacion por suficiencia", etc.) y advierte si no es una modalidad conocida
+func parseModality(text string, line int, code string, result *Result) string {
+	modalidad := models.NormalizarModalidad(text)
+	if modalidad != "" && !models.ValidarModalidad(modalidad) {
+		result.warn(line, WarnUnknownModality, text, code, "modalidad %q no reconocida", text)
+	}
+	return modalidad
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"olimpo-vicedecanatura/models"
+)
+
+// WarnIncompleteSummaryRow indica una fila del resumen de créditos sin las cinco columnas
+const WarnIncompleteSummaryRow = "incomplete_summary_row"
+
+// summaryColumns es el número de columnas numéricas de cada fila del resumen de créditos
+// (exigidos, aprobados, pendientes, inscritos, cursados)
+const summaryColumns = 5
+
+var (
+	summaryHeaderRe = regexp.MustCompile(`(?i)^(tipolog[íi]as|exigidos|aprobados|pendientes|inscritos|cursados)$`)
+	summaryEndRe    = regexp.MustCompile(`(?i)^(total cr[ée]ditos excedentes|total de cr[ée]ditos cancelados|porcentaje de avance|cupo de cr[ée]ditos)`)
+	summaryRowRe    = regexp.MustCompile(`^(.*?[^\d\s])\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)$`)
+	summaryNumberRe = regexp.MustCompile(`^\d+$`)
+)
+
+// summaryBuilder acumula las filas de la tabla "Resumen de créditos"
+type summaryBuilder struct {
+	label   string
+	line    int
+	numbers []int
+	done    bool
+}
+
+// consume procesa una línea de la sección de resumen de créditos
+func (b *summaryBuilder) consume(line sourceLine, result *Result) {
+	text := line.Text
+	if b.done || summaryHeaderRe.MatchString(text) {
+		return
+	}
+	if summaryEndRe.MatchString(text) {
+		b.flush(result)
+		b.done = true
+		return
+	}
+
+	// Fila completa en una sola línea (separada por tabulaciones o espacios)
+	if match := summaryRowRe.FindStringSubmatch(text); match != nil {
+		b.flush(result)
+		b.label, b.line = match[1], line.Number
+		for _, n := range match[2:] {
+			value, _ := strconv.Atoi(n)
+			b.numbers = append(b.numbers, value)
+		}
+		b.flush(result)
+		return
+	}
+
+	if summaryNumberRe.MatchString(text) {
+		if b.label == "" {
+			result.warn(line.Number, WarnUnrecognizedLine, text, "", "valor del resumen de créditos sin tipología")
+			return
+		}
+		value, _ := strconv.Atoi(text)
+		b.numbers = append(b.numbers, value)
+		if len(b.numbers) == summaryColumns {
+			b.flush(result)
+		}
+		return
+	}
+
+	// Nueva tipología
+	b.flush(result)
+	b.label, b.line = text, line.Number
+}
+
+// flush agrega la fila acumulada al resultado, advirtiendo si quedó incompleta
+func (b *summaryBuilder) flush(result *Result) {
+	defer func() {
+		b.label, b.line, b.numbers = "", 0, nil
+	}()
+	if b.label == "" {
+		return
+	}
+	if len(b.numbers) != summaryColumns {
+		result.warn(b.line, WarnIncompleteSummaryRow, b.label, "",
+			"la fila %q del resumen de créditos tiene %d de %d columnas: se descarta", b.label, len(b.numbers), summaryColumns)
+		return
+	}
+
+	result.CreditSummary = append(result.CreditSummary, models.ResumenCreditos{
+		Tipologia:  summaryTipologia(b.label),
+		Exigidos:   b.numbers[0],
+		Aprobados:  b.numbers[1],
+		Pendientes: b.numbers[2],
+		Inscritos:  b.numbers[3],
+		Cursados:   b.numbers[4],
+	})
+}
+
+// summaryTipologia normaliza la etiqueta de la fila; las filas de totales se conservan tal cual
+func summaryTipologia(label string) models.TipologiaAsignatura {
+	label = strings.ToUpper(strings.TrimSpace(label))
+	if strings.HasPrefix(label, "TOTAL") {
+		return models.TipologiaAsignatura(label)
+	}
+	if tipo, ok := LookupTipologia(label); ok {
+		return tipo
+	}
+	return models.TipologiaAsignatura(label)
+}
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"olimpo-vicedecanatura/models"
+)
+
+// tabularNameRe extrae nombre y código del formato "Nombre (CÓDIGO)"
+var tabularNameRe = regexp.MustCompile(`(.+)\s\((\d{6,}-?[A-Za-z]?)\)`)
+
+// ParseTabular procesa la historia académica en formato tabulado, una materia por línea:
+// "Nombre (CÓDIGO)\tCréditos\tTipología\tPeriodo\tCalificación[\tEstado]"
+// Si el texto tiene varios "Plan de estudios", la historia de cada plan queda en Plans.
+func ParseTabular(raw string) *Result {
+	raw, fixes := Normalize(raw)
+	result, _ := parseByPlan(raw, planSectionTextRe, func(section string) (*Result, error) {
+		return parseTabularSection(section), nil
+	})
+	result.Normalizations = fixes
+	return result
+}
+
+// parseTabularSection procesa las líneas tabuladas de un solo plan
+func parseTabularSection(raw string) *Result {
+	result := NewResult()
+	result.Metadata = parseMetadata(raw)
+	for i, linea := range strings.Split(raw, "\n") {
+		number := i + 1
+		linea = strings.TrimSpace(linea)
+		if linea == "" || !strings.Contains(linea, "\t") {
+			// Las líneas sin tabulaciones no hacen parte de la tabla
+			continue
+		}
+
+		partes := strings.Split(linea, "\t")
+		if len(partes) < 5 {
+			result.warn(number, WarnUnrecognizedLine, linea, "",
+				"línea no reconocida: se esperaban 5 columnas y se encontraron %d", len(partes))
+			continue
+		}
+
+		match := tabularNameRe.FindStringSubmatch(partes[0])
+		if match == nil {
+			result.warn(number, WarnUnrecognizedLine, linea, "",
+				"línea no reconocida: no se encontró el código de la materia")
+			continue
+		}
+		nombre := strings.TrimSpace(match[1])
+		codigo := strings.TrimSpace(match[2])
+
+		creditosStr := strings.TrimSpace(partes[1])
+		creditos, err := strconv.Atoi(creditosStr)
+		if err != nil {
+			result.warn(number, WarnMissingCredits, linea, codigo,
+				"la materia %s (%s) no tiene créditos válidos: se descarta", nombre, codigo)
+			continue
+		}
+
+		tipo, tipologia := strings.TrimSpace(partes[2]), models.TipologiaAsignatura("")
+		if tipo == "" {
+			result.warn(number, WarnMissingType, linea, codigo,
+				"la materia %s (%s) no tiene tipología", nombre, codigo)
+		} else if mapped, ok := LookupTipologia(tipo); ok {
+			tipologia = mapped
+		} else {
+			result.warnUnknownTipologia(number, tipo, codigo)
+		}
+
+		// El periodo puede venir acompañado de la modalidad ("2021-1S Ordinaria")
+		periodo, modalidad := strings.TrimSpace(partes[3]), ""
+		if match := periodLineRe.FindStringSubmatch(periodo); match != nil {
+			periodo = match[1]
+			modalidad = parseModality(match[2], number, codigo, result)
+		}
+		if periodo == "" {
+			result.warn(number, WarnMissingPeriod, linea, codigo,
+				"la materia %s (%s) no tiene periodo", nombre, codigo)
+		}
+
+		materia := models.SubjectInput{
+			Code:     codigo,
+			Name:     nombre,
+			Credits:  creditos,
+			Type:     tipologia,
+			Semester: periodo,
+			Modality: modalidad,
+		}
+
+		validGrade := false
+		calStr := strings.ToUpper(strings.TrimSpace(partes[4]))
+		if gradeLabelRe.MatchString(calStr) {
+			materia.GradeLabel = calStr
+			validGrade = true
+		} else if cal, err := strconv.ParseFloat(calStr, 64); err == nil {
+			if cal < MinGrade || cal > MaxGrade {
+				result.warn(number, WarnGradeOutOfRange, linea, codigo,
+					"calificación %s fuera de rango (%.1f - %.1f): se ignora", calStr, MinGrade, MaxGrade)
+			} else {
+				materia.Grade = cal
+				validGrade = true
+			}
+		}
+
+		if len(partes) > 5 && strings.TrimSpace(partes[5]) != "" {
+			materia.Status = models.NormalizarEstado(partes[5])
+		} else {
+			materia.Status = inferStatus(materia, validGrade)
+			if materia.Status != "" {
+				result.markInferred(codigo, "status")
+			}
+		}
+		if materia.Status == "" {
+			result.warn(number, WarnMissingStatus, linea, codigo,
+				"la materia %s (%s) no tiene estado ni calificación: no se cuenta como aprobada", nombre, codigo)
+		}
+
+		result.Subjects = append(result.Subjects, Subject{SubjectInput: materia, Line: number})
+	}
+	result.Finish()
+
+	return result
+}
@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"olimpo-vicedecanatura/models"
+)
+
+// defaultTipologiaSynonyms son las etiquetas del SIA reconocidas mientras no se carguen los sinónimos de la base de datos
+var defaultTipologiaSynonyms = map[string]models.TipologiaAsignatura{
+	"FUND. OBLIGATORIA":          models.TipologiaFundamentalObligatoria,
+	"FUNDAMENTACIÓN OBLIGATORIA": models.TipologiaFundamentalObligatoria,
+	"FUND. OPTATIVA":             models.TipologiaFundamentalOptativa,
+	"FUNDAMENTACIÓN OPTATIVA":    models.TipologiaFundamentalOptativa,
+	"DISCIPLINAR OBLIGATORIA":    models.TipologiaDisciplinarObligatoria,
+	"DISCIPLINAR OPTATIVA":       models.TipologiaDisciplinarOptativa,
+	"LIBRE ELECCIÓN":             models.TipologiaLibreEleccion,
+	"TRABAJO DE GRADO":           models.TipologiaTrabajoGrado,
+	"NIVELACIÓN":                 models.TipologiaNivelacion,
+}
+
+// tipologiaRegistry guarda los sinónimos de tipologías que usan todos los parsers, por etiqueta normalizada
+type tipologiaRegistry struct {
+	mu    sync.RWMutex
+	exact map[string]models.TipologiaAsignatura
+}
+
+var tipologias = newTipologiaRegistry(defaultTipologiaSynonyms)
+
+// accentReplacer quita las tildes de las etiquetas en mayúsculas
+var accentReplacer = strings.NewReplacer("Á", "A", "É", "E", "Í", "I", "Ó", "O", "Ú", "U", "Ü", "U")
+
+func newTipologiaRegistry(synonyms map[string]models.TipologiaAsignatura) *tipologiaRegistry {
+	r := &tipologiaRegistry{}
+	r.set(synonyms)
+	return r
+}
+
+func (r *tipologiaRegistry) set(synonyms map[string]models.TipologiaAsignatura) {
+	exact := make(map[string]models.TipologiaAsignatura, len(synonyms))
+	for label, tipo := range synonyms {
+		if key := TipologiaKey(label); key != "" {
+			exact[key] = tipo
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exact = exact
+}
+
+// DefaultTipologiaSynonyms retorna una copia de los sinónimos incorporados, usados para poblar la base de datos
+func DefaultTipologiaSynonyms() map[string]models.TipologiaAsignatura {
+	synonyms := make(map[string]models.TipologiaAsignatura, len(defaultTipologiaSynonyms))
+	for label, tipo := range defaultTipologiaSynonyms {
+		synonyms[label] = tipo
+	}
+	return synonyms
+}
+
+// SetTipologiaSynonyms reemplaza los sinónimos que usan los parsers (normalmente los de la base de datos).
+// Con un mapa vacío se vuelve a los sinónimos incorporados.
+func SetTipologiaSynonyms(synonyms map[string]models.TipologiaAsignatura) {
+	if len(synonyms) == 0 {
+		synonyms = defaultTipologiaSynonyms
+	}
+	tipologias.set(synonyms)
+}
+
+// TipologiaKey normaliza una etiqueta de tipología para compararla: mayúsculas, sin tildes
+// y con un solo espacio entre palabras ("Fundamentación  obligatoria" -> "FUNDAMENTACION OBLIGATORIA")
+func TipologiaKey(label string) string {
+	return accentReplacer.Replace(strings.Join(strings.Fields(strings.ToUpper(label)), " "))
+}
+
+// tipologiaLetterRe reconoce la letra con la que el SIA antepone algunas tipologías ("B - Fundamentación Obligatoria")
+var tipologiaLetterRe = regexp.MustCompile(`^[A-Z] ?- ?`)
+
+// LookupTipologia convierte una etiqueta de tipología del SIA a la del modelo usando los sinónimos
+// registrados. Solo se aceptan etiquetas que coinciden por completo con un sinónimo, sin contar la
+// letra del SIA ("B - "); las demás no se adivinan y retornan false para que se reporten.
+func LookupTipologia(label string) (models.TipologiaAsignatura, bool) {
+	key := tipologiaLetterRe.ReplaceAllString(TipologiaKey(label), "")
+	if key == "" {
+		return "", false
+	}
+
+	tipologias.mu.RLock()
+	defer tipologias.mu.RUnlock()
+
+	tipo, ok := tipologias.exact[key]
+	return tipo, ok
+}
+
+// warnUnknownTipologia reporta una etiqueta de tipología que no corresponde a ningún sinónimo registrado
+func (r *Result) warnUnknownTipologia(line int, label, subject string) {
+	r.warn(line, WarnUnknownTipologia, label, subject,
+		"la tipología %q de la materia %s no está registrada: agréguela como sinónimo o corrija la materia", label, subject)
+}
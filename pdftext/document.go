@@ -0,0 +1,347 @@
+package pdftext
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// pdfObject es un objeto indirecto del archivo: su valor y, si es un flujo, los datos sin decodificar
+type pdfObject struct {
+	value  interface{}
+	stream []byte
+}
+
+// document contiene los objetos indirectos de un archivo PDF indexados por número
+type document struct {
+	objects map[int]*pdfObject
+}
+
+// objHeaderRe reconoce el inicio de un objeto indirecto "12 0 obj"
+var objHeaderRe = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// maxResolveDepth evita ciclos al seguir referencias indirectas
+const maxResolveDepth = 32
+
+// parseDocument recorre el archivo buscando los objetos indirectos. No depende de la tabla xref,
+// de modo que tolera archivos con la tabla dañada; si un objeto aparece varias veces (actualizaciones
+// incrementales) se conserva la última definición.
+func parseDocument(data []byte) (*document, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-")) {
+		return nil, fmt.Errorf("el archivo no es un PDF")
+	}
+
+	doc := &document{objects: make(map[int]*pdfObject)}
+	for _, loc := range objHeaderRe.FindAllSubmatchIndex(data, -1) {
+		num, _ := strconv.Atoi(string(data[loc[2]:loc[3]]))
+		l := &lexer{data: data, pos: loc[1]}
+		value, err := l.readValue()
+		if err != nil {
+			continue
+		}
+
+		obj := &pdfObject{value: value}
+		l.skipSpace()
+		if bytes.HasPrefix(data[l.pos:], []byte("stream")) {
+			obj.stream = readStreamData(data, l.pos+len("stream"), value)
+		}
+		doc.objects[num] = obj
+	}
+	if len(doc.objects) == 0 {
+		return nil, fmt.Errorf("el PDF no contiene objetos legibles")
+	}
+
+	for _, obj := range doc.objects {
+		if d, ok := obj.value.(dict); ok && d["Encrypt"] != nil {
+			return nil, fmt.Errorf("los PDF cifrados no están soportados")
+		}
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return nil, fmt.Errorf("los PDF cifrados no están soportados")
+	}
+
+	doc.expandObjectStreams()
+	return doc, nil
+}
+
+// readStreamData obtiene los bytes de un flujo a partir de la posición siguiente a "stream"
+func readStreamData(data []byte, start int, value interface{}) []byte {
+	if bytes.HasPrefix(data[start:], []byte("\r\n")) {
+		start += 2
+	} else if start < len(data) && (data[start] == '\n' || data[start] == '\r') {
+		start++
+	}
+
+	// Se confía en /Length solo si es directo y termina justo antes de "endstream"
+	if d, ok := value.(dict); ok {
+		if length, ok := d["Length"].(int); ok && length >= 0 && start+length <= len(data) {
+			rest := bytes.TrimLeft(data[start+length:], "\r\n ")
+			if bytes.HasPrefix(rest, []byte("endstream")) {
+				return data[start : start+length]
+			}
+		}
+	}
+
+	end := bytes.Index(data[start:], []byte("endstream"))
+	if end < 0 {
+		return data[start:]
+	}
+	return bytes.TrimRight(data[start:start+end], "\r\n")
+}
+
+// expandObjectStreams agrega los objetos comprimidos dentro de flujos /ObjStm (PDF 1.5+)
+func (doc *document) expandObjectStreams() {
+	var streams []*pdfObject
+	for _, obj := range doc.objects {
+		if d, ok := obj.value.(dict); ok && d["Type"] == name("ObjStm") {
+			streams = append(streams, obj)
+		}
+	}
+
+	for _, obj := range streams {
+		d := obj.value.(dict)
+		data, err := doc.decodeStream(obj)
+		if err != nil {
+			continue
+		}
+		n, _ := doc.resolve(d["N"]).(int)
+		first, _ := doc.resolve(d["First"]).(int)
+		if first > len(data) {
+			continue
+		}
+
+		header := &lexer{data: data[:first]}
+		for i := 0; i < n; i++ {
+			numTok, err1 := header.readToken()
+			offTok, err2 := header.readToken()
+			if err1 != nil || err2 != nil {
+				break
+			}
+			num, ok1 := numTok.(int)
+			offset, ok2 := offTok.(int)
+			if !ok1 || !ok2 || first+offset > len(data) {
+				break
+			}
+			if _, exists := doc.objects[num]; exists {
+				continue
+			}
+			l := &lexer{data: data, pos: first + offset}
+			if value, err := l.readValue(); err == nil {
+				doc.objects[num] = &pdfObject{value: value}
+			}
+		}
+	}
+}
+
+// resolve sigue las referencias indirectas hasta obtener un valor directo
+func (doc *document) resolve(v interface{}) interface{} {
+	for i := 0; i < maxResolveDepth; i++ {
+		r, ok := v.(ref)
+		if !ok {
+			return v
+		}
+		obj, exists := doc.objects[r.num]
+		if !exists {
+			return nil
+		}
+		v = obj.value
+	}
+	return nil
+}
+
+// resolveDict resuelve un valor y lo retorna como diccionario (o nil)
+func (doc *document) resolveDict(v interface{}) dict {
+	d, _ := doc.resolve(v).(dict)
+	return d
+}
+
+// streamOf retorna el objeto de flujo al que apunta una referencia
+func (doc *document) streamOf(v interface{}) *pdfObject {
+	r, ok := v.(ref)
+	if !ok {
+		return nil
+	}
+	obj, exists := doc.objects[r.num]
+	if !exists || obj.stream == nil {
+		return nil
+	}
+	return obj
+}
+
+// decodeStream aplica los filtros del flujo. Se soportan FlateDecode, ASCIIHexDecode y ASCII85Decode.
+func (doc *document) decodeStream(obj *pdfObject) ([]byte, error) {
+	d, _ := obj.value.(dict)
+	data := obj.stream
+
+	var filters []interface{}
+	switch f := doc.resolve(d["Filter"]).(type) {
+	case name:
+		filters = []interface{}{f}
+	case array:
+		filters = f
+	}
+
+	for _, f := range filters {
+		var err error
+		switch doc.resolve(f) {
+		case name("FlateDecode"), name("Fl"):
+			data, err = inflate(data)
+		case name("ASCIIHexDecode"), name("AHx"):
+			data, err = decodeASCIIHex(data)
+		case name("ASCII85Decode"), name("A85"):
+			data, err = decodeASCII85(data)
+		default:
+			return nil, fmt.Errorf("filtro de flujo no soportado: %v", f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// inflate descomprime datos zlib; si el encabezado zlib falta o está dañado intenta con deflate puro
+func inflate(data []byte) ([]byte, error) {
+	if r, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+		out, err := io.ReadAll(r)
+		if err == nil || len(out) > 0 {
+			return out, nil
+		}
+	}
+	out, err := io.ReadAll(flate.NewReader(bytes.NewReader(data)))
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("no se pudo descomprimir el flujo: %v", err)
+	}
+	return out, nil
+}
+
+// decodeASCIIHex decodifica un flujo ASCIIHexDecode terminado en '>'
+func decodeASCIIHex(data []byte) ([]byte, error) {
+	var digits []byte
+	for _, b := range data {
+		if b == '>' {
+			break
+		}
+		if !isWhitespace(b) {
+			digits = append(digits, b)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	_, err := hex.Decode(out, digits)
+	return out, err
+}
+
+// decodeASCII85 decodifica un flujo ASCII85Decode terminado en "~>"
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	out := make([]byte, len(data)*4/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	return out[:n], err
+}
+
+// pages retorna los diccionarios de página en orden de lectura, heredando /Resources de los nodos padres
+func (doc *document) pages() []dict {
+	var pages []dict
+
+	var catalog dict
+	for _, obj := range doc.objects {
+		if d, ok := obj.value.(dict); ok && d["Type"] == name("Catalog") {
+			catalog = d
+			break
+		}
+	}
+
+	if catalog != nil {
+		visited := make(map[int]bool)
+		var walk func(node interface{}, resources interface{}, depth int)
+		walk = func(node interface{}, resources interface{}, depth int) {
+			if r, ok := node.(ref); ok {
+				if visited[r.num] {
+					return
+				}
+				visited[r.num] = true
+			}
+			d := doc.resolveDict(node)
+			if d == nil || depth > maxResolveDepth {
+				return
+			}
+			if res, ok := d["Resources"]; ok {
+				resources = res
+			}
+			kids, isTree := doc.resolve(d["Kids"]).(array)
+			if !isTree {
+				page := dict{}
+				for k, v := range d {
+					page[k] = v
+				}
+				page["Resources"] = resources
+				pages = append(pages, page)
+				return
+			}
+			for _, kid := range kids {
+				walk(kid, resources, depth+1)
+			}
+		}
+		walk(catalog["Pages"], nil, 0)
+	}
+
+	if len(pages) > 0 {
+		return pages
+	}
+
+	// Sin catálogo utilizable: páginas en el orden de sus números de objeto
+	var nums []int
+	for num, obj := range doc.objects {
+		if d, ok := obj.value.(dict); ok && d["Type"] == name("Page") {
+			nums = append(nums, num)
+		}
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		pages = append(pages, doc.objects[num].value.(dict))
+	}
+	return pages
+}
+
+// contents retorna el flujo de contenido decodificado de una página (uno o varios flujos concatenados)
+func (doc *document) contents(page dict) []byte {
+	var refs []interface{}
+	switch c := page["Contents"].(type) {
+	case ref:
+		if arr, ok := doc.resolve(c).(array); ok {
+			refs = arr
+		} else {
+			refs = []interface{}{c}
+		}
+	case array:
+		refs = c
+	}
+
+	var buf bytes.Buffer
+	for _, r := range refs {
+		obj := doc.streamOf(r)
+		if obj == nil {
+			continue
+		}
+		data, err := doc.decodeStream(obj)
+		if err != nil {
+			continue
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
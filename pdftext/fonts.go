@@ -0,0 +1,237 @@
+package pdftext
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// font decodifica los códigos de caracteres de una fuente a texto Unicode
+type font struct {
+	toUnicode map[string]string // Mapa ToUnicode: código (bytes) → texto
+	codeLens  []int             // Longitudes de código declaradas en codespacerange
+	twoByte   bool              // Fuente compuesta (Type0) con códigos de dos bytes
+}
+
+// winAnsiHigh contiene los caracteres de WinAnsiEncoding entre 0x80 y 0x9F; el resto coincide con Latin-1
+var winAnsiHigh = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+// loadFont construye el decodificador de una fuente a partir de su diccionario
+func (doc *document) loadFont(v interface{}) *font {
+	f := &font{}
+	d := doc.resolveDict(v)
+	if d == nil {
+		return f
+	}
+
+	f.twoByte = d["Subtype"] == name("Type0")
+	if obj := doc.streamOf(d["ToUnicode"]); obj != nil {
+		if data, err := doc.decodeStream(obj); err == nil {
+			f.parseCMap(data)
+		}
+	}
+	return f
+}
+
+// parseCMap lee las secciones codespacerange, bfchar y bfrange de un CMap ToUnicode
+func (f *font) parseCMap(data []byte) {
+	f.toUnicode = make(map[string]string)
+	l := &lexer{data: data}
+
+	var operands []interface{}
+	for !l.eof() {
+		tok, err := l.readValue()
+		if err != nil {
+			l.pos++
+			continue
+		}
+		kw, isKeyword := tok.(keyword)
+		if !isKeyword {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch kw {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				if lo, ok := operands[i].(pdfStr); ok && len(lo) > 0 {
+					f.addCodeLen(len(lo))
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfStr)
+				dst, ok2 := operands[i+1].(pdfStr)
+				if ok1 && ok2 {
+					f.toUnicode[string(src)] = decodeUTF16(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfStr)
+				hi, ok2 := operands[i+1].(pdfStr)
+				if !ok1 || !ok2 || len(lo) != len(hi) || len(lo) == 0 {
+					continue
+				}
+				f.addRange(lo, hi, operands[i+2])
+			}
+		}
+		if strings.HasPrefix(string(kw), "end") || strings.HasPrefix(string(kw), "begin") {
+			operands = operands[:0]
+		}
+	}
+}
+
+// addCodeLen registra una longitud de código válida para la fuente
+func (f *font) addCodeLen(n int) {
+	for _, existing := range f.codeLens {
+		if existing == n {
+			return
+		}
+	}
+	f.codeLens = append(f.codeLens, n)
+}
+
+// addRange agrega las entradas de un bfrange; el destino es una cadena que se incrementa o un arreglo de cadenas
+func (f *font) addRange(lo, hi pdfStr, dst interface{}) {
+	start := bytesToInt(lo)
+	end := bytesToInt(hi)
+	if end < start || end-start > 0xFFFF {
+		return
+	}
+
+	for code := start; code <= end; code++ {
+		key := intToBytes(code, len(lo))
+		offset := code - start
+		switch d := dst.(type) {
+		case pdfStr:
+			if len(d) == 0 {
+				continue
+			}
+			target := append([]byte(nil), d...)
+			last := int(target[len(target)-1]) + offset
+			target[len(target)-1] = byte(last & 0xFF)
+			if last > 0xFF && len(target) > 1 {
+				target[len(target)-2] += byte(last >> 8)
+			}
+			f.toUnicode[key] = decodeUTF16(target)
+		case array:
+			if offset < len(d) {
+				if s, ok := d[offset].(pdfStr); ok {
+					f.toUnicode[key] = decodeUTF16(s)
+				}
+			}
+		}
+	}
+}
+
+// decode convierte una cadena de la fuente en texto Unicode
+func (f *font) decode(s pdfStr) string {
+	if len(f.toUnicode) > 0 {
+		return f.decodeWithCMap(s)
+	}
+	if f.twoByte {
+		// Sin ToUnicode solo se puede suponer que los códigos de dos bytes son UTF-16
+		return decodeUTF16(s)
+	}
+
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case c >= 0x80 && c < 0xA0:
+			if r := winAnsiHigh[c-0x80]; r != 0 {
+				b.WriteRune(r)
+			}
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+// decodeWithCMap recorre la cadena probando primero las longitudes de código más largas
+func (f *font) decodeWithCMap(s pdfStr) string {
+	lens := f.codeLens
+	if len(lens) == 0 {
+		if f.twoByte {
+			lens = []int{2}
+		} else {
+			lens = []int{1}
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		matched := false
+		for n := 4; n >= 1 && !matched; n-- {
+			if !containsInt(lens, n) || i+n > len(s) {
+				continue
+			}
+			if text, ok := f.toUnicode[string(s[i:i+n])]; ok {
+				b.WriteString(text)
+				i += n
+				matched = true
+			}
+		}
+		if !matched {
+			// Código sin correspondencia: se omite con la longitud mínima declarada
+			i += minInt(lens)
+		}
+	}
+	return b.String()
+}
+
+// decodeUTF16 decodifica texto UTF-16BE (con o sin BOM)
+func decodeUTF16(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		b = b[2:]
+	}
+	if len(b)%2 == 1 {
+		return string(b)
+	}
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// bytesToInt interpreta los bytes como un entero sin signo big-endian
+func bytesToInt(b []byte) int {
+	n := 0
+	for _, c := range b {
+		n = n<<8 | int(c)
+	}
+	return n
+}
+
+// intToBytes codifica un entero en n bytes big-endian
+func intToBytes(v, n int) string {
+	out := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		out[i] = byte(v & 0xFF)
+		v >>= 8
+	}
+	return string(out)
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func minInt(values []int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
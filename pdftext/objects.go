@@ -0,0 +1,307 @@
+package pdftext
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// Tipos de valores de un archivo PDF
+type (
+	name    string                 // /Nombre
+	keyword string                 // Operadores y palabras clave (obj, R, Tj, BT...)
+	ref     struct{ num, gen int } // Referencia indirecta "12 0 R"
+	dict    map[string]interface{} // << /Clave valor >>
+	array   []interface{}          // [ valor valor ]
+	pdfStr  []byte                 // Cadena literal (...) o hexadecimal <...>
+)
+
+// lexer lee los tokens de un objeto o de un flujo de contenido PDF
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+// isWhitespace indica si el byte es un espacio en blanco según la especificación PDF
+func isWhitespace(b byte) bool {
+	switch b {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+// isDelimiter indica si el byte es un delimitador según la especificación PDF
+func isDelimiter(b byte) bool {
+	switch b {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// skipSpace avanza sobre espacios en blanco y comentarios
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		b := l.data[l.pos]
+		if isWhitespace(b) {
+			l.pos++
+			continue
+		}
+		if b == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// eof indica si ya no quedan tokens
+func (l *lexer) eof() bool {
+	l.skipSpace()
+	return l.pos >= len(l.data)
+}
+
+// readValue lee un valor completo, incluyendo arreglos, diccionarios y referencias indirectas
+func (l *lexer) readValue() (interface{}, error) {
+	tok, err := l.readToken()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case keyword:
+		switch t {
+		case "[":
+			var arr array
+			for {
+				if l.eof() {
+					return nil, fmt.Errorf("arreglo sin cerrar")
+				}
+				if l.data[l.pos] == ']' {
+					l.pos++
+					return arr, nil
+				}
+				v, err := l.readValue()
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, v)
+			}
+		case "<<":
+			d := dict{}
+			for {
+				if l.eof() {
+					return nil, fmt.Errorf("diccionario sin cerrar")
+				}
+				if bytes.HasPrefix(l.data[l.pos:], []byte(">>")) {
+					l.pos += 2
+					return d, nil
+				}
+				key, err := l.readToken()
+				if err != nil {
+					return nil, err
+				}
+				k, ok := key.(name)
+				if !ok {
+					return nil, fmt.Errorf("clave de diccionario inválida: %v", key)
+				}
+				v, err := l.readValue()
+				if err != nil {
+					return nil, err
+				}
+				d[string(k)] = v
+			}
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return t, nil
+	case int:
+		// Posible referencia indirecta: "num gen R"
+		saved := l.pos
+		if gen, err := l.readToken(); err == nil {
+			if g, ok := gen.(int); ok {
+				if r, err := l.readToken(); err == nil && r == keyword("R") {
+					return ref{num: t, gen: g}, nil
+				}
+			}
+		}
+		l.pos = saved
+		return t, nil
+	}
+	return tok, nil
+}
+
+// readToken lee un token simple: número, nombre, cadena, delimitador de arreglo/diccionario u operador
+func (l *lexer) readToken() (interface{}, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, fmt.Errorf("fin de datos inesperado")
+	}
+
+	b := l.data[l.pos]
+	switch {
+	case b == '[' || b == ']' || b == '{' || b == '}':
+		l.pos++
+		return keyword(string(b)), nil
+	case b == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return keyword("<<"), nil
+	case b == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+		l.pos += 2
+		return keyword(">>"), nil
+	case b == '<':
+		return l.readHexString()
+	case b == '(':
+		return l.readLiteralString()
+	case b == '/':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+			l.pos++
+		}
+		return name(decodeNameEscapes(l.data[start:l.pos])), nil
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	if start == l.pos {
+		// Delimitador suelto (por ejemplo ')' sin abrir): se omite
+		l.pos++
+		return keyword(string(b)), nil
+	}
+
+	word := string(l.data[start:l.pos])
+	if n, err := strconv.Atoi(word); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(word, 64); err == nil {
+		return f, nil
+	}
+	return keyword(word), nil
+}
+
+// readHexString lee una cadena hexadecimal <48656C6C6F>
+func (l *lexer) readHexString() (pdfStr, error) {
+	l.pos++ // '<'
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if !isWhitespace(l.data[l.pos]) {
+			digits = append(digits, l.data[l.pos])
+		}
+		l.pos++
+	}
+	l.pos++ // '>'
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+
+	out := make([]byte, 0, len(digits)/2)
+	for i := 0; i < len(digits); i += 2 {
+		v, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("cadena hexadecimal inválida")
+		}
+		out = append(out, byte(v))
+	}
+	return pdfStr(out), nil
+}
+
+// readLiteralString lee una cadena literal (texto) con paréntesis anidados y secuencias de escape
+func (l *lexer) readLiteralString() (pdfStr, error) {
+	l.pos++ // '('
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		b := l.data[l.pos]
+		l.pos++
+		switch b {
+		case '(':
+			depth++
+			out = append(out, b)
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfStr(out), nil
+			}
+			out = append(out, b)
+		case '\\':
+			if l.pos >= len(l.data) {
+				break
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				// Continuación de línea
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+				// Continuación de línea
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, b)
+		}
+	}
+	return nil, fmt.Errorf("cadena literal sin cerrar")
+}
+
+// decodeNameEscapes decodifica las secuencias #xx de los nombres PDF
+func decodeNameEscapes(raw []byte) string {
+	if !bytes.Contains(raw, []byte("#")) {
+		return string(raw)
+	}
+	var out []byte
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) {
+			if v, err := strconv.ParseUint(string(raw[i+1:i+3]), 16, 8); err == nil {
+				out = append(out, byte(v))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, raw[i])
+	}
+	return string(out)
+}
+
+// toFloat convierte un número PDF (entero o real) a float64
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,236 @@
+// Package pdftext extrae el texto de archivos PDF sin dependencias externas.
+//
+// Está pensado para reportes generados por sistemas como el SIA (texto en fuentes estándar
+// o compuestas con mapa ToUnicode), no para documentos escaneados. El texto se reconstruye
+// por líneas: los fragmentos ubicados a la misma altura se separan con tabulaciones y cada
+// cambio de altura inicia una nueva línea, de modo que las tablas quedan una fila por línea.
+package pdftext
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// spaceThreshold es el desplazamiento (en milésimas de em) dentro de un arreglo TJ
+// a partir del cual se considera que hay un espacio entre palabras
+const spaceThreshold = 200
+
+// lineTolerance es la diferencia vertical mínima para considerar que el texto cambió de línea
+const lineTolerance = 1.0
+
+// maxFormDepth limita el anidamiento de formularios XObject
+const maxFormDepth = 8
+
+// ExtractText extrae el texto de todas las páginas del PDF
+func ExtractText(data []byte) (string, error) {
+	doc, err := parseDocument(data)
+	if err != nil {
+		return "", err
+	}
+
+	pages := doc.pages()
+	if len(pages) == 0 {
+		return "", fmt.Errorf("el PDF no contiene páginas")
+	}
+
+	var out strings.Builder
+	for i, page := range pages {
+		w := &textWriter{}
+		doc.runContent(doc.contents(page), doc.resolveDict(page["Resources"]), w, 0)
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString(w.String())
+	}
+
+	text := strings.TrimSpace(out.String())
+	if text == "" {
+		return "", fmt.Errorf("el PDF no contiene texto extraíble (¿es un documento escaneado?)")
+	}
+	return text, nil
+}
+
+// textWriter acumula el texto de una página insertando los separadores pendientes solo entre fragmentos
+type textWriter struct {
+	b       strings.Builder
+	pending string
+}
+
+// separate registra un separador; un salto de línea tiene prioridad sobre una tabulación
+func (w *textWriter) separate(sep string) {
+	if w.b.Len() == 0 || w.pending == "\n" {
+		return
+	}
+	w.pending = sep
+}
+
+// write agrega texto precedido del separador pendiente
+func (w *textWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	if w.pending != "" {
+		w.b.WriteString(w.pending)
+		w.pending = ""
+	}
+	w.b.WriteString(s)
+}
+
+func (w *textWriter) String() string {
+	return w.b.String()
+}
+
+// textState es el estado de texto relevante para separar líneas y celdas
+type textState struct {
+	font    *font
+	lineX   float64 // Origen de la línea actual (matriz de línea de texto)
+	lineY   float64
+	leading float64 // Interlineado (TL)
+	lastX   float64 // Última posición en la que se ubicó texto, se conserva entre bloques BT/ET
+	lastY   float64
+	started bool // Si ya se ubicó texto en la página
+}
+
+// moveTo actualiza la posición de la línea y registra el separador correspondiente
+func (st *textState) moveTo(x, y float64, w *textWriter) {
+	if st.started {
+		if math.Abs(y-st.lastY) > lineTolerance {
+			w.separate("\n")
+		} else if math.Abs(x-st.lastX) > lineTolerance {
+			w.separate("\t")
+		}
+	}
+	st.lineX, st.lineY = x, y
+	st.lastX, st.lastY = x, y
+	st.started = true
+}
+
+// runContent interpreta un flujo de contenido y escribe el texto mostrado por los operadores Tj, TJ, ' y "
+func (doc *document) runContent(content []byte, resources dict, w *textWriter, depth int) {
+	fonts := make(map[string]*font)
+	fontDicts := doc.resolveDict(resources["Font"])
+	st := &textState{font: &font{}}
+
+	getFont := func(n name) *font {
+		if f, ok := fonts[string(n)]; ok {
+			return f
+		}
+		f := doc.loadFont(fontDicts[string(n)])
+		fonts[string(n)] = f
+		return f
+	}
+
+	l := &lexer{data: content}
+	var operands []interface{}
+	for !l.eof() {
+		tok, err := l.readValue()
+		if err != nil {
+			l.pos++
+			operands = operands[:0]
+			continue
+		}
+		op, isOperator := tok.(keyword)
+		if !isOperator {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch op {
+		case "BT":
+			// Cada bloque de texto reinicia la matriz de texto
+			st.lineX, st.lineY = 0, 0
+		case "BI":
+			// Imagen en línea: se salta hasta el operador EI
+			if end := strings.Index(string(content[l.pos:]), "EI"); end >= 0 {
+				l.pos += end + 2
+			}
+		case "Tf":
+			if len(operands) >= 2 {
+				if n, ok := operands[0].(name); ok {
+					st.font = getFont(n)
+				}
+			}
+		case "TL":
+			if len(operands) >= 1 {
+				st.leading, _ = toFloat(operands[0])
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				tx, _ := toFloat(operands[0])
+				ty, _ := toFloat(operands[1])
+				if op == "TD" {
+					st.leading = -ty
+				}
+				st.moveTo(st.lineX+tx, st.lineY+ty, w)
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				e, _ := toFloat(operands[4])
+				f, _ := toFloat(operands[5])
+				st.moveTo(e, f, w)
+			}
+		case "T*":
+			st.moveTo(st.lineX, st.lineY-st.leading, w)
+			w.separate("\n")
+		case "Tj":
+			if len(operands) >= 1 {
+				if s, ok := operands[0].(pdfStr); ok {
+					w.write(st.font.decode(s))
+				}
+			}
+		case "'", "\"":
+			st.moveTo(st.lineX, st.lineY-st.leading, w)
+			w.separate("\n")
+			if len(operands) >= 1 {
+				if s, ok := operands[len(operands)-1].(pdfStr); ok {
+					w.write(st.font.decode(s))
+				}
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				if arr, ok := operands[0].(array); ok {
+					for _, item := range arr {
+						if s, ok := item.(pdfStr); ok {
+							w.write(st.font.decode(s))
+						} else if n, ok := toFloat(item); ok && n < -spaceThreshold {
+							w.write(" ")
+						}
+					}
+				}
+			}
+		case "Do":
+			if len(operands) >= 1 && depth < maxFormDepth {
+				if n, ok := operands[0].(name); ok {
+					doc.runForm(resources, n, w, depth)
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+// runForm interpreta un formulario XObject referenciado desde el contenido de la página
+func (doc *document) runForm(resources dict, n name, w *textWriter, depth int) {
+	xobjects := doc.resolveDict(resources["XObject"])
+	obj := doc.streamOf(xobjects[string(n)])
+	if obj == nil {
+		return
+	}
+	d, _ := obj.value.(dict)
+	if d["Subtype"] != name("Form") {
+		return
+	}
+	data, err := doc.decodeStream(obj)
+	if err != nil {
+		return
+	}
+
+	formResources := doc.resolveDict(d["Resources"])
+	if formResources == nil {
+		formResources = resources
+	}
+	w.separate("\n")
+	doc.runContent(data, formResources, w, depth+1)
+	w.separate("\n")
+}
@@ -0,0 +1,85 @@
+#!/bin/bash
+
+# Script para probar la resolución de códigos de materias: normalización de formato y tabla de alias
+# Usa el plan de Ingeniería de Sistemas (ISIS) cargado con scripts/seed_ing_sistemas.go
+
+API_URL=${API_URL:-http://localhost:8080}
+CAREER="ISIS"
+FAILED=0
+
+echo "🧪 Probando normalización y alias de códigos de materias..."
+echo ""
+
+echo "📝 Registrando el alias 9990435 -> 3010435 (código histórico de Fundamentos de Programación)"
+CREATED=$(curl -s -X POST "$API_URL/api/subject-aliases" \
+  -H "Content-Type: application/json" \
+  -d '{"alias": "9990435", "subject_code": "3010435", "notes": "Código anterior al cambio de plan"}')
+ALIAS_ID=$(echo "$CREATED" | jq '.alias.ID')
+if [ "$ALIAS_ID" = "null" ]; then
+  echo "   ❌ No se pudo crear el alias"
+  echo "$CREATED" | jq '.'
+  exit 1
+fi
+echo "   ✅ Alias creado con ID $ALIAS_ID"
+
+# 1000003 sin sufijo de sede y con ceros a la izquierda se resuelve por normalización a 1000003-M
+RESPONSE=$(curl -s -X POST "$API_URL/api/compare-by-career" \
+  -H "Content-Type: application/json" \
+  -d "{
+    \"career_code\": \"$CAREER\",
+    \"subjects\": [
+      {\"code\": \"9990435\", \"name\": \"Programación\", \"credits\": 3, \"type\": \"DISCIPLINAR OBLIGATORIA\", \"grade\": 4.0, \"status\": \"APROBADA\", \"semester\": \"2019-1S\"},
+      {\"code\": \"0001000003\", \"name\": \"Álgebra Lineal\", \"credits\": 4, \"type\": \"FUND. OBLIGATORIA\", \"grade\": 3.5, \"status\": \"APROBADA\", \"semester\": \"2019-2S\"}
+    ]
+  }")
+
+echo ""
+echo "📊 Resoluciones reportadas:"
+echo "$RESPONSE" | jq '[.comparison_result.equivalent_subjects[] | select(.resolved_alias) | .resolved_alias]'
+
+BY_ALIAS=$(echo "$RESPONSE" | jq -r '.comparison_result.equivalent_subjects[] | select(.code == "3010435") | .resolved_alias.rule')
+BY_NORMALIZATION=$(echo "$RESPONSE" | jq -r '.comparison_result.equivalent_subjects[] | select(.code == "1000003-M") | .resolved_alias.rule')
+
+if [ "$BY_ALIAS" = "ALIAS" ]; then
+  echo "   ✅ 9990435 resuelto por alias a 3010435"
+else
+  echo "   ❌ 9990435 no se resolvió por alias"
+  FAILED=1
+fi
+if [ "$BY_NORMALIZATION" = "NORMALIZACIÓN" ]; then
+  echo "   ✅ 0001000003 resuelto por normalización a 1000003-M"
+else
+  echo "   ❌ 0001000003 no se resolvió por normalización"
+  FAILED=1
+fi
+
+echo ""
+echo "🔍 Código con otro sufijo de sede"
+# 1000003-B no es 1000003-M: sin alias queda fuera del plan en lugar de coincidir por la base
+OTHER_SEDE=$(curl -s -X POST "$API_URL/api/compare-by-career" \
+  -H "Content-Type: application/json" \
+  -d "{
+    \"career_code\": \"$CAREER\",
+    \"subjects\": [
+      {\"code\": \"1000003-B\", \"name\": \"Álgebra Lineal\", \"credits\": 4, \"type\": \"FUND. OBLIGATORIA\", \"grade\": 3.5, \"status\": \"APROBADA\", \"semester\": \"2019-2S\"}
+    ]
+  }")
+MATCHED=$(echo "$OTHER_SEDE" | jq '[.comparison_result.equivalent_subjects[] | select(.code == "1000003-M")] | length')
+if [ "$MATCHED" = "0" ]; then
+  echo "   ✅ 1000003-B no se resolvió a 1000003-M"
+else
+  echo "   ❌ 1000003-B se resolvió a 1000003-M por el código base"
+  FAILED=1
+fi
+
+echo ""
+echo "🧹 Eliminando el alias de prueba"
+curl -s -X DELETE "$API_URL/api/subject-aliases/$ALIAS_ID" | jq -r '.message // .error'
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
@@ -0,0 +1,91 @@
+#!/bin/bash
+
+# Script para probar el explorador de cambio de carrera: una historia comparada con todas las carreras
+# Usa el plan de Ingeniería de Sistemas (ISIS) cargado con scripts/seed_ing_sistemas.go
+
+API_URL=${API_URL:-http://localhost:8080}
+FIXTURES="$(dirname "$0")/testdata"
+FAILED=0
+
+echo "🧪 Probando el explorador de carreras..."
+echo ""
+
+RESPONSE=$(curl -s -X POST "$API_URL/api/explore-careers" \
+  -H "Content-Type: application/json" \
+  -d '{
+    "subjects": [
+      {"code": "1000004-M", "name": "Cálculo Diferencial", "credits": 4, "type": "FUND. OBLIGATORIA", "grade": 4.0, "status": "APROBADA", "semester": "2022-1S"},
+      {"code": "3010435", "name": "Fundamentos de Programación", "credits": 3, "type": "DISCIPLINAR OBLIGATORIA", "grade": 4.3, "status": "APROBADA", "semester": "2022-1S"},
+      {"code": "3007744", "name": "Programación Orientada a Objetos", "credits": 3, "type": "DISCIPLINAR OBLIGATORIA", "grade": 3.8, "status": "APROBADA", "semester": "2022-2S"}
+    ]
+  }')
+
+echo "🏆 Carreras ordenadas por avance:"
+echo "$RESPONSE" | jq -r '.careers[] | "   \(.rank). \(.career_code) \(.career_name) (plan \(.study_plan_version)): \(.completion_percentage | . * 10 | round / 10)% - \(.homologable_credits) créditos homologables, faltan \(.missing_credits)"'
+echo "$RESPONSE" | jq -r '.skipped_careers[] | "   ⏭️  \(.career_code): \(.reason)"'
+echo ""
+
+ISIS=$(echo "$RESPONSE" | jq '.careers[] | select(.career_code == "ISIS") | .homologable_credits')
+if [ "$ISIS" = "10" ]; then
+  echo "   ✅ ISIS reconoce los 10 créditos de la historia"
+else
+  echo "   ❌ Se esperaban 10 créditos homologables en ISIS, se obtuvo $ISIS"
+  FAILED=1
+fi
+
+SORTED=$(echo "$RESPONSE" | jq '[.careers[].completion_percentage] as $p | $p == ($p | sort | reverse)')
+RANKS=$(echo "$RESPONSE" | jq '[.careers[].rank] == [range(1; (.careers | length) + 1)]')
+if [ "$SORTED" = "true" ] && [ "$RANKS" = "true" ]; then
+  echo "   ✅ Las carreras están ordenadas por porcentaje de avance"
+else
+  echo "   ❌ Las carreras no están ordenadas por avance"
+  FAILED=1
+fi
+
+BREAKDOWN=$(echo "$RESPONSE" | jq '[.careers[] | .credits_summary | has("fund_obligatoria") and has("libre") and has("total")] | all')
+if [ "$BREAKDOWN" = "true" ]; then
+  echo "   ✅ Cada carrera trae el resumen de créditos por tipología"
+else
+  echo "   ❌ Falta el resumen de créditos en alguna carrera"
+  FAILED=1
+fi
+
+COUNT=$(( $(echo "$RESPONSE" | jq '.summary.careers_compared') + $(echo "$RESPONSE" | jq '.summary.careers_skipped') ))
+TOTAL=$(curl -s "$API_URL/api/careers" | jq '.careers | length')
+if [ "$COUNT" = "$TOTAL" ]; then
+  echo "   ✅ Se reportan las $TOTAL carreras (comparadas u omitidas)"
+else
+  echo "   ❌ Se reportan $COUNT carreras de $TOTAL"
+  FAILED=1
+fi
+
+echo ""
+echo "📄 Historia en texto del SIA"
+TEXT_RESPONSE=$(jq -n --rawfile text "$FIXTURES/historia_academica_sia.txt" '{academic_history_text: $text}' | \
+  curl -s -X POST "$API_URL/api/explore-careers" -H "Content-Type: application/json" -d @-)
+if [ "$(echo "$TEXT_RESPONSE" | jq '.summary.total_subjects > 0 and (.careers | length) > 0')" = "true" ]; then
+  echo "   ✅ $(echo "$TEXT_RESPONSE" | jq '.summary.total_subjects') materias comparadas; mejor carrera: $(echo "$TEXT_RESPONSE" | jq -r '.summary.best_career')"
+else
+  echo "   ❌ Respuesta inesperada: $(echo "$TEXT_RESPONSE" | jq -c '.error // .summary')"
+  FAILED=1
+fi
+
+echo ""
+echo "🚫 Historia vacía"
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "$API_URL/api/explore-careers" \
+  -H "Content-Type: application/json" \
+  -d '{"subjects": []}')
+if [ "$STATUS" = "400" ]; then
+  echo "   ✅ La historia vacía se rechazó con 400"
+else
+  echo "   ❌ Se esperaba 400, se obtuvo $STATUS"
+  FAILED=1
+fi
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
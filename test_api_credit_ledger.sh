@@ -0,0 +1,72 @@
+#!/bin/bash
+
+# Script para probar el libro de créditos de la comparación
+# Cada fila de credits_summary debe ser igual a la suma de los abonos de credit_ledger de su tipología
+
+API_URL=${API_URL:-http://localhost:8080}
+FIXTURES="$(dirname "$0")/testdata"
+CAREER="ISIS"
+FAILED=0
+
+echo "🧪 Probando el libro de créditos de /api/api-compare..."
+echo ""
+
+RESPONSE=$(curl -s -X POST "$API_URL/api/api-compare" \
+  -H "Content-Type: application/json" \
+  -d "$(jq -n --rawfile history "$FIXTURES/historia_academica_sia.txt" --arg career "$CAREER" \
+    '{academic_history_text: $history, target_career_code: $career}')")
+
+if ! echo "$RESPONSE" | jq -e '.comparison_result.credit_ledger' > /dev/null; then
+  echo "❌ La respuesta no tiene credit_ledger"
+  echo "$RESPONSE" | jq '.'
+  exit 1
+fi
+
+echo "📒 Abonos registrados: $(echo "$RESPONSE" | jq '.comparison_result.credit_ledger | length')"
+echo "$RESPONSE" | jq -r '.comparison_result.credit_ledger[] | "   \(.plan_code) ← \(.history_code) (\(.via)): \(.credits) créditos \(.tipologia)"'
+echo ""
+
+check() {
+  local FIELD=$1
+  local TIPOLOGIA=$2
+  local COMPLETED=$(echo "$RESPONSE" | jq ".comparison_result.credits_summary.$FIELD.completed")
+  local LEDGER=$(echo "$RESPONSE" | jq --arg t "$TIPOLOGIA" '[.comparison_result.credit_ledger[] | select(.tipologia == $t) | .credits] | add // 0')
+
+  if [ "$COMPLETED" = "$LEDGER" ]; then
+    echo "   ✅ $TIPOLOGIA: $COMPLETED créditos"
+  else
+    echo "   ❌ $TIPOLOGIA: el resumen dice $COMPLETED y el libro suma $LEDGER"
+    FAILED=1
+  fi
+}
+
+check fund_obligatoria "FUND. OBLIGATORIA"
+check fund_optativa "FUND. OPTATIVA"
+check dis_obligatoria "DISCIPLINAR OBLIGATORIA"
+check dis_optativa "DISCIPLINAR OPTATIVA"
+check libre "LIBRE ELECCIÓN"
+
+TOTAL=$(echo "$RESPONSE" | jq '.comparison_result.credits_summary.total.completed')
+LEDGER_TOTAL=$(echo "$RESPONSE" | jq '[.comparison_result.credit_ledger[].credits] | add // 0')
+if [ "$TOTAL" = "$LEDGER_TOTAL" ]; then
+  echo "   ✅ TOTAL: $TOTAL créditos"
+else
+  echo "   ❌ TOTAL: el resumen dice $TOTAL y el libro suma $LEDGER_TOTAL"
+  FAILED=1
+fi
+
+# La historia tiene Cálculo diferencial (1000004-M) aprobada, que es fundamentación obligatoria en el plan
+if [ "$(echo "$RESPONSE" | jq '.comparison_result.credits_summary.fund_obligatoria.completed')" -gt 0 ]; then
+  echo "   ✅ Los créditos de fundamentación obligatoria ya no quedan en 0"
+else
+  echo "   ❌ fund_obligatoria.completed sigue en 0"
+  FAILED=1
+fi
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
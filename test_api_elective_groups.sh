@@ -0,0 +1,111 @@
+#!/bin/bash
+
+# Script para probar las agrupaciones de optativas: las optativas se exigen por créditos y no una por una
+# Usa el plan de Ingeniería de Sistemas (ISIS) cargado con scripts/seed_ing_sistemas.go
+
+API_URL=${API_URL:-http://localhost:8080}
+CAREER="ISIS"
+FAILED=0
+
+echo "🧪 Probando agrupaciones de optativas..."
+echo ""
+
+PLAN_ID=$(curl -s "$API_URL/api/careers/$CAREER/study-plans" | jq '[.study_plans[] | select(.IsActive)][0].ID')
+echo "📚 Plan activo de $CAREER: $PLAN_ID"
+
+echo "📝 Creando la agrupación \"Inteligencia artificial\" (6 créditos entre 3 optativas)"
+CREATED=$(curl -s -X POST "$API_URL/api/study-plans/$PLAN_ID/elective-groups" \
+  -H "Content-Type: application/json" \
+  -d '{"name": "Inteligencia artificial", "tipologia": "DISCIPLINAR OPTATIVA", "min_credits": 6, "subject_codes": ["3007854", "3007862", "3009150"]}')
+GROUP_ID=$(echo "$CREATED" | jq '.elective_group.ID')
+if [ "$GROUP_ID" = "null" ]; then
+  echo "   ❌ No se pudo crear la agrupación"
+  echo "$CREATED" | jq '.'
+  exit 1
+fi
+echo "   ✅ Agrupación creada con ID $GROUP_ID"
+
+# Visión Artificial aprobada: a la agrupación le faltan 3 créditos
+RESPONSE=$(curl -s -X POST "$API_URL/api/compare-by-career" \
+  -H "Content-Type: application/json" \
+  -d "{
+    \"career_code\": \"$CAREER\",
+    \"subjects\": [
+      {\"code\": \"3007862\", \"name\": \"Visión Artificial\", \"credits\": 3, \"type\": \"DISCIPLINAR OPTATIVA\", \"grade\": 4.2, \"status\": \"APROBADA\", \"semester\": \"2023-1S\"}
+    ]
+  }")
+
+echo ""
+echo "📊 Agrupaciones reportadas:"
+echo "$RESPONSE" | jq -r '.comparison_result.elective_groups[] | "   \(.name): \(.completed_credits)/\(.min_credits) créditos, faltan \(.missing_credits), \(.options | length) opciones"'
+
+GROUP=$(echo "$RESPONSE" | jq '.comparison_result.elective_groups[] | select(.name == "Inteligencia artificial")')
+if [ "$(echo "$GROUP" | jq '.missing_credits')" = "3" ] && [ "$(echo "$GROUP" | jq '.satisfied')" = "false" ] && [ "$(echo "$GROUP" | jq '.options | length')" = "2" ]; then
+  echo "   ✅ La agrupación reporta 3 créditos faltantes y 2 opciones disponibles"
+else
+  echo "   ❌ Estado inesperado de la agrupación: $(echo "$GROUP" | jq -c '{completed_credits, missing_credits, satisfied, options: [.options[].code]}')"
+  FAILED=1
+fi
+
+OPTATIVAS_PENDIENTES=$(echo "$RESPONSE" | jq '[.comparison_result.missing_subjects[] | select(.type == "DISCIPLINAR OPTATIVA" or .type == "FUND. OPTATIVA")] | length')
+if [ "$OPTATIVAS_PENDIENTES" = "0" ]; then
+  echo "   ✅ Ninguna optativa aparece como materia pendiente"
+else
+  echo "   ❌ $OPTATIVAS_PENDIENTES optativas siguen en missing_subjects"
+  FAILED=1
+fi
+
+# Los créditos de las agrupaciones deben coincidir con los abonados a la tipología en el resumen,
+# incluso cuando el excedente de optativas pasa a libre elección
+GROUPED=$(echo "$RESPONSE" | jq '[.comparison_result.elective_groups[] | select(.tipologia == "DISCIPLINAR OPTATIVA") | .completed_credits] | add')
+SUMMARY=$(echo "$RESPONSE" | jq '.comparison_result.credits_summary.dis_optativa.completed')
+if [ "$GROUPED" = "$SUMMARY" ]; then
+  echo "   ✅ Las agrupaciones suman los mismos $SUMMARY créditos del resumen de DISCIPLINAR OPTATIVA"
+else
+  echo "   ❌ Las agrupaciones suman $GROUPED créditos y el resumen $SUMMARY"
+  FAILED=1
+fi
+
+echo ""
+echo "🚫 Validación de la tipología"
+ERROR=$(curl -s -X POST "$API_URL/api/study-plans/$PLAN_ID/elective-groups" \
+  -H "Content-Type: application/json" \
+  -d '{"name": "Obligatorias", "tipologia": "DISCIPLINAR OBLIGATORIA", "min_credits": 3, "subject_codes": ["3010435"]}' | jq -r '.error // empty')
+if [ -n "$ERROR" ]; then
+  echo "   ✅ Agrupación con tipología obligatoria rechazada: $ERROR"
+else
+  echo "   ❌ Se creó una agrupación con tipología DISCIPLINAR OBLIGATORIA"
+  FAILED=1
+fi
+
+ERROR=$(curl -s -X POST "$API_URL/api/study-plans/$PLAN_ID/elective-groups" \
+  -H "Content-Type: application/json" \
+  -d '{"name": "Mezclada", "tipologia": "DISCIPLINAR OPTATIVA", "min_credits": 3, "subject_codes": ["3007854", "3010435"]}' | jq -r '.error // empty')
+if [ -n "$ERROR" ]; then
+  echo "   ✅ Materia obligatoria en una agrupación optativa rechazada: $ERROR"
+else
+  echo "   ❌ Se aceptó una materia DISCIPLINAR OBLIGATORIA en una agrupación optativa"
+  FAILED=1
+fi
+
+ERROR=$(curl -s -X PUT "$API_URL/api/elective-groups/$GROUP_ID" \
+  -H "Content-Type: application/json" \
+  -d '{"tipologia": "FUND. OPTATIVA"}' | jq -r '.error // empty')
+if [ -n "$ERROR" ]; then
+  echo "   ✅ Cambio de tipología incoherente con las materias rechazado: $ERROR"
+else
+  echo "   ❌ Se cambió la tipología de la agrupación aunque sus materias son DISCIPLINAR OPTATIVA"
+  FAILED=1
+fi
+
+echo ""
+echo "🧹 Eliminando la agrupación de prueba"
+curl -s -X DELETE "$API_URL/api/elective-groups/$GROUP_ID" | jq -r '.message // .error'
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
@@ -0,0 +1,94 @@
+#!/bin/bash
+
+# Script para probar las equivalencias encadenadas entre versiones del plan y la detección de ciclos
+# Usa el plan de Ingeniería de Sistemas (ISIS) cargado con scripts/seed_ing_sistemas.go,
+# que trae la equivalencia 3006914 (Estadística I antigua) → 3010651 (Estadística I)
+
+API_URL=${API_URL:-http://localhost:8080}
+CAREER="ISIS"
+FAILED=0
+
+echo "🧪 Probando equivalencias encadenadas..."
+echo ""
+
+CAREER_ID=$(curl -s "$API_URL/api/careers/$CAREER/study-plans" | jq '[.study_plans[] | select(.IsActive)][0].CareerID')
+SUBJECTS=$(curl -s "$API_URL/api/subjects")
+ESTADISTICA_ANTIGUA=$(echo "$SUBJECTS" | jq '.subjects[] | select(.Code == "3006914") | .ID')
+
+# 9200001 es del plan más antiguo: equivale a 3006914, que a su vez equivale a 3010651
+echo "📝 Creando la equivalencia 9200001 → 3006914"
+CREATED=$(curl -s -X POST "$API_URL/api/equivalences" \
+  -H "Content-Type: application/json" \
+  -d "{
+    \"source_subject\": {\"code\": \"9200001\", \"name\": \"Probabilidad y Estadística (Plan 1990)\", \"type\": \"FUND. OBLIGATORIA\", \"credits\": 3},
+    \"target_subject_id\": $ESTADISTICA_ANTIGUA,
+    \"career_id\": $CAREER_ID,
+    \"type\": \"TOTAL\",
+    \"notes\": \"Prueba de equivalencia encadenada\"
+  }")
+CHAIN_ID=$(echo "$CREATED" | jq '.equivalence.ID')
+NEW_SUBJECT=$(echo "$CREATED" | jq '.equivalence.SourceSubjectID')
+if [ "$CHAIN_ID" = "null" ]; then
+  echo "   ❌ No se pudo crear la equivalencia"
+  echo "$CREATED" | jq '.'
+  exit 1
+fi
+echo "   ✅ Equivalencia creada con ID $CHAIN_ID"
+
+HISTORY='{"code": "9200001", "name": "Probabilidad y Estadística (Plan 1990)", "credits": 3, "type": "FUND. OBLIGATORIA", "grade": 3.9, "status": "APROBADA", "semester": "2015-1S"}'
+RESPONSE=$(curl -s -X POST "$API_URL/api/compare-by-career" \
+  -H "Content-Type: application/json" \
+  -d "{\"career_code\": \"$CAREER\", \"subjects\": [$HISTORY]}")
+
+echo ""
+echo "🔗 Cadena que justifica Estadística I:"
+echo "$RESPONSE" | jq -r '.comparison_result.equivalent_subjects[] | select(.code == "3010651") | .equivalence.chain[] | "   #\(.id) \(.source_codes | join(" + ")) → \(.target_code) (\(.type))"'
+
+CHAIN=$(echo "$RESPONSE" | jq -c '.comparison_result.equivalent_subjects[] | select(.code == "3010651") | [.equivalence.chain[] | .target_code]')
+if [ "$CHAIN" = '["3006914","3010651"]' ]; then
+  echo "   ✅ Estadística I aprobada a través de 3006914"
+else
+  echo "   ❌ Cadena inesperada: $CHAIN"
+  FAILED=1
+fi
+
+# 3010651 → 9200001 cierra el ciclo 9200001 → 3006914 → 3010651 → 9200001
+echo ""
+echo "📝 Creando la equivalencia 3010651 → 9200001 (cierra un ciclo)"
+CYCLE=$(curl -s -X POST "$API_URL/api/equivalences" \
+  -H "Content-Type: application/json" \
+  -d "{
+    \"source_subject\": {\"code\": \"3010651\", \"name\": \"Estadística I\", \"type\": \"FUND. OBLIGATORIA\", \"credits\": 3},
+    \"target_subject_id\": $NEW_SUBJECT,
+    \"career_id\": $CAREER_ID,
+    \"type\": \"TOTAL\",
+    \"notes\": \"Prueba de ciclo de equivalencias\"
+  }")
+CYCLE_ID=$(echo "$CYCLE" | jq '.equivalence.ID')
+
+STATUS=$(curl -s -o /tmp/equivalence_cycle.json -w "%{http_code}" --max-time 10 -X POST "$API_URL/api/compare-by-career" \
+  -H "Content-Type: application/json" \
+  -d "{\"career_code\": \"$CAREER\", \"subjects\": [{\"code\": \"9000001\", \"name\": \"Apreciación musical\", \"credits\": 3, \"type\": \"LIBRE ELECCIÓN\", \"grade\": 4.5, \"status\": \"APROBADA\", \"semester\": \"2022-1S\"}]}")
+PENDING=$(jq -r '.comparison_result.missing_subjects[] | select(.code == "3010651") | .status' /tmp/equivalence_cycle.json 2>/dev/null)
+if [ "$STATUS" = "200" ] && [ "$PENDING" = "PENDIENTE" ]; then
+  echo "   ✅ La comparación termina con el ciclo y Estadística I sigue pendiente"
+else
+  echo "   ❌ Respuesta inesperada con el ciclo: HTTP $STATUS, estado \"$PENDING\""
+  FAILED=1
+fi
+rm -f /tmp/equivalence_cycle.json
+
+echo ""
+echo "🧹 Eliminando las equivalencias de prueba"
+curl -s -X DELETE "$API_URL/api/equivalences/$CHAIN_ID" | jq -r '.message // .error'
+if [ "$CYCLE_ID" != "null" ]; then
+  curl -s -X DELETE "$API_URL/api/equivalences/$CYCLE_ID" | jq -r '.message // .error'
+fi
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
@@ -0,0 +1,142 @@
+#!/bin/bash
+
+# Script para probar las equivalencias compuestas (varias materias a una) y parciales
+# Usa el plan de Ingeniería de Sistemas (ISIS) cargado con scripts/seed_ing_sistemas.go
+
+API_URL=${API_URL:-http://localhost:8080}
+CAREER="ISIS"
+FAILED=0
+
+echo "🧪 Probando equivalencias compuestas y parciales..."
+echo ""
+
+CAREER_ID=$(curl -s "$API_URL/api/careers/$CAREER/study-plans" | jq '[.study_plans[] | select(.IsActive)][0].CareerID')
+SUBJECTS=$(curl -s "$API_URL/api/subjects")
+SISTEMAS_OPERATIVOS=$(echo "$SUBJECTS" | jq '.subjects[] | select(.Code == "3007867") | .ID')
+REDES=$(echo "$SUBJECTS" | jq '.subjects[] | select(.Code == "3007865") | .ID')
+
+# 9100001 + 3007746 (materias del plan anterior) equivalen a Sistemas Operativos
+echo "📝 Creando la equivalencia compuesta 9100001 + 3007746 → 3007867"
+COMPUESTA=$(curl -s -X POST "$API_URL/api/equivalences" \
+  -H "Content-Type: application/json" \
+  -d "{
+    \"source_subject\": {\"code\": \"9100001\", \"name\": \"Sistemas Operativos I (Antigua)\", \"type\": \"DISCIPLINAR OBLIGATORIA\", \"credits\": 2},
+    \"extra_source_codes\": [\"3007746\"],
+    \"target_subject_id\": $SISTEMAS_OPERATIVOS,
+    \"career_id\": $CAREER_ID,
+    \"type\": \"TOTAL\",
+    \"notes\": \"Prueba de equivalencia compuesta\"
+  }")
+COMPUESTA_ID=$(echo "$COMPUESTA" | jq '.equivalence.ID')
+if [ "$COMPUESTA_ID" = "null" ]; then
+  echo "   ❌ No se pudo crear la equivalencia compuesta"
+  echo "$COMPUESTA" | jq '.'
+  exit 1
+fi
+echo "   ✅ Equivalencia creada con ID $COMPUESTA_ID"
+
+# 9100003 abona 2 de los 3 créditos de Redes y Telecomunicaciones I
+echo "📝 Creando la equivalencia parcial 9100003 → 3007865 (2 créditos)"
+PARCIAL=$(curl -s -X POST "$API_URL/api/equivalences" \
+  -H "Content-Type: application/json" \
+  -d "{
+    \"source_subject\": {\"code\": \"9100003\", \"name\": \"Teleinformática (Antigua)\", \"type\": \"DISCIPLINAR OBLIGATORIA\", \"credits\": 2},
+    \"target_subject_id\": $REDES,
+    \"career_id\": $CAREER_ID,
+    \"type\": \"PARCIAL\",
+    \"credits\": 2,
+    \"notes\": \"Prueba de equivalencia parcial\"
+  }")
+PARCIAL_ID=$(echo "$PARCIAL" | jq '.equivalence.ID')
+if [ "$PARCIAL_ID" = "null" ]; then
+  echo "   ❌ No se pudo crear la equivalencia parcial"
+  echo "$PARCIAL" | jq '.'
+  exit 1
+fi
+echo "   ✅ Equivalencia creada con ID $PARCIAL_ID"
+
+compare() {
+  curl -s -X POST "$API_URL/api/compare-by-career" \
+    -H "Content-Type: application/json" \
+    -d "{\"career_code\": \"$CAREER\", \"subjects\": [$1]}"
+}
+
+echo ""
+echo "🔍 Solo una de las materias de la equivalencia compuesta"
+RESPONSE=$(compare '{"code": "9100001", "name": "Sistemas Operativos I (Antigua)", "credits": 2, "type": "DISCIPLINAR OBLIGATORIA", "grade": 4.0, "status": "APROBADA", "semester": "2019-1S"}')
+STATUS=$(echo "$RESPONSE" | jq -r '.comparison_result.missing_subjects[] | select(.code == "3007867") | .status')
+if [ "$STATUS" = "PENDIENTE" ]; then
+  echo "   ✅ Sistemas Operativos sigue pendiente"
+else
+  echo "   ❌ Se esperaba PENDIENTE, se obtuvo \"$STATUS\""
+  FAILED=1
+fi
+
+echo ""
+echo "🔍 Las dos materias de la equivalencia compuesta y la de la parcial"
+RESPONSE=$(compare '{"code": "9100001", "name": "Sistemas Operativos I (Antigua)", "credits": 2, "type": "DISCIPLINAR OBLIGATORIA", "grade": 4.0, "status": "APROBADA", "semester": "2019-1S"},
+  {"code": "3007746", "name": "Materia Antigua 3007746", "credits": 3, "type": "DISCIPLINAR OBLIGATORIA", "grade": 3.6, "status": "APROBADA", "semester": "2019-2S"},
+  {"code": "9100003", "name": "Teleinformática (Antigua)", "credits": 2, "type": "DISCIPLINAR OBLIGATORIA", "grade": 4.4, "status": "APROBADA", "semester": "2019-2S"}')
+echo "$RESPONSE" | jq -r '.comparison_result | (.equivalent_subjects + .missing_subjects)[] | select(.equivalence) | "   \(.code) \(.name): \(.status) - \(.equivalence.type), \(.equivalence.credits) créditos (\(.equivalence.notes))"'
+
+SO=$(echo "$RESPONSE" | jq -c '.comparison_result.equivalent_subjects[] | select(.code == "3007867") | {status, type: .equivalence.type, sources: .equivalence.source_codes}')
+if [ "$SO" = '{"status":"APROBADA","type":"TOTAL","sources":["9100001","3007746"]}' ]; then
+  echo "   ✅ Sistemas Operativos aprobada por la equivalencia compuesta"
+else
+  echo "   ❌ Resultado inesperado para Sistemas Operativos: $SO"
+  FAILED=1
+fi
+
+REDES_RESULT=$(echo "$RESPONSE" | jq -c '.comparison_result.missing_subjects[] | select(.code == "3007865") | {status, credits: .equivalence.credits}')
+LEDGER=$(echo "$RESPONSE" | jq '[.comparison_result.credit_ledger[] | select(.plan_code == "3007865") | .credits] | add')
+if [ "$REDES_RESULT" = '{"status":"PARCIAL","credits":2}' ] && [ "$LEDGER" = "2" ]; then
+  echo "   ✅ Redes y Telecomunicaciones I abona 2 créditos y sigue pendiente"
+else
+  echo "   ❌ Resultado inesperado para Redes: $REDES_RESULT, créditos en el libro: $LEDGER"
+  FAILED=1
+fi
+
+echo ""
+echo "🔍 La materia de la equivalencia parcial en curso"
+RESPONSE=$(compare '{"code": "9100003", "name": "Teleinformática (Antigua)", "credits": 2, "type": "DISCIPLINAR OBLIGATORIA", "grade": 0, "status": "INSCRITA", "semester": "2024-1S"}')
+PROJECTED=$(echo "$RESPONSE" | jq '.comparison_result.projected_credits')
+if [ "$PROJECTED" = "2" ]; then
+  echo "   ✅ Redes y Telecomunicaciones I proyecta solo los 2 créditos de la equivalencia parcial"
+else
+  echo "   ❌ Se esperaban 2 créditos proyectados, se obtuvo $PROJECTED"
+  FAILED=1
+fi
+
+echo ""
+echo "🚫 Validaciones"
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X PUT "$API_URL/api/equivalences/$PARCIAL_ID" \
+  -H "Content-Type: application/json" \
+  -d '{"type": "EQUIVALENTE"}')
+if [ "$STATUS" = "400" ]; then
+  echo "   ✅ Un tipo de equivalencia desconocido se rechazó con 400"
+else
+  echo "   ❌ Se esperaba 400 para el tipo desconocido, se obtuvo $STATUS"
+  FAILED=1
+fi
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X PUT "$API_URL/api/equivalences/$PARCIAL_ID" \
+  -H "Content-Type: application/json" \
+  -d '{"credits": 3}')
+if [ "$STATUS" = "400" ]; then
+  echo "   ✅ Una equivalencia parcial no puede abonar todos los créditos de la materia destino"
+else
+  echo "   ❌ Se esperaba 400 para los créditos de la parcial, se obtuvo $STATUS"
+  FAILED=1
+fi
+
+echo ""
+echo "🧹 Eliminando las equivalencias de prueba"
+curl -s -X DELETE "$API_URL/api/equivalences/$COMPUESTA_ID" | jq -r '.message // .error'
+curl -s -X DELETE "$API_URL/api/equivalences/$PARCIAL_ID" | jq -r '.message // .error'
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
@@ -0,0 +1,136 @@
+#!/bin/bash
+
+# Script para probar el registro de decisiones de la comparación (?explain=true)
+# Usa el plan de Ingeniería de Sistemas (ISIS) cargado con scripts/seed_ing_sistemas.go
+
+API_URL=${API_URL:-http://localhost:8080}
+CAREER="ISIS"
+FAILED=0
+
+echo "🧪 Probando el registro de decisiones de la comparación..."
+echo ""
+
+# "3010435 " trae un espacio al final, 3006914 es equivalente a 3010651 y 3007744 está reprobada
+HISTORY="{
+  \"career_code\": \"$CAREER\",
+  \"subjects\": [
+    {\"code\": \"3010435 \", \"name\": \"Fundamentos de Programación\", \"credits\": 3, \"type\": \"DISCIPLINAR OBLIGATORIA\", \"grade\": 4.3, \"status\": \"APROBADA\", \"semester\": \"2022-1S\"},
+    {\"code\": \"3006914\", \"name\": \"Estadística I (Antigua)\", \"credits\": 3, \"type\": \"FUND. OBLIGATORIA\", \"grade\": 3.7, \"status\": \"APROBADA\", \"semester\": \"2021-2S\"},
+    {\"code\": \"3007744\", \"name\": \"Programación Orientada a Objetos\", \"credits\": 3, \"type\": \"DISCIPLINAR OBLIGATORIA\", \"grade\": 2.1, \"status\": \"REPROBADA\", \"semester\": \"2022-2S\"}
+  ]
+}"
+
+RESPONSE=$(curl -s -X POST "$API_URL/api/compare-by-career?explain=true" \
+  -H "Content-Type: application/json" \
+  -d "$HISTORY")
+
+echo "📜 Registro de decisiones (sin las materias sin coincidencia):"
+echo "$RESPONSE" | jq -r '.comparison_result.decision_log[] | select(.kind != "SIN COINCIDENCIA") | "   \(.seq). [\(.kind)] \(.plan_code // .history_code): \(.message)"'
+echo ""
+
+SEQ=$(echo "$RESPONSE" | jq '[.comparison_result.decision_log[].seq] == [range(1; (.comparison_result.decision_log | length) + 1)]')
+if [ "$SEQ" = "true" ]; then
+  echo "   ✅ Las decisiones se reportan en orden"
+else
+  echo "   ❌ Las decisiones no están numeradas en orden"
+  FAILED=1
+fi
+
+KINDS=$(echo "$RESPONSE" | jq -c '[.comparison_result.equivalent_subjects[] | select(.code == "3010435") | .decisions[].kind]')
+if [ "$KINDS" = '["CÓDIGO NORMALIZADO","COINCIDENCIA DIRECTA"]' ]; then
+  echo "   ✅ 3010435: el código con espacio se normalizó y coincidió directamente"
+else
+  echo "   ❌ Decisiones inesperadas para 3010435: $KINDS"
+  FAILED=1
+fi
+
+EQUIVALENCE=$(echo "$RESPONSE" | jq -c '.comparison_result.equivalent_subjects[] | select(.code == "3010651") | .decisions[] | select(.kind == "EQUIVALENCIA") | {id: (.equivalence_id != null), direction}')
+if [ "$EQUIVALENCE" = '{"id":true,"direction":"ORIGEN → DESTINO"}' ]; then
+  echo "   ✅ 3010651: se indica la equivalencia aplicada y su sentido"
+else
+  echo "   ❌ Decisión inesperada para 3010651: $EQUIVALENCE"
+  FAILED=1
+fi
+
+REJECTED=$(echo "$RESPONSE" | jq -r '.comparison_result.missing_subjects[] | select(.code == "3007744") | .decisions[] | select(.kind == "CANDIDATA DESCARTADA") | .history_code')
+if [ "$REJECTED" = "3007744" ]; then
+  echo "   ✅ 3007744: el intento reprobado se reporta como candidata descartada"
+else
+  echo "   ❌ No se reportó el intento reprobado de 3007744"
+  FAILED=1
+fi
+
+echo ""
+echo "🔤 Varias formas del mismo código"
+# Dos intentos de Cálculo Diferencial con el código escrito de forma distinta: cada variante
+# de la historia debe tener su propia decisión de normalización
+VARIANTS=$(curl -s -X POST "$API_URL/api/compare-by-career?explain=true" \
+  -H "Content-Type: application/json" \
+  -d "{
+    \"career_code\": \"$CAREER\",
+    \"subjects\": [
+      {\"code\": \"1000004 - m\", \"name\": \"Cálculo Diferencial\", \"credits\": 4, \"type\": \"FUND. OBLIGATORIA\", \"grade\": 2.4, \"status\": \"REPROBADA\", \"semester\": \"2021-2S\"},
+      {\"code\": \"01000004-M\", \"name\": \"Cálculo Diferencial\", \"credits\": 4, \"type\": \"FUND. OBLIGATORIA\", \"grade\": 3.9, \"status\": \"APROBADA\", \"semester\": \"2022-1S\"}
+    ]
+  }")
+NORMALIZED=$(echo "$VARIANTS" | jq '[.comparison_result.decision_log[] | select(.kind == "CÓDIGO NORMALIZADO" and .history_code == "1000004-M")] | length')
+if [ "$NORMALIZED" = "2" ]; then
+  echo "   ✅ Las dos variantes de 1000004-M tienen su decisión de normalización"
+else
+  echo "   ❌ Se esperaban 2 decisiones de normalización para 1000004-M, se obtuvieron $NORMALIZED"
+  FAILED=1
+fi
+
+echo ""
+echo "🎓 Registro de decisiones en doble titulación"
+# 3010435 se homologa directamente; 1000004-M ya está aprobada en la historia de doble titulación
+DOBLE=$(curl -s -X POST "$API_URL/api/doble-titulacion/estructurada?explain=true" \
+  -H "Content-Type: application/json" \
+  -d "{
+    \"codigo_carrera_objetivo\": \"$CAREER\",
+    \"materias_origen\": [
+      {\"code\": \"3010435\", \"name\": \"Fundamentos de Programación\", \"credits\": 3, \"type\": \"DISCIPLINAR OBLIGATORIA\", \"grade\": 4.3, \"status\": \"APROBADA\", \"semester\": \"2022-1S\"},
+      {\"code\": \"1000004-M\", \"name\": \"Cálculo Diferencial\", \"credits\": 4, \"type\": \"FUND. OBLIGATORIA\", \"grade\": 4.0, \"status\": \"APROBADA\", \"semester\": \"2022-1S\"}
+    ],
+    \"materias_doble\": [
+      {\"code\": \"1000004-M\", \"name\": \"Cálculo Diferencial\", \"credits\": 4, \"type\": \"FUND. OBLIGATORIA\", \"grade\": 4.0, \"status\": \"APROBADA\", \"semester\": \"2022-1S\"}
+    ]
+  }")
+DIRECT=$(echo "$DOBLE" | jq -r '.resultado.decision_log[] | select(.plan_code == "3010435") | .kind')
+DISCARDED=$(echo "$DOBLE" | jq -r '.resultado.decision_log[] | select(.plan_code == "1000004-M") | .kind' | tail -1)
+if [ "$DIRECT" = "COINCIDENCIA DIRECTA" ] && [ "$DISCARDED" = "CANDIDATA DESCARTADA" ]; then
+  echo "   ✅ 3010435 se homologa por coincidencia directa y 1000004-M se descarta por estar en la historia doble"
+else
+  echo "   ❌ Decisiones inesperadas: 3010435 $DIRECT, 1000004-M $DISCARDED"
+  FAILED=1
+fi
+
+DOBLE_TEXT=$(jq -n --rawfile text "$(dirname "$0")/testdata/historia_academica_sia.txt" --arg career "$CAREER" \
+  '{historia_origen: $text, historia_doble: $text, codigo_carrera_objetivo: $career, explain: true}' | \
+  curl -s -X POST "$API_URL/api/doble-titulacion" -H "Content-Type: application/json" -d @-)
+if [ "$(echo "$DOBLE_TEXT" | jq '.resultado.decision_log | length > 0')" = "true" ]; then
+  echo "   ✅ /api/doble-titulacion acepta explain en el cuerpo y trae decision_log"
+else
+  echo "   ❌ /api/doble-titulacion no trae decision_log: $(echo "$DOBLE_TEXT" | jq -c '.error // .resultado.resumen')"
+  FAILED=1
+fi
+
+echo ""
+echo "🔍 Sin explain no se incluye el registro"
+PLAIN=$(curl -s -X POST "$API_URL/api/compare-by-career" \
+  -H "Content-Type: application/json" \
+  -d "$HISTORY")
+if [ "$(echo "$PLAIN" | jq '.comparison_result | has("decision_log")')" = "false" ]; then
+  echo "   ✅ La respuesta no trae decision_log"
+else
+  echo "   ❌ La respuesta trae decision_log sin haberlo pedido"
+  FAILED=1
+fi
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
@@ -0,0 +1,57 @@
+#!/bin/bash
+
+# Script para probar la detección automática del formato de la historia académica
+# Cada archivo debe parsearse con el formato indicado y producir el número de materias esperado
+
+API_URL=${API_URL:-http://localhost:8080}
+FIXTURES="$(dirname "$0")/testdata"
+FAILED=0
+
+echo "🧪 Probando la detección de formatos de historia académica..."
+echo ""
+
+echo "📋 Formatos registrados: $(curl -s "$API_URL/api/parse/formats" | jq -r '.formats | join(", ")')"
+echo ""
+
+check() {
+  local FILE=$1
+  local FORMAT=$2
+  local SUBJECTS=$3
+  local RESPONSE=$(curl -s -X POST "$API_URL/api/parse" \
+    -H "Content-Type: application/json" \
+    -d "$(jq -n --rawfile history "$FIXTURES/$FILE" '{academic_history_text: $history}')")
+  local DETECTED=$(echo "$RESPONSE" | jq -r '.format')
+  local COUNT=$(echo "$RESPONSE" | jq '.subjects | length')
+
+  if [ "$DETECTED" = "$FORMAT" ] && [ "$COUNT" = "$SUBJECTS" ]; then
+    echo "   ✅ $FILE: $DETECTED, $COUNT materias"
+  else
+    echo "   ❌ $FILE: se esperaba $FORMAT con $SUBJECTS materias, se obtuvo $DETECTED con $COUNT"
+    FAILED=1
+  fi
+}
+
+check historia_academica_sia.txt sia_text 16
+check historia_academica_sia.html sia_html 16
+check historia_academica_tabulada.txt sia_tabular 16
+check historia_externa.csv generic_table 4
+
+echo ""
+echo "⚠️  Las filas inválidas de la tabla genérica se reportan como advertencias"
+RESPONSE=$(curl -s -X POST "$API_URL/api/parse" \
+  -H "Content-Type: application/json" \
+  -d "$(jq -n --rawfile history "$FIXTURES/historia_externa.csv" '{academic_history_text: $history}')")
+if [ "$(echo "$RESPONSE" | jq '[.warnings[] | select(.line == 5 and .code == "missing_credits")] | length')" = "1" ]; then
+  echo "   ✅ Línea 5: créditos inválidos reportados"
+else
+  echo "   ❌ No se reportó la línea 5: $(echo "$RESPONSE" | jq -c '.warnings')"
+  FAILED=1
+fi
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
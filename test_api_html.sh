@@ -0,0 +1,49 @@
+#!/bin/bash
+
+# Script para probar el envío del HTML de la página "Historia Académica" del SIA
+# El HTML de testdata/ debe producir las mismas materias que el texto copiado del portal
+
+API_URL=${API_URL:-http://localhost:8080}
+FIXTURE="$(dirname "$0")/testdata/historia_academica_sia.html"
+EXPECTED_SUBJECTS=16
+
+echo "🧪 Probando historia académica en HTML..."
+echo ""
+
+JSON_DATA=$(jq -n \
+  --rawfile history "$FIXTURE" \
+  --arg career "ISIS" \
+  '{
+    "academic_history_text": $history,
+    "target_career_code": $career
+  }')
+
+echo "📤 /api/api-compare con $(basename "$FIXTURE")"
+RESPONSE=$(curl -s -X POST "$API_URL/api/api-compare" \
+  -H "Content-Type: application/json" \
+  -d "$JSON_DATA")
+
+SUBJECTS=$(echo "$RESPONSE" | jq '.parsed_subjects | length')
+WARNINGS=$(echo "$RESPONSE" | jq '.parse_warnings | length')
+
+echo "📤 /api/historia-academica con $(basename "$FIXTURE")"
+HEADER=$(curl -s -X POST "$API_URL/api/historia-academica" \
+  -H "Content-Type: application/json" \
+  -d "$(jq -n --rawfile historia "$FIXTURE" '{historia: $historia}')")
+
+PLAN=$(echo "$HEADER" | jq -r '.plan_estudios')
+SUMMARY_ROWS=$(echo "$HEADER" | jq '.resumen_creditos | length')
+# Los cursos de inglés de la historia se aprobaron por validación
+VALIDATED=$(echo "$HEADER" | jq '[.asignaturas[] | select(.modalidad == "VALIDACIÓN")] | length')
+
+echo ""
+if [ "$SUBJECTS" = "$EXPECTED_SUBJECTS" ] && [ "$WARNINGS" = "0" ] && [ "$PLAN" = "INGENIERÍA DE MINAS Y METALURGIA" ] && [ "$SUMMARY_ROWS" = "9" ] && [ "$VALIDATED" = "2" ]; then
+  echo "   ✅ $SUBJECTS materias, sin advertencias, plan \"$PLAN\", $SUMMARY_ROWS filas de resumen y $VALIDATED materias por validación"
+  echo ""
+  echo "✅ Prueba completada"
+else
+  echo "   ❌ Resultado inesperado"
+  echo "$RESPONSE" | jq '{error, parse_warnings, total: (.parsed_subjects | length)}'
+  echo "$HEADER" | jq '{error, plan_estudios, resumen_creditos, modalidades: [.asignaturas[].modalidad]}'
+  exit 1
+fi
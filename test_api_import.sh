@@ -0,0 +1,67 @@
+#!/bin/bash
+
+# Script para probar la importación de historias académicas estructuradas (CSV, XLSX y JSON)
+# Cada archivo de testdata/ tiene 6 filas: 4 válidas y 2 con errores (créditos inválidos; código, nota y periodo inválidos)
+
+API_URL=${API_URL:-http://localhost:8080}
+FIXTURES="$(dirname "$0")/testdata"
+CAREER="ISIS"
+FAILED=0
+
+echo "🧪 Probando importación de historia académica estructurada..."
+echo ""
+
+check() {
+  local NAME=$1
+  local RESPONSE=$2
+  local IMPORTED=$(echo "$RESPONSE" | jq '.import.subjects | length')
+  local ERROR_ROWS=$(echo "$RESPONSE" | jq '[.import.errors[].row] | unique | length')
+
+  if [ "$IMPORTED" = "4" ] && [ "$ERROR_ROWS" = "2" ]; then
+    echo "   ✅ $NAME: 4 materias importadas, 2 filas con errores"
+  else
+    echo "   ❌ $NAME: se esperaban 4 materias y 2 filas con errores"
+    echo "$RESPONSE" | jq '{error, import}'
+    FAILED=1
+  fi
+}
+
+echo "📤 CSV (separado por punto y coma, columnas detectadas por encabezado)"
+check "CSV" "$(curl -s -X POST "$API_URL/api/import-compare" \
+  -F "file=@$FIXTURES/historia_externa.csv" \
+  -F "career_code=$CAREER")"
+
+echo "📤 XLSX (hoja \"Historia\", mapeo explícito de columnas)"
+check "XLSX" "$(curl -s -X POST "$API_URL/api/import-compare" \
+  -F "file=@$FIXTURES/historia_externa.xlsx" \
+  -F "career_code=$CAREER" \
+  -F "sheet=Historia" \
+  -F 'mapping={"code": "Código", "name": "Asignatura", "credits": "Créditos", "type": "Tipología", "grade": "Nota final", "status": "Estado", "semester": "Periodo"}')"
+
+echo "📤 JSON en el cuerpo de la petición"
+check "JSON" "$(curl -s -X POST "$API_URL/api/import-compare" \
+  -H "Content-Type: application/json" \
+  -d "$(jq -n --slurpfile rows "$FIXTURES/historia_externa.json" --arg career "$CAREER" \
+    '{career_code: $career, mapping: {grade: "nota"}, rows: $rows[0]}')")"
+
+echo ""
+echo "📤 Fila sin tipología"
+RESPONSE=$(curl -s -X POST "$API_URL/api/import-compare" \
+  -H "Content-Type: application/json" \
+  -d "{\"career_code\": \"$CAREER\", \"rows\": [{\"codigo\": \"9000001\", \"nombre\": \"Apreciación musical\", \"creditos\": 3, \"nota\": 4.5, \"periodo\": \"2021-1S\"}]}")
+TYPE=$(echo "$RESPONSE" | jq -r '.import.subjects[0].type')
+WARNING=$(echo "$RESPONSE" | jq -r '.import.warnings[0].field')
+if [ "$TYPE" = "" ] && [ "$WARNING" = "type" ]; then
+  echo "   ✅ Se importó sin tipología y con una advertencia en lugar de suponer LIBRE ELECCIÓN"
+else
+  echo "   ❌ Se esperaba la materia sin tipología y una advertencia: tipología \"$TYPE\", advertencia \"$WARNING\""
+  FAILED=1
+fi
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
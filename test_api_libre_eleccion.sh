@@ -0,0 +1,77 @@
+#!/bin/bash
+
+# Script para probar el abono de materias fuera del plan a libre elección y el reporte de créditos sin usar
+# Usa el plan de Ingeniería de Sistemas (ISIS) cargado con scripts/seed_ing_sistemas.go
+
+API_URL=${API_URL:-http://localhost:8080}
+CAREER="ISIS"
+FAILED=0
+
+echo "🧪 Probando libre elección y créditos excedentes..."
+echo ""
+
+# 9000001 y 9000002 no están en el plan ni tienen equivalencia; el curso de nivelación no cuenta para el grado
+RESPONSE=$(curl -s -X POST "$API_URL/api/compare-by-career" \
+  -H "Content-Type: application/json" \
+  -d "{
+    \"career_code\": \"$CAREER\",
+    \"subjects\": [
+      {\"code\": \"1000004-M\", \"name\": \"Cálculo Diferencial\", \"credits\": 4, \"type\": \"FUND. OBLIGATORIA\", \"grade\": 4.0, \"status\": \"APROBADA\", \"semester\": \"2022-1S\"},
+      {\"code\": \"9000001\", \"name\": \"Apreciación musical\", \"credits\": 3, \"type\": \"LIBRE ELECCIÓN\", \"grade\": 4.5, \"status\": \"APROBADA\", \"semester\": \"2022-1S\"},
+      {\"code\": \"9000002\", \"name\": \"Historia del arte\", \"credits\": 2, \"type\": \"LIBRE ELECCIÓN\", \"grade\": 3.8, \"status\": \"APROBADA\", \"semester\": \"2022-2S\"},
+      {\"code\": \"1000001-M\", \"name\": \"Matemáticas básicas\", \"credits\": 4, \"type\": \"NIVELACIÓN\", \"grade\": 4.1, \"status\": \"APROBADA\", \"semester\": \"2021-2S\"}
+    ]
+  }")
+
+echo "📊 Materias fuera del plan:"
+echo "$RESPONSE" | jq -r '.comparison_result.out_of_plan_subjects[] | "   \(.code) \(.name): \(.credits) créditos → \(.status)"'
+echo "📊 Créditos sin usar:"
+echo "$RESPONSE" | jq -r '.comparison_result.unused_credits[] | "   \(.history_code): \(.credits) créditos (\(.via)) - \(.notes)"'
+echo ""
+
+CODES=$(echo "$RESPONSE" | jq -c '[.comparison_result.out_of_plan_subjects[].code] | sort')
+if [ "$CODES" = '["9000001","9000002"]' ]; then
+  echo "   ✅ Solo las materias sin correspondencia en el plan se tratan como fuera del plan"
+else
+  echo "   ❌ Materias fuera del plan inesperadas: $CODES"
+  FAILED=1
+fi
+
+# Cada crédito fuera del plan se abona a libre elección o queda reportado como sin usar
+USED=$(echo "$RESPONSE" | jq '[.comparison_result.credit_ledger[] | select(.via == "FUERA DEL PLAN") | .credits] | add // 0')
+UNUSED=$(echo "$RESPONSE" | jq '[.comparison_result.unused_credits[] | select(.via == "FUERA DEL PLAN") | .credits] | add // 0')
+if [ $((USED + UNUSED)) = "5" ]; then
+  echo "   ✅ 5 créditos fuera del plan: $USED abonados a libre elección y $UNUSED sin usar"
+else
+  echo "   ❌ Se esperaban 5 créditos entre abonados ($USED) y sin usar ($UNUSED)"
+  FAILED=1
+fi
+
+LIBRE=$(echo "$RESPONSE" | jq '.comparison_result.credits_summary.libre')
+if [ "$(echo "$LIBRE" | jq '.completed <= .required')" = "true" ]; then
+  echo "   ✅ Libre elección no supera el cupo del plan: $(echo "$LIBRE" | jq -c '.')"
+else
+  echo "   ❌ Libre elección supera el cupo del plan: $(echo "$LIBRE" | jq -c '.')"
+  FAILED=1
+fi
+
+echo ""
+echo "🚫 Regla de excedentes inválida"
+PLAN_ID=$(curl -s "$API_URL/api/careers/$CAREER/study-plans" | jq '[.study_plans[] | select(.IsActive)][0].ID')
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X PUT "$API_URL/api/study-plans/$PLAN_ID/elective-overflow" \
+  -H "Content-Type: application/json" \
+  -d '{"elective_overflow": "OBLIGATORIA"}')
+if [ "$STATUS" = "400" ]; then
+  echo "   ✅ La regla inválida se rechazó con 400"
+else
+  echo "   ❌ Se esperaba 400, se obtuvo $STATUS"
+  FAILED=1
+fi
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
@@ -0,0 +1,53 @@
+#!/bin/bash
+
+# Script para probar la normalización del texto antes del parseo
+# Cada archivo de testdata/normalizacion/ es testdata/historia_academica_sia.txt con un problema de
+# codificación o formato (ver generar_fixtures.py) y debe producir las mismas materias que el original
+
+API_URL=${API_URL:-http://localhost:8080}
+FIXTURES="$(dirname "$0")/testdata"
+FAILED=0
+
+echo "🧪 Probando normalización de texto (mojibake, NFC, espacios y coma decimal)..."
+echo ""
+
+parse() {
+  curl -s -X POST "$API_URL/api/parse" \
+    -H "Content-Type: application/json" \
+    -d "$(jq -n --rawfile history "$1" '{academic_history_text: $history}')"
+}
+
+subjects() {
+  jq -c '[.subjects[] | {code, name, credits, type, grade, grade_label, status, semester, modality}]'
+}
+
+EXPECTED=$(parse "$FIXTURES/historia_academica_sia.txt" | subjects)
+echo "📄 historia_academica_sia.txt: $(echo "$EXPECTED" | jq 'length') materias de referencia"
+
+check() {
+  local FILE=$1
+  local FIX=$2
+  local RESPONSE=$(parse "$FIXTURES/normalizacion/$FILE")
+  local APPLIED=$(echo "$RESPONSE" | jq -r '.normalizations | join(",")')
+
+  if [ "$(echo "$RESPONSE" | subjects)" = "$EXPECTED" ] && [ "$APPLIED" = "$FIX" ]; then
+    echo "   ✅ $FILE: mismas materias, corrección $APPLIED"
+  else
+    echo "   ❌ $FILE: se esperaban las materias de referencia y la corrección $FIX (aplicadas: $APPLIED)"
+    diff <(echo "$EXPECTED" | jq '.') <(echo "$RESPONSE" | subjects | jq '.') | head -20
+    FAILED=1
+  fi
+}
+
+check mojibake.txt mojibake
+check nfd.txt nfc
+check espacios.txt whitespace
+check coma_decimal.txt decimal_comma
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
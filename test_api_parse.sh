@@ -0,0 +1,55 @@
+#!/bin/bash
+
+# Script para probar la vista previa del parseo (/api/parse) y el envío de la historia corregida a /api/compare-by-career
+# testdata/historia_academica_incompleta.txt tiene 4 materias; la última no tiene estado ni nota y debe quedar con baja confianza
+
+API_URL=${API_URL:-http://localhost:8080}
+FIXTURES="$(dirname "$0")/testdata"
+CAREER="ISIS"
+FAILED=0
+
+echo "🧪 Probando vista previa del parseo..."
+echo ""
+
+echo "📤 /api/parse con historia_academica_incompleta.txt"
+PREVIEW=$(curl -s -X POST "$API_URL/api/parse" \
+  -H "Content-Type: application/json" \
+  -d "$(jq -n --rawfile history "$FIXTURES/historia_academica_incompleta.txt" --arg career "$CAREER" \
+    '{academic_history_text: $history, career_code: $career}')")
+
+SUBJECTS=$(echo "$PREVIEW" | jq '.subjects | length')
+LOW=$(echo "$PREVIEW" | jq '[.subjects[] | select(.confidence < 0.7) | .code] | join(",")')
+
+if [ "$SUBJECTS" = "4" ] && [ "$LOW" = '"3000002"' ]; then
+  echo "   ✅ 4 materias; 3000002 marcada con baja confianza"
+  echo "$PREVIEW" | jq -r '.subjects[] | "      \(.code): \(.confidence) \(.issues // [] | join(", "))"'
+  echo "   📊 Confianza promedio: $(echo "$PREVIEW" | jq '.average_confidence')"
+else
+  echo "   ❌ Se esperaban 4 materias y solo 3000002 con baja confianza"
+  echo "$PREVIEW" | jq '{error, subjects, warnings}'
+  FAILED=1
+fi
+
+echo ""
+echo "✏️  Corrigiendo la materia 3000002 (estado APROBADA) y enviando a /api/compare-by-career"
+CORRECTED=$(echo "$PREVIEW" | jq '.history | .subjects |= map(if .code == "3000002" then .status = "APROBADA" | .grade = 4.0 else . end)')
+RESPONSE=$(curl -s -X POST "$API_URL/api/compare-by-career" \
+  -H "Content-Type: application/json" \
+  -d "$CORRECTED")
+
+if echo "$RESPONSE" | jq -e '.comparison_result' > /dev/null; then
+  echo "   ✅ Comparación realizada con la historia corregida"
+  echo "$RESPONSE" | jq '.summary'
+else
+  echo "   ❌ /api/compare-by-career no aceptó la historia corregida"
+  echo "$RESPONSE" | jq '.'
+  FAILED=1
+fi
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
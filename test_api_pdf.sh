@@ -0,0 +1,57 @@
+#!/bin/bash
+
+# Script para probar la carga del PDF oficial de la historia académica (SIA)
+# Usa los PDF de prueba de testdata/ y verifica que se parseen igual que el texto copiado del portal
+
+API_URL=${API_URL:-http://localhost:8080}
+FIXTURES="$(dirname "$0")/testdata"
+EXPECTED_SUBJECTS=16
+FAILED=0
+
+echo "🧪 Probando carga de historia académica en PDF..."
+echo ""
+
+for PDF in "$FIXTURES"/historia_academica_sia.pdf "$FIXTURES"/historia_academica_sia_type0.pdf; do
+  echo "📤 /api/api-compare con $(basename "$PDF")"
+
+  RESPONSE=$(curl -s -X POST "$API_URL/api/api-compare" \
+    -F "academic_history_pdf=@$PDF;type=application/pdf" \
+    -F "target_career_code=ISIS")
+
+  SUBJECTS=$(echo "$RESPONSE" | jq '.parsed_subjects | length')
+  WARNINGS=$(echo "$RESPONSE" | jq '.parse_warnings | length')
+  CONSISTENT_ROWS=$(echo "$RESPONSE" | jq '.credit_reconciliation.sia | length')
+
+  if [ "$SUBJECTS" = "$EXPECTED_SUBJECTS" ] && [ "$WARNINGS" = "0" ] && [ "$CONSISTENT_ROWS" = "9" ]; then
+    echo "   ✅ $SUBJECTS materias, sin advertencias, 9 filas de resumen de créditos"
+  else
+    echo "   ❌ Se esperaban $EXPECTED_SUBJECTS materias sin advertencias y 9 filas de resumen"
+    echo "$RESPONSE" | jq '{error, parse_warnings, total: (.parsed_subjects | length)}'
+    FAILED=1
+  fi
+done
+
+echo ""
+echo "📤 /api/doble-titulacion con ambas historias en PDF"
+
+RESPONSE=$(curl -s -X POST "$API_URL/api/doble-titulacion" \
+  -F "historia_origen_pdf=@$FIXTURES/historia_academica_sia.pdf;type=application/pdf" \
+  -F "historia_doble_pdf=@$FIXTURES/historia_academica_sia_type0.pdf;type=application/pdf" \
+  -F "codigo_carrera_objetivo=ISIS")
+
+if [ "$(echo "$RESPONSE" | jq '.success')" = "true" ]; then
+  echo "   ✅ Comparación de doble titulación realizada"
+  echo "$RESPONSE" | jq '.advertencias'
+else
+  echo "   ❌ La comparación de doble titulación falló"
+  echo "$RESPONSE" | jq '.'
+  FAILED=1
+fi
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
@@ -0,0 +1,151 @@
+#!/bin/bash
+
+# Script para probar la elección del plan de estudio por versión o periodo de admisión
+# y la comparación con todas las versiones del plan de la carrera
+# Usa el plan de Ingeniería de Sistemas (ISIS) cargado con scripts/seed_ing_sistemas.go (versión 2023-1)
+
+API_URL=${API_URL:-http://localhost:8080}
+CAREER="ISIS"
+FAILED=0
+
+echo "🧪 Probando las versiones del plan de estudio..."
+echo ""
+
+SUBJECTS='[
+  {"code": "1000004-M", "name": "Cálculo Diferencial", "credits": 4, "type": "FUND. OBLIGATORIA", "grade": 4.0, "status": "APROBADA", "semester": "2023-1S"},
+  {"code": "3010435", "name": "Fundamentos de Programación", "credits": 3, "type": "DISCIPLINAR OBLIGATORIA", "grade": 4.3, "status": "APROBADA", "semester": "2023-1S"}
+]'
+
+compare() {
+  curl -s -X POST "$API_URL/api/compare-by-career" \
+    -H "Content-Type: application/json" \
+    -d "{\"career_code\": \"$CAREER\", \"subjects\": $SUBJECTS $1}"
+}
+
+PLANS=$(curl -s "$API_URL/api/careers/$CAREER/study-plans")
+echo "📚 Planes de $CAREER:"
+echo "$PLANS" | jq -r '.study_plans[] | "   #\(.ID) versión \(.Version) (activo: \(.IsActive), admisión desde: \(.AdmissionFrom // "-"))"'
+echo ""
+
+echo "🔖 Plan por versión"
+VERSION=$(compare ', "plan_version": "2023-1"' | jq -r '.study_plan_info.version')
+if [ "$VERSION" = "2023-1" ]; then
+  echo "   ✅ Se usó el plan 2023-1"
+else
+  echo "   ❌ Se esperaba el plan 2023-1, se obtuvo $VERSION"
+  FAILED=1
+fi
+
+ERROR=$(compare ', "plan_version": "1999-9"' | jq -r '.error // empty')
+if [ -n "$ERROR" ]; then
+  echo "   ✅ Versión inexistente rechazada: $ERROR"
+else
+  echo "   ❌ La versión 1999-9 no se rechazó"
+  FAILED=1
+fi
+
+echo ""
+echo "📅 Plan por periodo de admisión"
+INFO=$(compare ', "admission_period": "2024-2S"' | jq -c '.study_plan_info')
+if [ "$(echo "$INFO" | jq -r '.version')" = "2023-1" ]; then
+  echo "   ✅ Admitido en 2024-2S: plan $(echo "$INFO" | jq -r '.version') (aplica desde $(echo "$INFO" | jq -r '.admission_start'))"
+else
+  echo "   ❌ Plan inesperado para 2024-2S: $INFO"
+  FAILED=1
+fi
+
+ERROR=$(compare ', "admission_period": "1990-1S"' | jq -r '.error // empty')
+if [ -n "$ERROR" ]; then
+  echo "   ✅ Sin plan vigente para 1990-1S: $ERROR"
+else
+  echo "   ❌ Se esperaba un error para el periodo 1990-1S"
+  FAILED=1
+fi
+
+ERROR=$(compare ', "admission_period": "primer semestre"' | jq -r '.error // empty')
+if [ -n "$ERROR" ]; then
+  echo "   ✅ Periodo inválido rechazado: $ERROR"
+else
+  echo "   ❌ El periodo \"primer semestre\" no se rechazó"
+  FAILED=1
+fi
+
+echo ""
+echo "🗂️  Comparación con todas las versiones"
+RESPONSE=$(curl -s -X POST "$API_URL/api/compare-plan-versions" \
+  -H "Content-Type: application/json" \
+  -d "{\"career_code\": \"$CAREER\", \"subjects\": $SUBJECTS, \"admission_period\": \"2023-1S\"}")
+echo "$RESPONSE" | jq -r '.versions[] | "   \(if .applies then "👉" else "  " end) \(.version): \(.completion_percentage | . * 10 | round / 10)% (\(.approved_subjects) aprobadas, \(.missing_subjects) pendientes)"'
+
+EXPECTED=$(echo "$PLANS" | jq '.study_plans | length')
+if [ "$(echo "$RESPONSE" | jq '.summary.versions_compared')" = "$EXPECTED" ]; then
+  echo "   ✅ Se compararon las $EXPECTED versiones"
+else
+  echo "   ❌ Se esperaban $EXPECTED versiones: $(echo "$RESPONSE" | jq -c '.error // .summary')"
+  FAILED=1
+fi
+if [ "$(echo "$RESPONSE" | jq '[.versions[] | select(.applies)] | length')" = "1" ]; then
+  echo "   ✅ Una sola versión aplica al estudiante: $(echo "$RESPONSE" | jq -r '.summary.applicable_version')"
+else
+  echo "   ❌ Se esperaba exactamente una versión aplicable"
+  FAILED=1
+fi
+
+echo ""
+echo "🎓 Plan objetivo de doble titulación"
+doble() {
+  curl -s -X POST "$API_URL/api/doble-titulacion/estructurada" \
+    -H "Content-Type: application/json" \
+    -d "{\"materias_origen\": $SUBJECTS, \"materias_doble\": [], \"codigo_carrera_objetivo\": \"$CAREER\" $1}"
+}
+VERSION=$(doble ', "admission_period": "2024-1S"' | jq -r '.resultado.version_plan_objetivo')
+if [ "$VERSION" = "2023-1" ]; then
+  echo "   ✅ Admitido en 2024-1S: plan objetivo 2023-1"
+else
+  echo "   ❌ Se esperaba el plan objetivo 2023-1, se obtuvo $VERSION"
+  FAILED=1
+fi
+ERROR=$(doble ', "plan_version": "1999-9"' | jq -r '.error // empty')
+if [ -n "$ERROR" ]; then
+  echo "   ✅ Versión inexistente rechazada: $ERROR"
+else
+  echo "   ❌ La versión 1999-9 no se rechazó en doble titulación"
+  FAILED=1
+fi
+
+echo ""
+echo "✏️  Periodo de admisión del plan"
+PLAN_ID=$(echo "$PLANS" | jq '.study_plans[] | select(.Version == "2023-1") | .ID')
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X PUT "$API_URL/api/study-plans/$PLAN_ID/admission-period" \
+  -H "Content-Type: application/json" \
+  -d '{"admission_from": "2023"}')
+if [ "$STATUS" = "400" ]; then
+  echo "   ✅ Periodo inválido rechazado con 400"
+else
+  echo "   ❌ Se esperaba 400, se obtuvo $STATUS"
+  FAILED=1
+fi
+
+UPDATED=$(curl -s -X PUT "$API_URL/api/study-plans/$PLAN_ID/admission-period" \
+  -H "Content-Type: application/json" \
+  -d '{"admission_from": "2023-2S"}' | jq -r '.study_plan.AdmissionFrom')
+ERROR=$(compare ', "admission_period": "2023-1S"' | jq -r '.error // empty')
+if [ "$UPDATED" = "2023-2S" ] && [ -n "$ERROR" ]; then
+  echo "   ✅ Con admisión desde 2023-2S el plan ya no aplica a los admitidos en 2023-1S"
+else
+  echo "   ❌ Respuesta inesperada: admisión \"$UPDATED\", error \"$ERROR\""
+  FAILED=1
+fi
+
+# Restaurar: sin periodo de admisión el plan vuelve a usar su versión
+curl -s -o /dev/null -X PUT "$API_URL/api/study-plans/$PLAN_ID/admission-period" \
+  -H "Content-Type: application/json" \
+  -d '{"admission_from": ""}'
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
@@ -0,0 +1,100 @@
+#!/bin/bash
+
+# Script para probar historias con varios planes de estudio (estudiantes que cambiaron de carrera)
+# testdata/historia_academica_dos_planes.* tiene el plan de Minas (16 materias) y el de Sistemas (3 materias)
+
+API_URL=${API_URL:-http://localhost:8080}
+FIXTURES="$(dirname "$0")/testdata"
+CAREER="ISIS"
+FAILED=0
+
+echo "🧪 Probando la separación de historias con varios planes..."
+echo ""
+
+for FILE in historia_academica_dos_planes.txt historia_academica_dos_planes.html; do
+  RESPONSE=$(curl -s -X POST "$API_URL/api/parse" \
+    -H "Content-Type: application/json" \
+    -d "$(jq -n --rawfile history "$FIXTURES/$FILE" '{academic_history_text: $history}')")
+  PLANS=$(echo "$RESPONSE" | jq -c '[.plans[] | {index, plan: .metadata.plan_name, materias: (.subjects | length)}]')
+  EXPECTED='[{"index":1,"plan":"INGENIERÍA DE MINAS Y METALURGIA","materias":16},{"index":2,"plan":"INGENIERÍA DE SISTEMAS Y COMPUTACIÓN","materias":3}]'
+
+  if [ "$PLANS" = "$EXPECTED" ]; then
+    echo "   ✅ $FILE: 2 planes separados"
+  else
+    echo "   ❌ $FILE: se esperaban 2 planes, se obtuvo $PLANS"
+    FAILED=1
+  fi
+done
+
+echo ""
+echo "🔀 Comparando solo el segundo plan con /api/api-compare"
+RESPONSE=$(curl -s -X POST "$API_URL/api/api-compare" \
+  -H "Content-Type: application/json" \
+  -d "$(jq -n --rawfile history "$FIXTURES/historia_academica_dos_planes.txt" --arg career "$CAREER" \
+    '{academic_history_text: $history, target_career_code: $career, plan: "2"}')")
+if [ "$(echo "$RESPONSE" | jq -r '.selected_plan')" = "INGENIERÍA DE SISTEMAS Y COMPUTACIÓN" ] && [ "$(echo "$RESPONSE" | jq '.parsed_subjects | length')" = "3" ]; then
+  echo "   ✅ Se compararon solo las 3 materias del plan de Sistemas"
+else
+  echo "   ❌ No se seleccionó el plan 2: $(echo "$RESPONSE" | jq -c '{error, selected_plan, available_plans}')"
+  FAILED=1
+fi
+
+echo ""
+echo "🚫 Comparando sin elegir plan"
+RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "$API_URL/api/api-compare" \
+  -H "Content-Type: application/json" \
+  -d "$(jq -n --rawfile history "$FIXTURES/historia_academica_dos_planes.txt" --arg career "$CAREER" \
+    '{academic_history_text: $history, target_career_code: $career}')")
+STATUS=$(echo "$RESPONSE" | tail -n 1)
+AVAILABLE=$(echo "$RESPONSE" | sed '$d' | jq '.available_plans | length')
+if [ "$STATUS" = "400" ] && [ "$AVAILABLE" = "2" ]; then
+  echo "   ✅ Sin plan se rechazó con 400 y se listan los 2 planes disponibles"
+else
+  echo "   ❌ Se esperaba 400 con 2 planes disponibles, se obtuvo $STATUS ($AVAILABLE planes)"
+  FAILED=1
+fi
+
+RESPONSE=$(curl -s -X POST "$API_URL/api/parse" \
+  -H "Content-Type: application/json" \
+  -d "$(jq -n --rawfile history "$FIXTURES/historia_academica_dos_planes.txt" '{academic_history_text: $history}')")
+MIXED=$(echo "$RESPONSE" | jq '{materias: (.subjects | length), resumen: (.credit_summary | length), aviso: ([.warnings[] | select(.code == "multiple_plans")] | length)}' -c)
+if [ "$MIXED" = '{"materias":0,"resumen":0,"aviso":1}' ]; then
+  echo "   ✅ La vista previa no mezcla los planes: cada uno está en plans"
+else
+  echo "   ❌ La vista previa mezcla los planes: $MIXED"
+  FAILED=1
+fi
+
+echo ""
+echo "🚫 Pidiendo un plan que no existe"
+STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "$API_URL/api/api-compare" \
+  -H "Content-Type: application/json" \
+  -d "$(jq -n --rawfile history "$FIXTURES/historia_academica_dos_planes.txt" --arg career "$CAREER" \
+    '{academic_history_text: $history, target_career_code: $career, plan: "MEDICINA"}')")
+if [ "$STATUS" = "400" ]; then
+  echo "   ✅ El plan inexistente se rechazó con 400"
+else
+  echo "   ❌ Se esperaba 400, se obtuvo $STATUS"
+  FAILED=1
+fi
+
+echo ""
+echo "🎓 Doble titulación usando el plan de Minas como origen"
+RESPONSE=$(curl -s -X POST "$API_URL/api/doble-titulacion" \
+  -H "Content-Type: application/json" \
+  -d "$(jq -n --rawfile origen "$FIXTURES/historia_academica_dos_planes.txt" --rawfile doble "$FIXTURES/historia_academica_incompleta.txt" --arg career "$CAREER" \
+    '{historia_origen: $origen, historia_doble: $doble, codigo_carrera_objetivo: $career, plan_origen: "ingeniería de minas y metalurgia"}')")
+if [ "$(echo "$RESPONSE" | jq -r '.plan_origen')" = "INGENIERÍA DE MINAS Y METALURGIA" ]; then
+  echo "   ✅ Plan de origen: $(echo "$RESPONSE" | jq -r '.plan_origen') (disponibles: $(echo "$RESPONSE" | jq -r '.planes_origen | join(", ")'))"
+else
+  echo "   ❌ No se usó el plan de Minas: $(echo "$RESPONSE" | jq -c '{error, plan_origen, planes_origen}')"
+  FAILED=1
+fi
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
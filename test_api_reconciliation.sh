@@ -0,0 +1,110 @@
+#!/bin/bash
+
+# Script para probar la conciliación del resumen de créditos del SIA con el resumen calculado
+# Crea una carrera de prueba con los créditos exigidos del plan de testdata/historia_academica_sia.txt
+# (Ingeniería de Minas y Metalurgia) y compara la historia del fixture contra ella.
+# Los códigos de las materias se cambian por códigos únicos de la corrida porque el catálogo de
+# materias es global y el plan de ISIS ya tiene varias de las materias del fixture
+
+API_URL=${API_URL:-http://localhost:8080}
+FIXTURES="$(dirname "$0")/testdata"
+RUN=$(date +%s | tail -c 5)
+CAREER="REC$RUN"
+FAILED=0
+
+echo "🧪 Probando la conciliación del resumen de créditos..."
+echo ""
+
+# Código de la historia -> código de prueba, tipología y créditos (vacío: la materia no es del plan)
+MAPPING="3010435:01:FUND. OBLIGATORIA:3
+1000003-M:02:FUND. OBLIGATORIA:4
+1000005-M:03:FUND. OBLIGATORIA:4
+1000019-M:04:FUND. OBLIGATORIA:4
+1000004-M:05:FUND. OBLIGATORIA:4
+1000008-M:06:FUND. OBLIGATORIA:4
+3006829:07:FUND. OBLIGATORIA:3
+3007309:08:FUND. OPTATIVA:3
+3007476:09:DISCIPLINAR OBLIGATORIA:1
+3007022:10:DISCIPLINAR OPTATIVA:3
+3010348:11::
+1000089-O:12::
+1000002-M:13::
+1000001-M:14::
+1000044-M:15::
+1000045-M:16::"
+
+HISTORY=$(cat "$FIXTURES/historia_academica_sia.txt")
+SUBJECTS="[]"
+while IFS=: read -r ORIGINAL SUFFIX TYPE CREDITS; do
+  CODE="98$RUN$SUFFIX"
+  HISTORY=${HISTORY//"($ORIGINAL)"/"($CODE)"}
+  if [ -n "$TYPE" ]; then
+    SUBJECTS=$(echo "$SUBJECTS" | jq --arg code "$CODE" --arg type "$TYPE" --argjson credits "$CREDITS" \
+      '. + [{code: $code, name: ("Materia " + $code), type: $type, credits: $credits}]')
+  fi
+done <<< "$MAPPING"
+
+echo "🏗️  Creando la carrera de prueba $CAREER"
+CAREER_ID=$(curl -s -X POST "$API_URL/api/careers" \
+  -H "Content-Type: application/json" \
+  -d "{\"name\": \"Conciliación $RUN\", \"code\": \"$CAREER\", \"description\": \"Carrera de prueba para la conciliación de créditos\"}" | jq '.career.ID')
+
+PLAN=$(jq -n --argjson career "$CAREER_ID" --argjson subjects "$SUBJECTS" '{
+  career_id: $career,
+  version: "2019-1",
+  fund_obligatoria_credits: 29,
+  fund_optativa_credits: 16,
+  dis_obligatoria_credits: 72,
+  dis_optativa_credits: 21,
+  libre_credits: 36,
+  trabajo_grado_credits: 6,
+  subjects: $subjects
+}' | curl -s -X POST "$API_URL/api/complete-study-plan" -H "Content-Type: application/json" -d @-)
+
+TOTAL_CREDITS=$(echo "$PLAN" | jq '.study_plan.TotalCredits')
+if [ "$TOTAL_CREDITS" = "180" ]; then
+  echo "   ✅ Plan creado con 180 créditos (incluye 6 de trabajo de grado)"
+else
+  echo "   ❌ No se pudo crear el plan de prueba: $(echo "$PLAN" | jq -c '.error // .study_plan.TotalCredits')"
+  exit 1
+fi
+
+echo ""
+echo "📤 /api/api-compare con historia_academica_sia.txt"
+RESPONSE=$(jq -n --arg text "$HISTORY" --arg career "$CAREER" '{academic_history_text: $text, target_career_code: $career}' | \
+  curl -s -X POST "$API_URL/api/api-compare" -H "Content-Type: application/json" -d @-)
+RECONCILIATION=$(echo "$RESPONSE" | jq '.credit_reconciliation')
+
+echo "$RECONCILIATION" | jq -r '.discrepancies[] | "   ⚠️  \(.tipologia) \(.field): SIA \(.sia), calculado \(.computed)"'
+
+UNMATCHED=$(echo "$RECONCILIATION" | jq -c '.unmatched')
+if [ "$UNMATCHED" = "[]" ]; then
+  echo "   ✅ Todas las filas del SIA tienen equivalente (incluye TRABAJO DE GRADO y TOTAL ESTUDIANTE)"
+else
+  echo "   ❌ Filas del SIA sin equivalente: $UNMATCHED"
+  FAILED=1
+fi
+
+TRABAJO_GRADO=$(echo "$RESPONSE" | jq -c '.comparison_result.credits_summary.trabajo_grado | [.required, .completed]')
+if [ "$TRABAJO_GRADO" = "[6,0]" ]; then
+  echo "   ✅ Trabajo de grado: 6 créditos exigidos, 0 aprobados"
+else
+  echo "   ❌ Fila de trabajo de grado inesperada: $TRABAJO_GRADO"
+  FAILED=1
+fi
+
+CONSISTENT=$(echo "$RECONCILIATION" | jq '.consistent')
+if [ "$CONSISTENT" = "true" ]; then
+  echo "   ✅ El resumen calculado coincide con el del SIA en las $(echo "$RECONCILIATION" | jq '.sia | length') filas"
+else
+  echo "   ❌ El resumen calculado no coincide con el del SIA: $(echo "$RESPONSE" | jq -c '.error // .credit_reconciliation.discrepancies')"
+  FAILED=1
+fi
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
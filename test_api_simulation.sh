@@ -0,0 +1,105 @@
+#!/bin/bash
+
+# Script para probar la simulación de cambios hipotéticos sobre una comparación
+# Usa el plan de Ingeniería de Sistemas (ISIS) cargado con scripts/seed_ing_sistemas.go
+
+API_URL=${API_URL:-http://localhost:8080}
+FAILED=0
+
+echo "🧪 Probando la simulación de escenarios..."
+echo ""
+
+HISTORY='{
+  "career_code": "ISIS",
+  "subjects": [
+    {"code": "1000004-M", "name": "Cálculo Diferencial", "credits": 4, "type": "FUND. OBLIGATORIA", "grade": 4.0, "status": "APROBADA", "semester": "2023-1S"},
+    {"code": "3010435", "name": "Fundamentos de Programación", "credits": 3, "type": "DISCIPLINAR OBLIGATORIA", "grade": 4.3, "status": "APROBADA", "semester": "2023-1S"}
+  ]
+}'
+
+simulate() {
+  curl -s -X POST "$API_URL/api/simulate" \
+    -H "Content-Type: application/json" \
+    -d "{\"history\": $HISTORY, $1}"
+}
+
+# Cálculo Integral y Álgebra Lineal el próximo semestre; se retira Fundamentos de Programación
+RESPONSE=$(simulate '"add": [{"code": "1000005-M", "grade": 4.5}, {"code": "1000003-M", "grade": 3.5}], "drop": ["3010435"]')
+
+echo "📊 Créditos por tipología (antes → después):"
+echo "$RESPONSE" | jq -r '.simulation.diff.credits[] | "   \(.tipologia): \(.completed_before) → \(.completed_after) (\(if .completed_delta >= 0 then "+" else "" end)\(.completed_delta))"'
+echo "$RESPONSE" | jq -r '.simulation.diff | "   Avance: \(.completion_before | . * 10 | round / 10)% → \(.completion_after | . * 10 | round / 10)%, pendientes: \(.pending_before) → \(.pending_after)"'
+echo ""
+
+FUND=$(echo "$RESPONSE" | jq '.simulation.diff.credits[] | select(.tipologia == "FUND. OBLIGATORIA") | .completed_delta')
+DIS=$(echo "$RESPONSE" | jq '.simulation.diff.credits[] | select(.tipologia == "DISCIPLINAR OBLIGATORIA") | .completed_delta')
+TOTAL=$(echo "$RESPONSE" | jq '.simulation.diff.credits[] | select(.tipologia == "TOTAL") | .completed_delta')
+if [ "$FUND" = "8" ] && [ "$DIS" = "-3" ] && [ "$TOTAL" = "5" ]; then
+  echo "   ✅ Fundamentación obligatoria +8, disciplinar obligatoria -3, total +5"
+else
+  echo "   ❌ Diferencias inesperadas: fundamentación $FUND, disciplinar $DIS, total $TOTAL"
+  FAILED=1
+fi
+
+COVERED=$(echo "$RESPONSE" | jq -c '.simulation.diff.newly_covered | sort')
+PENDING=$(echo "$RESPONSE" | jq -c '.simulation.diff.newly_pending')
+if [ "$COVERED" = '["1000003-M","1000005-M"]' ] && [ "$PENDING" = '["3010435"]' ]; then
+  echo "   ✅ Dejan de estar pendientes $COVERED y vuelve a estar pendiente $PENDING"
+else
+  echo "   ❌ Materias pendientes inesperadas: cubiertas $COVERED, pendientes $PENDING"
+  FAILED=1
+fi
+
+AVERAGE=$(echo "$RESPONSE" | jq '.simulation.diff | "\(.hypothetical_credits) \(.hypothetical_average)"' -r)
+if [ "$AVERAGE" = "8 4" ]; then
+  echo "   ✅ Promedio ponderado de las notas esperadas: 4.0 en 8 créditos"
+else
+  echo "   ❌ Promedio hipotético inesperado (créditos promedio): $AVERAGE"
+  FAILED=1
+fi
+
+APPROVED=$(echo "$RESPONSE" | jq -r '.simulation.simulated.equivalent_subjects[] | select(.code == "1000005-M") | .status')
+BASELINE=$(echo "$RESPONSE" | jq '.simulation.baseline.missing_subjects | map(.code) | index("1000005-M") != null')
+if [ -n "$APPROVED" ] && [ "$BASELINE" = "true" ]; then
+  echo "   ✅ La comparación base no cambia y la simulada aprueba Cálculo Integral"
+else
+  echo "   ❌ Las comparaciones base y simulada no son las esperadas"
+  FAILED=1
+fi
+
+echo ""
+echo "📉 Nota esperada reprobatoria"
+FAILING=$(simulate '"add": [{"code": "1000005-M", "grade": 2.5}]' | jq -c '.simulation.diff.newly_covered')
+if [ "$FAILING" = "[]" ]; then
+  echo "   ✅ Con 2.5 Cálculo Integral sigue pendiente"
+else
+  echo "   ❌ Se esperaba que ninguna materia quedara cubierta: $FAILING"
+  FAILED=1
+fi
+
+echo ""
+echo "🚫 Cambios inválidos"
+for CASE in '"drop": ["9999999"]|materia retirada que no está en la historia' \
+            '"add": [{"code": "1000005-M"}]|materia sin nota esperada' \
+            '"add": [{"code": "9999999", "grade": 4.0}]|materia fuera del plan sin créditos ni tipología' \
+            '"target_plan_version": "1999-9"|plan objetivo inexistente'; do
+  BODY="${CASE%%|*}"
+  LABEL="${CASE#*|}"
+  STATUS=$(curl -s -o /dev/null -w "%{http_code}" -X POST "$API_URL/api/simulate" \
+    -H "Content-Type: application/json" \
+    -d "{\"history\": $HISTORY, $BODY}")
+  if [ "$STATUS" = "400" ]; then
+    echo "   ✅ Rechazada con 400: $LABEL"
+  else
+    echo "   ❌ Se esperaba 400 para $LABEL, se obtuvo $STATUS"
+    FAILED=1
+  fi
+done
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
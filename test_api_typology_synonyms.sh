@@ -0,0 +1,77 @@
+#!/bin/bash
+
+# Script para probar los sinónimos de tipologías: una etiqueta sin registrar genera la advertencia
+# unknown_tipologia y, al registrarla como sinónimo, el parser la reconoce
+
+API_URL=${API_URL:-http://localhost:8080}
+FIXTURE="$(dirname "$0")/testdata/historia_academica_incompleta.txt"
+LABEL="ASIGNATURA ELEGIBLE"
+FAILED=0
+
+echo "🧪 Probando sinónimos de tipologías..."
+echo ""
+
+# La materia 3000001 usa una etiqueta de posgrado en lugar de LIBRE ELECCIÓN
+HISTORY=$(awk -v label="$LABEL" '/^CURSO X/ {found=1} found && $0 == "LIBRE ELECCIÓN" {print label; found=0; next} {print}' "$FIXTURE")
+parse() {
+  curl -s -X POST "$API_URL/api/parse" \
+    -H "Content-Type: application/json" \
+    -d "$(jq -n --arg history "$HISTORY" '{academic_history_text: $history}')"
+}
+
+echo "📤 Parseo con la etiqueta \"$LABEL\" sin registrar"
+BEFORE=$(parse)
+UNKNOWN=$(echo "$BEFORE" | jq -r '[.warnings[] | select(.code == "unknown_tipologia") | .subject] | join(",")')
+if [ "$UNKNOWN" = "3000001" ]; then
+  echo "   ✅ Advertencia unknown_tipologia para 3000001"
+else
+  echo "   ❌ Se esperaba la advertencia unknown_tipologia para 3000001"
+  echo "$BEFORE" | jq '.warnings'
+  FAILED=1
+fi
+
+echo "📝 Registrando \"$LABEL\" como DISCIPLINAR OPTATIVA"
+CREATED=$(curl -s -X POST "$API_URL/api/typology-synonyms" \
+  -H "Content-Type: application/json" \
+  -d "$(jq -n --arg label "$LABEL" '{label: $label, tipologia: "DISCIPLINAR OPTATIVA", notes: "Tipología de posgrado"}')")
+SYNONYM_ID=$(echo "$CREATED" | jq '.synonym.ID')
+if [ "$SYNONYM_ID" = "null" ]; then
+  echo "   ❌ No se pudo crear el sinónimo"
+  echo "$CREATED" | jq '.'
+  exit 1
+fi
+
+echo "📤 Parseo con la etiqueta registrada"
+AFTER=$(parse)
+TYPE=$(echo "$AFTER" | jq -r '.subjects[] | select(.code == "3000001") | .type')
+if [ "$TYPE" = "DISCIPLINAR OPTATIVA" ]; then
+  echo "   ✅ 3000001 reconocida como DISCIPLINAR OPTATIVA"
+else
+  echo "   ❌ 3000001 quedó con tipología \"$TYPE\""
+  FAILED=1
+fi
+
+echo ""
+echo "📤 Parseo con una etiqueta que solo contiene un sinónimo"
+HISTORY=$(awk '/^CURSO X/ {found=1} found && $0 == "LIBRE ELECCIÓN" {print "DISCIPLINAR OPTATIVA POSGRADO"; found=0; next} {print}' "$FIXTURE")
+PARTIAL=$(parse)
+TYPE=$(echo "$PARTIAL" | jq -r '.subjects[] | select(.code == "3000001") | .type')
+UNKNOWN=$(echo "$PARTIAL" | jq -r '[.warnings[] | select(.code == "unknown_tipologia") | .subject] | join(",")')
+if [ "$UNKNOWN" = "3000001" ] && [ "$TYPE" != "DISCIPLINAR OPTATIVA" ]; then
+  echo "   ✅ \"DISCIPLINAR OPTATIVA POSGRADO\" no se adivina: advertencia unknown_tipologia"
+else
+  echo "   ❌ La etiqueta se interpretó como \"$TYPE\" sin advertencia"
+  FAILED=1
+fi
+
+echo ""
+echo "🧹 Eliminando el sinónimo de prueba"
+curl -s -X DELETE "$API_URL/api/typology-synonyms/$SYNONYM_ID" | jq -r '.message // .error'
+
+echo ""
+if [ "$FAILED" = "0" ]; then
+  echo "✅ Prueba completada"
+else
+  echo "❌ Prueba con errores"
+  exit 1
+fi
@@ -0,0 +1,225 @@
+"""Genera los PDF de prueba de la historia académica usados por test_api_pdf.sh.
+
+historia_academica_sia.pdf: fuente Helvetica (WinAnsiEncoding), cada celda ubicada con Tm.
+historia_academica_sia_type0.pdf: fuente Type0 Identity-H con mapa ToUnicode, celdas con Td
+relativo, arreglos TJ con ajuste de espaciado y objetos comprimidos en un flujo /ObjStm.
+
+Uso: python3 testdata/generar_pdfs.py testdata
+"""
+import zlib, sys
+
+HEADER = [
+    "Historia Académica",
+    "Plan de estudios",
+    "INGENIERÍA DE MINAS Y METALURGIA",
+    ["Facultad: FACULTAD DE MINAS", "Hist. Acad.: 202", "ESTADO BLOQUEADO"],
+    "Causas de bloqueo: B - 41 Readmisión Res.235 de 2009 Vic. Académica",
+    "4.1 (Acumulado) Pregrado - Promedio académico 2021-2S",
+    "4.1 (Acumulado) Pregrado - P.A.P.A 2021-2S",
+    ["Asignaturas", "Créditos", "Tipo", "Periodo", "Calificación"],
+]
+SUBJECTS = [
+    ["Fundamentos de programación (3010435)", "3", "FUND. OBLIGATORIA", "2021-2S Ordinaria", "4.6", "APROBADA"],
+    ["ÁLGEBRA LINEAL (1000003-M)", "4", "FUND. OBLIGATORIA", "2021-1S Ordinaria", "4.0", "APROBADA"],
+    ["CÁLCULO INTEGRAL (1000005-M)", "4", "FUND. OBLIGATORIA", "2021-1S Ordinaria", "3.5", "APROBADA"],
+    ["FÍSICA MECÁNICA (1000019-M)", "4", "FUND. OBLIGATORIA", "2021-1S Ordinaria", "3.7", "APROBADA"],
+    ["Cátedra estudiantil: universidad, participación y sociedad (3010348)", "3", "LIBRE ELECCIÓN", "2021-1S Ordinaria", "4.5", "APROBADA"],
+    ["CÁLCULO DIFERENCIAL (1000004-M)", "4", "FUND. OBLIGATORIA", "2020-2S Ordinaria", "4.9", "APROBADA"],
+    ["GEOMETRÍA VECTORIAL Y ANALÍTICA (1000008-M)", "4", "FUND. OBLIGATORIA", "2020-2S Ordinaria", "3.6", "APROBADA"],
+    ["CIENCIA DE LOS MATERIALES (3007309)", "3", "FUND. OPTATIVA", "2020-2S Ordinaria", "3.5", "APROBADA"],
+    ["Introducción a la Ingeniería de Minas y Metalurgia (3007476)", "1", "DISCIPLINAR OBLIGATORIA", "2020-2S Ordinaria", "4.6", "APROBADA"],
+    ["ECOLOGÍA GENERAL (3007022)", "3", "DISCIPLINAR OPTATIVA", "2020-1S Ordinaria", "4.4", "APROBADA"],
+    ["Química general (3006829)", "3", "FUND. OBLIGATORIA", "2020-1S Ordinaria", "4.3", "APROBADA"],
+    ["LECTO-ESCRITURA (1000002-M)", "4", "NIVELACIÓN", "2020-1S Ordinaria", "4.1", "APROBADA"],
+    ["MATEMÁTICAS BÁSICAS (1000001-M)", "4", "NIVELACIÓN", "2020-1S Ordinaria", "4.1", "APROBADA"],
+    ["Cátedra nacional de inducción y preparación para la vida universitaria (1000089-O)", "2", "LIBRE ELECCIÓN", "2020-1S Ordinaria", "AP", "APROBADA"],
+    ["INGLÉS I (1000044-M)", "3", "NIVELACIÓN", "2020-1S Validacion por suficiencia", "AP", "APROBADA"],
+    ["INGLÉS II (1000045-M)", "3", "NIVELACIÓN", "2020-1S Validacion por suficiencia", "AP", "APROBADA"],
+]
+SUMMARY = [
+    "Resumen de créditos",
+    ["Tipologías", "Exigidos", "Aprobados", "Pendientes", "Inscritos", "Cursados"],
+    ["DISCIPLINAR OPTATIVA", "21", "3", "18", "0", "3"],
+    ["FUND. OBLIGATORIA", "29", "26", "3", "0", "26"],
+    ["FUND. OPTATIVA", "16", "3", "13", "0", "3"],
+    ["DISCIPLINAR OBLIGATORIA", "72", "1", "71", "0", "1"],
+    ["LIBRE ELECCIÓN", "36", "5", "31", "0", "5"],
+    ["TRABAJO DE GRADO", "6", "0", "6", "0", "0"],
+    ["TOTAL", "180", "38", "142", "0", "38"],
+    ["NIVELACIÓN", "20", "14", "6", "0", "14"],
+    ["TOTAL ESTUDIANTE", "200", "52", "148", "0", "52"],
+    "Total Créditos Excedentes 0",
+    "Porcentaje de Avance 21,1%",
+]
+COLS = [40, 330, 360, 460, 560, 590]
+
+def pages_rows():
+    p1 = HEADER + SUBJECTS[:9]
+    p2 = SUBJECTS[9:] + SUMMARY
+    return [p1, p2]
+
+def lit(b):
+    out = b"("
+    for c in b:
+        if c in b"()\\":
+            out += b"\\" + bytes([c])
+        elif c < 32 or c > 126:
+            out += b"\\%03o" % c
+        else:
+            out += bytes([c])
+    return out + b")"
+
+# ---------- Fixture 1: Helvetica WinAnsi, celdas con Tm absoluto ----------
+def content_winansi(rows):
+    out = []
+    y = 800
+    for row in rows:
+        cells = row if isinstance(row, list) else [row]
+        for i, cell in enumerate(cells):
+            x = COLS[i] if len(cells) > 1 else 40
+            out.append(b"BT /F1 7 Tf 1 0 0 1 %d %d Tm %s Tj ET" % (x, y, lit(cell.encode("cp1252"))))
+        y -= 14
+    return b"\n".join(out)
+
+def write_classic(path, objects):
+    # objects: list of bytes bodies; object i+1
+    out = b"%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"
+    offsets = []
+    for i, body in enumerate(objects):
+        offsets.append(len(out))
+        out += b"%d 0 obj\n" % (i + 1) + body + b"\nendobj\n"
+    xref = len(out)
+    out += b"xref\n0 %d\n0000000000 65535 f \n" % (len(objects) + 1)
+    for o in offsets:
+        out += b"%010d 00000 n \n" % o
+    out += b"trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n" % (len(objects) + 1, xref)
+    open(path, "wb").write(out)
+
+def stream(dict_extra, data, compress=True):
+    if compress:
+        data = zlib.compress(data)
+        dict_extra += b" /Filter /FlateDecode"
+    return b"<< /Length %d%s >>\nstream\n" % (len(data), dict_extra) + data + b"\nendstream"
+
+def fixture_winansi(path):
+    pr = pages_rows()
+    objs = [None] * 7
+    objs[0] = b"<< /Type /Catalog /Pages 2 0 R >>"
+    objs[1] = b"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 5 0 R >> >> /MediaBox [0 0 612 842] >>"
+    objs[2] = b"<< /Type /Page /Parent 2 0 R /Contents 6 0 R >>"
+    objs[3] = b"<< /Type /Page /Parent 2 0 R /Contents 7 0 R >>"
+    objs[4] = b"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"
+    objs[5] = stream(b"", content_winansi(pr[0]))
+    objs[6] = stream(b"", content_winansi(pr[1]))
+    write_classic(path, objs)
+
+# ---------- Fixture 2: Type0 Identity-H + ToUnicode, Td relativo, TJ, ObjStm ----------
+def fixture_type0(path):
+    pr = pages_rows()
+    chars = sorted(set("".join(
+        c for rows in pr for row in rows for c in ("".join(row) if isinstance(row, list) else row))))
+    gid = {c: i + 3 for i, c in enumerate(chars)}  # GIDs arbitrarios
+
+    def enc(text):
+        return "".join("%04X" % gid[c] for c in text)
+
+    def tj(text):
+        # Partes con ajuste de espaciado y espacios codificados como desplazamiento
+        words = text.split(" ")
+        items = []
+        for i, w in enumerate(words):
+            if w:
+                half = len(w) // 2
+                if half:
+                    items.append("<%s> -12 <%s>" % (enc(w[:half]), enc(w[half:])))
+                else:
+                    items.append("<%s>" % enc(w))
+            if i < len(words) - 1:
+                items.append("-250")
+        return "[%s] TJ" % " ".join(items)
+
+    def content(rows):
+        out = ["BT /F1 7 Tf 40 800 Td"]
+        for r, row in enumerate(rows):
+            cells = row if isinstance(row, list) else [row]
+            prev = 40
+            for i, cell in enumerate(cells):
+                x = COLS[i] if len(cells) > 1 else 40
+                if i > 0:
+                    out.append("%d 0 Td" % (x - prev))
+                prev = x
+                out.append(tj(cell))
+            out.append("%d -14 Td" % (40 - prev))
+        out.append("ET")
+        return "\n".join(out).encode()
+
+    # bfrange para caracteres consecutivos + bfchar para el resto
+    items = sorted(gid.items(), key=lambda kv: kv[1])
+    ranges, singles = [], []
+    i = 0
+    while i < len(items):
+        j = i
+        while j + 1 < len(items) and items[j + 1][1] == items[j][1] + 1 and ord(items[j + 1][0]) == ord(items[j][0]) + 1:
+            j += 1
+        if j > i:
+            ranges.append((items[i][1], items[j][1], ord(items[i][0])))
+        else:
+            singles.append((items[i][1], ord(items[i][0])))
+        i = j + 1
+    cmap = ["/CIDInit /ProcSet findresource begin", "12 dict begin", "begincmap",
+            "/CMapName /Adobe-Identity-UCS def", "1 begincodespacerange", "<0000> <FFFF>", "endcodespacerange"]
+    if singles:
+        cmap.append("%d beginbfchar" % len(singles))
+        cmap += ["<%04X> <%04X>" % s for s in singles]
+        cmap.append("endbfchar")
+    if ranges:
+        cmap.append("%d beginbfrange" % len(ranges))
+        cmap += ["<%04X> <%04X> <%04X>" % r for r in ranges]
+        cmap.append("endbfrange")
+    cmap += ["endcmap", "CMapName currentdict /CMap defineresource pop", "end", "end"]
+    cmap = "\n".join(cmap).encode()
+
+    # Objetos: 1 catálogo, 2 páginas, 3-4 página, 5 fuente Type0, 6 CIDFont (en ObjStm),
+    # 7 ToUnicode, 8-9 contenidos, 10 ObjStm, 11 xref stream
+    compressed = {
+        1: b"<< /Type /Catalog /Pages 2 0 R >>",
+        2: b"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 612 842] >>",
+        3: b"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 5 0 R >> >> /Contents [8 0 R] >>",
+        4: b"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 5 0 R >> >> /Contents 9 0 R >>",
+        5: b"<< /Type /Font /Subtype /Type0 /BaseFont /ArialMT /Encoding /Identity-H /DescendantFonts [6 0 R] /ToUnicode 7 0 R >>",
+        6: b"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /ArialMT /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /DW 500 >>",
+    }
+    header, body = b"", b""
+    for num, data in compressed.items():
+        header += b"%d %d " % (num, len(body))
+        body += data + b"\n"
+    objstm = zlib.compress(header + body)
+
+    out = b"%PDF-1.5\n%\xe2\xe3\xcf\xd3\n"
+    offsets = {}
+    direct = {
+        7: stream(b"", cmap),
+        8: stream(b"", content(pr[0])),
+        9: stream(b"", content(pr[1])),
+        10: b"<< /Type /ObjStm /N %d /First %d /Length %d /Filter /FlateDecode >>\nstream\n" % (len(compressed), len(header), len(objstm)) + objstm + b"\nendstream",
+    }
+    for num in sorted(direct):
+        offsets[num] = len(out)
+        out += b"%d 0 obj\n" % num + direct[num] + b"\nendobj\n"
+    xref_off = len(out)
+    rows = [bytes([0, 0, 0, 0, 0xFF, 0xFF])]
+    idx = {n: i for i, n in enumerate(compressed)}
+    for num in range(1, 12):
+        if num in compressed:
+            rows.append(bytes([2]) + (10).to_bytes(3, "big") + idx[num].to_bytes(2, "big"))
+        elif num in offsets:
+            rows.append(bytes([1]) + offsets[num].to_bytes(3, "big") + (0).to_bytes(2, "big"))
+        else:
+            rows.append(bytes([1]) + xref_off.to_bytes(3, "big") + (0).to_bytes(2, "big"))
+    xdata = zlib.compress(b"".join(rows))
+    out += b"11 0 obj\n<< /Type /XRef /Size 12 /W [1 3 2] /Root 1 0 R /Length %d /Filter /FlateDecode >>\nstream\n" % len(xdata) + xdata + b"\nendstream\nendobj\n"
+    out += b"startxref\n%d\n%%%%EOF\n" % xref_off
+    open(path, "wb").write(out)
+
+fixture_winansi(sys.argv[1] + "/historia_academica_sia.pdf")
+fixture_type0(sys.argv[1] + "/historia_academica_sia_type0.pdf")
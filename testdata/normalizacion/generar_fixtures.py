@@ -0,0 +1,58 @@
+"""Genera las variantes de testdata/historia_academica_sia.txt usadas por test_api_normalizacion.sh.
+
+Cada variante introduce un solo problema de codificación o formato; después de la normalización
+todas deben producir las mismas materias que el texto original.
+
+mojibake.txt      texto UTF-8 leído como Windows-1252 ("ELECCIÃ“N")
+nfd.txt           tildes descompuestas (letra + marca combinante)
+espacios.txt      BOM, espacios no separables, espacios de ancho cero y fin de línea CRLF
+coma_decimal.txt  calificaciones con coma decimal ("4,6")
+
+Uso: python3 testdata/normalizacion/generar_fixtures.py testdata
+"""
+import os, re, sys, unicodedata
+
+
+def mojibake(text):
+    # Los bytes sin carácter en Windows-1252 se conservan como controles C1, igual que los navegadores
+    out = []
+    for b in text.encode("utf-8"):
+        try:
+            out.append(bytes([b]).decode("cp1252"))
+        except UnicodeDecodeError:
+            out.append(chr(b))
+    return "".join(out)
+
+
+def espacios(text):
+    lines = []
+    for i, line in enumerate(text.split("\n")):
+        if i % 3 == 0:
+            line = line.replace(" ", "\u00a0")
+        elif i % 3 == 1 and line:
+            line = line + "\u200b  "
+        lines.append(line)
+    return "\ufeff" + "\r\n".join(lines)
+
+
+def coma_decimal(text):
+    return re.sub(r"(?m)^(\d)\.(\d)$", r"\1,\2", text)
+
+
+def main(testdata):
+    with open(os.path.join(testdata, "historia_academica_sia.txt"), encoding="utf-8") as f:
+        text = f.read()
+
+    variants = {
+        "mojibake.txt": mojibake(text),
+        "nfd.txt": unicodedata.normalize("NFD", text),
+        "espacios.txt": espacios(text),
+        "coma_decimal.txt": coma_decimal(text),
+    }
+    for name, content in variants.items():
+        with open(os.path.join(testdata, "normalizacion", name), "w", encoding="utf-8", newline="") as f:
+            f.write(content)
+
+
+if __name__ == "__main__":
+    main(sys.argv[1] if len(sys.argv) > 1 else "testdata")